@@ -0,0 +1,134 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	settingAlertThresholds = "budget_alert_thresholds"
+	defaultAlertThresholds = "80, 100"
+)
+
+func getSetting(db *sql.DB, key, fallback string) string {
+	var value string
+	if err := db.QueryRow("SELECT value FROM app_settings WHERE key = ?", key).Scan(&value); err != nil {
+		return fallback
+	}
+	return value
+}
+
+func setSetting(db *sql.DB, key, value string) error {
+	_, err := db.Exec("INSERT OR REPLACE INTO app_settings (key, value) VALUES (?, ?)", key, value)
+	return err
+}
+
+// parseThresholds разбирает строку вида "80, 100" в отсортированный список процентов
+func parseThresholds(text string) ([]float64, error) {
+	var thresholds []float64
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(part), "%"))
+		if part == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf(T("неверный порог %q"), part)
+		}
+		thresholds = append(thresholds, value)
+	}
+	if len(thresholds) == 0 {
+		return nil, errors.New(T("не указаны пороги уведомлений"))
+	}
+	sort.Float64s(thresholds)
+	return thresholds, nil
+}
+
+func alertThresholds(db *sql.DB) []float64 {
+	thresholds, err := parseThresholds(getSetting(db, settingAlertThresholds, defaultAlertThresholds))
+	if err != nil {
+		thresholds, _ = parseThresholds(defaultAlertThresholds)
+	}
+	return thresholds
+}
+
+// checkBudgetAlerts возвращает уведомления о бюджетах, пересекших пороги в текущем периоде.
+// Каждый порог записывается в budget_alerts один раз за период, поэтому повторно
+// уведомление не придет. Пустой список categories означает все бюджеты.
+func checkBudgetAlerts(db *sql.DB, now time.Time, categories []string) ([]*fyne.Notification, error) {
+	budgets, err := loadBudgets(db)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool)
+	for _, c := range categories {
+		wanted[c] = true
+	}
+	thresholds := alertThresholds(db)
+
+	var notifications []*fyne.Notification
+	for _, b := range budgets {
+		if len(wanted) > 0 && !wanted[b.Category] {
+			continue
+		}
+		p, err := computeBudgetProgress(db, b, now)
+		if err != nil {
+			return nil, err
+		}
+
+		// Сообщаем только о наибольшем из впервые пересеченных порогов,
+		// но отмечаем все, чтобы меньшие не всплыли позже
+		reached := 0.0
+		for _, threshold := range thresholds {
+			if p.Percent < threshold {
+				break
+			}
+			res, err := db.Exec(`
+				INSERT OR IGNORE INTO budget_alerts (category, period_start, threshold, sent_at)
+				VALUES (?, ?, ?, ?)
+			`, b.Category, p.Start, threshold, now.Format("2006-01-02 15:04:05"))
+			if err != nil {
+				return nil, err
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				reached = threshold
+			}
+		}
+		if reached == 0 {
+			continue
+		}
+
+		title := T("Бюджет почти исчерпан")
+		if reached >= 100 {
+			title = T("Превышен бюджет")
+		}
+		notifications = append(notifications, &fyne.Notification{
+			Title: title,
+			Content: Tf("%s: израсходовано %.0f%% (%s из %s, %s – %s)",
+				b.Category, p.Percent, money(p.Spent), money(p.Limit), formatDate(p.Start), formatDate(p.End)),
+		})
+	}
+	return notifications, nil
+}
+
+// sendBudgetAlerts проверяет бюджеты указанных категорий и отправляет уведомления
+func sendBudgetAlerts(a fyne.App, db *sql.DB, categories ...string) {
+	notifications, err := checkBudgetAlerts(db, time.Now(), categories)
+	if err != nil {
+		a.SendNotification(&fyne.Notification{
+			Title:   T("Ошибка"),
+			Content: T("Не удалось проверить бюджеты: ") + err.Error(),
+		})
+		return
+	}
+	for _, n := range notifications {
+		a.SendNotification(n)
+	}
+}
@@ -0,0 +1,556 @@
+package main
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiTokenEnv — переменная окружения с токеном, если он не задан флагом
+const apiTokenEnv = "FINANCE_API_TOKEN"
+
+// Размер страницы списка транзакций
+const (
+	apiDefaultLimit = 50
+	apiMaxLimit     = 500
+)
+
+// Ограничения на входящие запросы: медленный или слишком большой запрос
+// не должен держать соединение и память сервера
+const (
+	apiReadHeaderTimeout = 10 * time.Second
+	apiReadTimeout       = 30 * time.Second
+	apiWriteTimeout      = 60 * time.Second
+	apiMaxBodyBytes      = 1 << 20
+)
+
+// apiServer обслуживает HTTP API поверх той же базы, что и окно
+type apiServer struct {
+	db    *sql.DB
+	token string
+}
+
+// TransactionPage — страница списка транзакций
+type TransactionPage struct {
+	Items  []Transaction
+	Total  int
+	Limit  int
+	Offset int
+}
+
+// CategoryInfo — категория с типом и числом строк транзакций
+type CategoryInfo struct {
+	Type     string
+	Category string
+	Count    int
+}
+
+func cliServe(db *sql.DB, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("serve", stderr)
+	addr := fs.String("addr", "127.0.0.1:8080", T("адрес для входящих подключений"))
+	token := fs.String("token", os.Getenv(apiTokenEnv), T("токен доступа (или переменная ")+apiTokenEnv+")")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *token == "" {
+		return fmt.Errorf(T("задайте токен доступа флагом -token или переменной %s"), apiTokenEnv)
+	}
+	fmt.Fprintf(stdout, T("API доступно на http://%s/api/ (описание: /api/openapi.json)\n"), *addr)
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newAPIServer(db, *token),
+		ReadHeaderTimeout: apiReadHeaderTimeout,
+		ReadTimeout:       apiReadTimeout,
+		WriteTimeout:      apiWriteTimeout,
+	}
+	return server.ListenAndServe()
+}
+
+func newAPIServer(db *sql.DB, token string) http.Handler {
+	s := &apiServer{db: db, token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	mux.Handle("/api/transactions", s.authorized(s.handleTransactions))
+	mux.Handle("/api/transactions/", s.authorized(s.handleTransaction))
+	mux.Handle("/api/categories", s.authorized(s.handleCategories))
+	mux.Handle("/api/budgets", s.authorized(s.handleBudgets))
+	mux.Handle("/api/statistics", s.authorized(s.handleStatistics))
+	return mux
+}
+
+// authorized пропускает запрос дальше только с заголовком "Authorization: Bearer <токен>"
+// и ограничивает размер его тела
+func (s *apiServer) authorized(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPIError(w, http.StatusUnauthorized, errors.New(T("неверный токен")))
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, apiMaxBodyBytes)
+		next(w, r)
+	})
+}
+
+// readAPIJSON разбирает тело запроса; при ошибке отвечает клиенту сам и возвращает false
+func readAPIJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeAPIError(w, http.StatusRequestEntityTooLarge, fmt.Errorf(T("тело запроса больше %d байт"), tooLarge.Limit))
+		return false
+	}
+	writeAPIError(w, http.StatusBadRequest, fmt.Errorf(T("неверный JSON: %w"), err))
+	return false
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeAPIError(w, http.StatusMethodNotAllowed, errors.New(T("метод не поддерживается")))
+}
+
+// queryPeriod читает период из параметров period, year, month, from, to -
+// те же значения, что у флагов командной строки
+func queryPeriod(q url.Values) (periodFilter, error) {
+	p := periodFlags{kind: q.Get("period"), year: q.Get("year"), start: q.Get("from"), end: q.Get("to")}
+	if p.kind == "" {
+		p.kind = "all"
+	}
+	if month := q.Get("month"); month != "" {
+		m, err := strconv.Atoi(month)
+		if err != nil {
+			return periodFilter{}, fmt.Errorf(T("неверный месяц %q"), month)
+		}
+		p.month = m
+	}
+	return p.filter()
+}
+
+func queryInt(q url.Values, name string, def int) (int, error) {
+	value := q.Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf(T("неверное значение %s"), name)
+	}
+	return n, nil
+}
+
+func (s *apiServer) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		period, err := queryPeriod(q)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		limit, err := queryInt(q, "limit", apiDefaultLimit)
+		if err == nil && (limit == 0 || limit > apiMaxLimit) {
+			err = fmt.Errorf(T("limit должен быть от 1 до %d"), apiMaxLimit)
+		}
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		offset, err := queryInt(q, "offset", 0)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		// Необязательные фильтры по типу и категории (в том числе категории части)
+		kind, category := cliTypes[q.Get("type")], q.Get("category")
+		if q.Get("type") != "" && kind == "" {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf(T("неизвестный тип %q"), q.Get("type")))
+			return
+		}
+		page, err := loadTransactionPage(s.db, period, kind, category, limit, offset)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, page)
+	case http.MethodPost:
+		var t Transaction
+		if !readAPIJSON(w, r, &t) {
+			return
+		}
+		if t.Date == "" {
+			t.Date = time.Now().Format("2006-01-02")
+		}
+		t.Type = typeCode(t.Type)
+		t, err := applyStoredMarkup(s.db, resetIncoming(t))
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err := validateTransaction(s.db, t); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		id, err := insertTransaction(s.db, t)
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		t.ID = int(id)
+		writeAPIJSON(w, http.StatusCreated, t)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// loadTransactionPage отбирает и считает транзакции в SQL, а части загружает только
+// для транзакций страницы. Категория ищется и среди частей разделенных транзакций.
+func loadTransactionPage(db *sql.DB, period periodFilter, kind, category string, limit, offset int) (TransactionPage, error) {
+	page := TransactionPage{Items: []Transaction{}, Limit: limit, Offset: offset}
+	cond, args, err := period.condition("date")
+	if err != nil {
+		return page, err
+	}
+	if kind != "" {
+		cond += " AND type = ?"
+		args = append(args, kind)
+	}
+	if category != "" {
+		cond += " AND id IN (SELECT transaction_id FROM transaction_lines WHERE category = ?)"
+		args = append(args, category)
+	}
+	if err := db.QueryRow("SELECT COUNT(*) FROM transactions WHERE "+cond, args...).Scan(&page.Total); err != nil {
+		return page, err
+	}
+
+	rows, err := db.Query(`
+		SELECT id, date, type, category, amount, description, account_id, transfer_account_id, tags, payee_id, status
+		FROM transactions WHERE `+cond+`
+		ORDER BY date DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+	byID := make(map[int]int)
+	var ids []string
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.Date, &t.Type, &t.Category, &t.Amount, &t.Description, &t.AccountID,
+			&t.TransferAccountID, &t.Tags, &t.PayeeID, &t.Status); err != nil {
+			return page, err
+		}
+		byID[t.ID] = len(page.Items)
+		ids = append(ids, strconv.Itoa(t.ID))
+		page.Items = append(page.Items, t)
+	}
+	if err := rows.Err(); err != nil {
+		return page, err
+	}
+	if len(ids) == 0 {
+		return page, nil
+	}
+
+	// Номера взяты из базы, поэтому их можно подставить в запрос как есть
+	splitRows, err := db.Query(`
+		SELECT transaction_id, category, amount, note FROM transaction_splits
+		WHERE transaction_id IN (` + strings.Join(ids, ", ") + `)
+		ORDER BY id
+	`)
+	if err != nil {
+		return page, err
+	}
+	defer splitRows.Close()
+	for splitRows.Next() {
+		var id int
+		var line SplitLine
+		if err := splitRows.Scan(&id, &line.Category, &line.Amount, &line.Note); err != nil {
+			return page, err
+		}
+		t := &page.Items[byID[id]]
+		t.Splits = append(t.Splits, line)
+	}
+	return page, splitRows.Err()
+}
+
+func (s *apiServer) handleTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/transactions/"))
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, errors.New(T("неверный ID транзакции")))
+		return
+	}
+	t, err := loadTransaction(s.db, id)
+	var missing missingTransactionError
+	switch {
+	case errors.As(err, &missing):
+		writeAPIError(w, http.StatusNotFound, err)
+	case err != nil:
+		writeAPIError(w, http.StatusInternalServerError, err)
+	default:
+		writeAPIJSON(w, http.StatusOK, t)
+	}
+}
+
+func loadCategories(db *sql.DB) ([]CategoryInfo, error) {
+	rows, err := db.Query(`
+		SELECT type, category, COUNT(*)
+		FROM transaction_lines
+		GROUP BY type, category
+		ORDER BY type, category
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []CategoryInfo{}
+	for rows.Next() {
+		var c CategoryInfo
+		if err := rows.Scan(&c.Type, &c.Category, &c.Count); err != nil {
+			continue
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (s *apiServer) handleCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	categories, err := loadCategories(s.db)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, categories)
+}
+
+func (s *apiServer) handleBudgets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		progress, err := budgetProgress(s.db, time.Now())
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		sort.Slice(progress, func(i, j int) bool { return progress[i].Category < progress[j].Category })
+		if progress == nil {
+			progress = []BudgetProgress{}
+		}
+		writeAPIJSON(w, http.StatusOK, progress)
+	case http.MethodPut:
+		var b Budget
+		if !readAPIJSON(w, r, &b) {
+			return
+		}
+		if b.Period == "" {
+			b.Period = budgetPeriodMonthly
+		}
+		if !isBudgetPeriod(b.Period) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf(T("неизвестный период бюджета %q"), b.Period))
+			return
+		}
+		if b.Rollover != "" && !isRolloverRule(b.Rollover) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf(T("неизвестное правило переноса %q"), b.Rollover))
+			return
+		}
+		if err := saveBudget(s.db, b); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		p, err := computeBudgetProgress(s.db, b, time.Now())
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, p)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+func (s *apiServer) handleStatistics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	period, err := queryPeriod(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	report, err := buildStatsReport(s.db, period)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if report.Categories == nil {
+		report.Categories = []CategoryTotal{}
+	}
+	writeAPIJSON(w, http.StatusOK, report)
+}
+
+func (s *apiServer) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	io.WriteString(w, openAPISpec)
+}
+
+// openAPISpec описывает API в формате OpenAPI 3
+const openAPISpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Finance Tracker API", "version": "1.0.0"},
+  "components": {
+    "securitySchemes": {"bearer": {"type": "http", "scheme": "bearer"}},
+    "parameters": {
+      "period": {"name": "period", "in": "query", "schema": {"type": "string", "enum": ["all", "year", "month", "custom"], "default": "all"}},
+      "year": {"name": "year", "in": "query", "description": "Год для периодов year и month", "schema": {"type": "string", "example": "2026"}},
+      "month": {"name": "month", "in": "query", "description": "Номер месяца для периода month", "schema": {"type": "integer", "minimum": 1, "maximum": 12}},
+      "from": {"name": "from", "in": "query", "description": "Начальная дата периода custom", "schema": {"type": "string", "format": "date"}},
+      "to": {"name": "to", "in": "query", "description": "Конечная дата периода custom", "schema": {"type": "string", "format": "date"}}
+    },
+    "schemas": {
+      "Error": {"type": "object", "properties": {"error": {"type": "string"}}},
+      "SplitLine": {"type": "object", "properties": {
+        "Category": {"type": "string"}, "Amount": {"type": "number"}, "Note": {"type": "string"}}},
+      "Transaction": {"type": "object", "required": ["Type", "Amount"], "properties": {
+        "ID": {"type": "integer", "readOnly": true},
+        "Date": {"type": "string", "format": "date"},
+        "Category": {"type": "string"},
+        "Amount": {"type": "number"},
+        "Description": {"type": "string"},
+        "Type": {"type": "string", "enum": ["income", "expense", "transfer"]},
+        "AccountID": {"type": "integer"},
+        "TransferAccountID": {"type": "integer"},
+        "Splits": {"type": "array", "items": {"$ref": "#/components/schemas/SplitLine"}},
+        "Tags": {"type": "string", "description": "Теги через запятую"},
+        "PayeeID": {"type": "integer", "readOnly": true, "description": "Получатель; назначается по правилам и алиасам"},
+        "Status": {"type": "string", "enum": ["", "cleared", "reconciled"], "readOnly": true, "description": "Отметка сверки с банком"}}},
+      "TransactionPage": {"type": "object", "properties": {
+        "Items": {"type": "array", "items": {"$ref": "#/components/schemas/Transaction"}},
+        "Total": {"type": "integer"}, "Limit": {"type": "integer"}, "Offset": {"type": "integer"}}},
+      "CategoryInfo": {"type": "object", "properties": {
+        "Type": {"type": "string"}, "Category": {"type": "string"}, "Count": {"type": "integer"}}},
+      "Budget": {"type": "object", "required": ["Category", "Limit"], "properties": {
+        "Category": {"type": "string"},
+        "Limit": {"type": "number"},
+        "Period": {"type": "string", "enum": ["monthly", "weekly", "yearly", "custom"]},
+        "StartDate": {"type": "string", "format": "date"},
+        "EndDate": {"type": "string", "format": "date"},
+        "Rollover": {"type": "string", "enum": ["none", "positive", "full"]}}},
+      "BudgetProgress": {"allOf": [{"$ref": "#/components/schemas/Budget"}, {"type": "object", "properties": {
+        "Start": {"type": "string", "format": "date"}, "End": {"type": "string", "format": "date"},
+        "Spent": {"type": "number"}, "Remaining": {"type": "number"}, "Percent": {"type": "number"}}}]},
+      "StatsReport": {"type": "object", "properties": {
+        "Period": {"type": "string"}, "Income": {"type": "number"}, "Expense": {"type": "number"}, "Balance": {"type": "number"},
+        "Categories": {"type": "array", "items": {"type": "object", "properties": {
+          "Type": {"type": "string"}, "Category": {"type": "string"}, "Total": {"type": "number"}}}}}}
+    }
+  },
+  "security": [{"bearer": []}],
+  "paths": {
+    "/api/transactions": {
+      "get": {
+        "summary": "Список транзакций за период, новые первыми",
+        "parameters": [
+          {"$ref": "#/components/parameters/period"}, {"$ref": "#/components/parameters/year"},
+          {"$ref": "#/components/parameters/month"}, {"$ref": "#/components/parameters/from"},
+          {"$ref": "#/components/parameters/to"},
+          {"name": "type", "in": "query", "schema": {"type": "string", "enum": ["income", "expense", "transfer"]}},
+          {"name": "category", "in": "query", "schema": {"type": "string"}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 500, "default": 50}},
+          {"name": "offset", "in": "query", "schema": {"type": "integer", "minimum": 0, "default": 0}}
+        ],
+        "responses": {
+          "200": {"description": "Страница транзакций", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TransactionPage"}}}},
+          "400": {"description": "Неверные параметры", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      },
+      "post": {
+        "summary": "Добавить транзакцию",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+        "responses": {
+          "201": {"description": "Транзакция сохранена", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "400": {"description": "Неверная транзакция", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+          "413": {"description": "Тело запроса больше 1 МБ", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/transactions/{id}": {
+      "get": {
+        "summary": "Транзакция по ID",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+        "responses": {
+          "200": {"description": "Транзакция", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "404": {"description": "Не найдена", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/categories": {
+      "get": {
+        "summary": "Категории по типам",
+        "responses": {"200": {"description": "Категории", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/CategoryInfo"}}}}}}
+      }
+    },
+    "/api/budgets": {
+      "get": {
+        "summary": "Исполнение бюджетов в текущем периоде",
+        "responses": {"200": {"description": "Бюджеты", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/BudgetProgress"}}}}}}
+      },
+      "put": {
+        "summary": "Задать лимит бюджета категории",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Budget"}}}},
+        "responses": {
+          "200": {"description": "Бюджет сохранен", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/BudgetProgress"}}}},
+          "400": {"description": "Неверный бюджет", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}},
+          "413": {"description": "Тело запроса больше 1 МБ", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+        }
+      }
+    },
+    "/api/statistics": {
+      "get": {
+        "summary": "Доходы и расходы по категориям за период",
+        "parameters": [
+          {"$ref": "#/components/parameters/period"}, {"$ref": "#/components/parameters/year"},
+          {"$ref": "#/components/parameters/month"}, {"$ref": "#/components/parameters/from"},
+          {"$ref": "#/components/parameters/to"}
+        ],
+        "responses": {"200": {"description": "Статистика", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/StatsReport"}}}}}
+      }
+    },
+    "/api/openapi.json": {
+      "get": {"summary": "Это описание", "security": [], "responses": {"200": {"description": "Описание OpenAPI"}}}
+    }
+  }
+}
+`
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAPICreateTransaction(t *testing.T) {
+	db := openTestDB(t)
+	if err := saveAccount(db, Account{Name: "Карта", Currency: "RUB"}); err != nil {
+		t.Fatal(err)
+	}
+	server := newAPIServer(db, "secret")
+
+	tests := []struct {
+		name   string
+		auth   string
+		body   string
+		status int
+	}{
+		{name: "расход", auth: "Bearer secret", body: `{"Type":"expense","Amount":5,"Category":"Еда"}`, status: http.StatusCreated},
+		{name: "перевод", auth: "Bearer secret", body: `{"Type":"transfer","Amount":5,"AccountID":1,"TransferAccountID":2}`, status: http.StatusCreated},
+		{name: "перевод с основного счета", auth: "Bearer secret", body: `{"Type":"transfer","Amount":5,"TransferAccountID":2}`, status: http.StatusCreated},
+		{name: "токен без схемы", auth: "secret", body: `{"Type":"expense","Amount":5,"Category":"Еда"}`, status: http.StatusUnauthorized},
+		{name: "перевод без счета зачисления", auth: "Bearer secret", body: `{"Type":"transfer","Amount":5}`, status: http.StatusBadRequest},
+		{name: "перевод на тот же счет", auth: "Bearer secret", body: `{"Type":"transfer","Amount":5,"AccountID":2,"TransferAccountID":2}`, status: http.StatusBadRequest},
+		{name: "расход со счетом зачисления", auth: "Bearer secret", body: `{"Type":"expense","Amount":5,"Category":"Еда","TransferAccountID":2}`, status: http.StatusBadRequest},
+		{name: "несуществующий счет", auth: "Bearer secret", body: `{"Type":"expense","Amount":5,"Category":"Еда","AccountID":42}`, status: http.StatusBadRequest},
+		{name: "несуществующий счет зачисления", auth: "Bearer secret", body: `{"Type":"transfer","Amount":5,"TransferAccountID":42}`, status: http.StatusBadRequest},
+		{name: "слишком большое тело", auth: "Bearer secret", body: `{"Description":"` + strings.Repeat("x", apiMaxBodyBytes) + `"}`, status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/transactions", strings.NewReader(tt.body))
+			r.Header.Set("Authorization", tt.auth)
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Fatalf("код %d (%s), ожидался %d", w.Code, strings.TrimSpace(w.Body.String()), tt.status)
+			}
+			if w.Code != http.StatusCreated {
+				return
+			}
+			var created Transaction
+			if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+				t.Fatal(err)
+			}
+			if created.AccountID == 0 {
+				t.Error("транзакция сохранена без счета списания")
+			}
+		})
+	}
+}
+
+func TestAPIListTransactions(t *testing.T) {
+	db := openTestDB(t)
+	for _, tr := range []Transaction{
+		{Date: "2026-09-01", Type: typeIncome, Category: "Зарплата", Amount: 1000},
+		{Date: "2026-09-02", Type: typeExpense, Category: "Еда", Amount: 100},
+		{Date: "2026-09-03", Type: typeExpense, Amount: 300, Splits: []SplitLine{
+			{Category: "Еда", Amount: 200}, {Category: "Дом", Amount: 100}}},
+		{Date: "2026-09-04", Type: typeExpense, Category: "Дом", Amount: 50},
+		{Date: "2026-10-01", Type: typeExpense, Category: "Еда", Amount: 70},
+	} {
+		if _, err := insertTransaction(db, tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server := newAPIServer(db, "secret")
+
+	tests := []struct {
+		name    string
+		query   string
+		total   int
+		ids     []int
+		splitID int
+	}{
+		{name: "все", query: "", total: 5, ids: []int{5, 4, 3, 2, 1}, splitID: 3},
+		{name: "страница", query: "limit=2&offset=1", total: 5, ids: []int{4, 3}, splitID: 3},
+		{name: "за пределами", query: "offset=10", total: 5, ids: []int{}},
+		{name: "по типу", query: "type=income", total: 1, ids: []int{1}},
+		{name: "по категории части", query: "category=Еда&limit=2", total: 3, ids: []int{5, 3}, splitID: 3},
+		{name: "по периоду и категории", query: "category=Дом&period=month&year=2026&month=9", total: 2, ids: []int{4, 3}, splitID: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/transactions?"+tt.query, nil)
+			r.Header.Set("Authorization", "Bearer secret")
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("код %d (%s)", w.Code, strings.TrimSpace(w.Body.String()))
+			}
+			var page TransactionPage
+			if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+				t.Fatal(err)
+			}
+			ids := []int{}
+			for _, item := range page.Items {
+				ids = append(ids, item.ID)
+				if wantSplits := item.ID == tt.splitID; wantSplits != (len(item.Splits) == 2) {
+					t.Errorf("у транзакции %d частей %d", item.ID, len(item.Splits))
+				}
+			}
+			if page.Total != tt.total || !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("всего %d, номера %v; ожидалось %d и %v", page.Total, ids, tt.total, tt.ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Действия в журнале изменений (audit_log.action)
+const (
+	auditInsert = "insert"
+	auditUpdate = "update"
+	auditDelete = "delete"
+)
+
+// auditedTables — таблицы, каждое изменение которых пишется в журнал, и их ключ.
+// Части разделенной транзакции записываются под номером самой транзакции.
+var auditedTables = []struct {
+	Table string
+	Key   string
+	Label string
+}{
+	{"transactions", "id", "Транзакция"},
+	{"transaction_splits", "transaction_id", "Часть транзакции"},
+	{"budget_limits", "category", "Бюджет"},
+}
+
+var auditActions = []struct {
+	Code  string
+	Label string
+}{
+	{auditInsert, "Добавление"},
+	{auditUpdate, "Изменение"},
+	{auditDelete, "Удаление"},
+}
+
+// auditFieldLabels — подписи столбцов в истории изменений
+var auditFieldLabels = map[string]string{
+	"date":                "Дата",
+	"type":                "Тип",
+	"category":            "Категория",
+	"amount":              "Сумма",
+	"description":         "Описание",
+	"account_id":          "Счет",
+	"transfer_account_id": "Счет зачисления",
+	"tags":                "Теги",
+	"payee_id":            "Получатель",
+	"status":              "Статус",
+	"limit_amount":        "Лимит",
+	"period":              "Период",
+	"start_date":          "Начальная дата",
+	"end_date":            "Конечная дата",
+	"rollover":            "Перенос остатка",
+	"note":                "Заметка",
+}
+
+// AuditEntry — запись журнала: состояние строки до и после изменения
+type AuditEntry struct {
+	ID        int
+	Table     string
+	RowKey    string
+	Action    string
+	Before    map[string]interface{}
+	After     map[string]interface{}
+	ChangedAt time.Time
+}
+
+// createAuditTriggers пересоздает триггеры журнала при каждом запуске, чтобы
+// в снимок строки попадали и столбцы, добавленные миграциями. Сам журнал
+// только дополняется: изменить или удалить из него запись нельзя.
+func createAuditTriggers(db *sql.DB) error {
+	statements := []string{
+		`DROP TRIGGER IF EXISTS audit_log_no_update`,
+		`CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+		BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END`,
+		`DROP TRIGGER IF EXISTS audit_log_no_delete`,
+		`CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+		BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END`,
+	}
+	for _, t := range auditedTables {
+		columns, err := tableColumns(db, t.Table)
+		if err != nil {
+			return err
+		}
+		snapshot := func(row string) string {
+			pairs := make([]string, 0, len(columns))
+			for _, c := range columns {
+				pairs = append(pairs, fmt.Sprintf("'%s', %s.%s", c, row, c))
+			}
+			return "json_object(" + strings.Join(pairs, ", ") + ")"
+		}
+		statements = append(statements,
+			fmt.Sprintf(`DROP TRIGGER IF EXISTS audit_%s_insert`, t.Table),
+			fmt.Sprintf(`CREATE TRIGGER audit_%[1]s_insert AFTER INSERT ON %[1]s
+			BEGIN
+				INSERT INTO audit_log (table_name, row_key, action, after_json)
+				VALUES ('%[1]s', NEW.%[2]s, '%[3]s', %[4]s);
+			END`, t.Table, t.Key, auditInsert, snapshot("NEW")),
+			fmt.Sprintf(`DROP TRIGGER IF EXISTS audit_%s_update`, t.Table),
+			fmt.Sprintf(`CREATE TRIGGER audit_%[1]s_update AFTER UPDATE ON %[1]s
+			WHEN %[4]s IS NOT %[5]s
+			BEGIN
+				INSERT INTO audit_log (table_name, row_key, action, before_json, after_json)
+				VALUES ('%[1]s', NEW.%[2]s, '%[3]s', %[4]s, %[5]s);
+			END`, t.Table, t.Key, auditUpdate, snapshot("OLD"), snapshot("NEW")),
+			fmt.Sprintf(`DROP TRIGGER IF EXISTS audit_%s_delete`, t.Table),
+			fmt.Sprintf(`CREATE TRIGGER audit_%[1]s_delete AFTER DELETE ON %[1]s
+			BEGIN
+				INSERT INTO audit_log (table_name, row_key, action, before_json)
+				VALUES ('%[1]s', OLD.%[2]s, '%[3]s', %[4]s);
+			END`, t.Table, t.Key, auditDelete, snapshot("OLD")),
+		)
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableColumns возвращает имена столбцов таблицы в порядке объявления
+func tableColumns(db *sql.DB, table string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return nil, err
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// loadAuditLog возвращает записи журнала, новые первыми. Без таблиц выбираются
+// все таблицы, пустой ключ означает все строки; limit <= 0 снимает ограничение.
+func loadAuditLog(db *sql.DB, tables []string, rowKey string, limit int) ([]AuditEntry, error) {
+	query := "SELECT id, table_name, row_key, action, COALESCE(before_json, ''), COALESCE(after_json, ''), changed_at FROM audit_log WHERE 1 = 1"
+	var args []interface{}
+	if len(tables) > 0 {
+		query += " AND table_name IN (?" + strings.Repeat(", ?", len(tables)-1) + ")"
+		for _, table := range tables {
+			args = append(args, table)
+		}
+	}
+	if rowKey != "" {
+		query += " AND row_key = ?"
+		args = append(args, rowKey)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		var before, after, changedAt string
+		if err := rows.Scan(&e.ID, &e.Table, &e.RowKey, &e.Action, &before, &after, &changedAt); err != nil {
+			continue
+		}
+		if before != "" {
+			json.Unmarshal([]byte(before), &e.Before)
+		}
+		if after != "" {
+			json.Unmarshal([]byte(after), &e.After)
+		}
+		if t, err := time.Parse("2006-01-02T15:04:05.000Z", changedAt); err == nil {
+			e.ChangedAt = t.Local()
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func auditTableLabel(table string) string {
+	for _, t := range auditedTables {
+		if t.Table == table {
+			return T(t.Label)
+		}
+	}
+	return table
+}
+
+func auditActionLabel(action string) string {
+	for _, a := range auditActions {
+		if a.Code == action {
+			return T(a.Label)
+		}
+	}
+	return action
+}
+
+// auditValue показывает значение столбца так же, как в остальных окнах
+func auditValue(field string, value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "—"
+	case float64:
+		switch field {
+		case "amount", "limit_amount":
+			return money(v)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		switch field {
+		case "type":
+			return typeLabel(v)
+		case "status":
+			return statusLabel(v)
+		case "date", "start_date", "end_date":
+			return formatDate(v)
+		}
+		if v == "" {
+			return "—"
+		}
+		return v
+	}
+	return fmt.Sprint(value)
+}
+
+func auditFieldLabel(field string) string {
+	if label, ok := auditFieldLabels[field]; ok {
+		return T(label)
+	}
+	return field
+}
+
+// describeAuditChanges перечисляет измененные поля: "Сумма: 250 ₽ → 300 ₽".
+// Для добавления и удаления выводится вся строка.
+func describeAuditChanges(e AuditEntry) []string {
+	fields := make(map[string]bool)
+	for field := range e.Before {
+		fields[field] = true
+	}
+	for field := range e.After {
+		fields[field] = true
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		if field != "id" && field != "transaction_id" {
+			names = append(names, field)
+		}
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, field := range names {
+		before, after := e.Before[field], e.After[field]
+		switch e.Action {
+		case auditInsert:
+			lines = append(lines, fmt.Sprintf("%s: %s", auditFieldLabel(field), auditValue(field, after)))
+		case auditDelete:
+			lines = append(lines, fmt.Sprintf("%s: %s", auditFieldLabel(field), auditValue(field, before)))
+		default:
+			if fmt.Sprint(before) != fmt.Sprint(after) {
+				lines = append(lines, fmt.Sprintf("%s: %s → %s", auditFieldLabel(field),
+					auditValue(field, before), auditValue(field, after)))
+			}
+		}
+	}
+	return lines
+}
+
+// auditEntryView — запись журнала в виде заголовка и списка изменений
+func auditEntryView(e AuditEntry, withRow bool) fyne.CanvasObject {
+	header := fmt.Sprintf("%s  %s", e.ChangedAt.Format(currentLocale().DateLayout+" 15:04:05"), auditActionLabel(e.Action))
+	if withRow {
+		header += fmt.Sprintf(" · %s %s", auditTableLabel(e.Table), e.RowKey)
+	} else if e.Table != "transactions" {
+		header += " · " + auditTableLabel(e.Table)
+	}
+	return container.NewVBox(
+		widget.NewLabelWithStyle(header, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabel(strings.Join(describeAuditChanges(e), "\n")),
+		widget.NewSeparator(),
+	)
+}
+
+// transactionHistoryWindow — все изменения одной транзакции, новые первыми
+func transactionHistoryWindow(a fyne.App, db *sql.DB, id int) fyne.Window {
+	window := a.NewWindow(Tf("История транзакции %d", id))
+	restoreWindowSize(window, "transaction_history", fyne.NewSize(700, 600))
+
+	entries, err := loadAuditLog(db, []string{"transactions", "transaction_splits"}, strconv.Itoa(id), 0)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+	history := container.NewVBox()
+	for _, e := range entries {
+		history.Add(auditEntryView(e, false))
+	}
+	if len(entries) == 0 {
+		history.Add(widget.NewLabel(T("Изменений не записано")))
+	}
+	window.SetContent(container.NewScroll(history))
+	return window
+}
+
+// activityWindow — лента последних изменений по всем таблицам журнала
+func activityWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Журнал изменений"))
+	restoreWindowSize(window, "activity", fyne.NewSize(900, 700))
+
+	const feedLimit = 500
+	allTables := T("Все")
+	tableOptions := []string{allTables}
+	for _, t := range auditedTables {
+		tableOptions = append(tableOptions, T(t.Label))
+	}
+	tableSelect := widget.NewSelect(tableOptions, nil)
+	feed := container.NewVBox()
+
+	update := func() {
+		var tables []string
+		for _, t := range auditedTables {
+			if T(t.Label) == tableSelect.Selected {
+				tables = append(tables, t.Table)
+			}
+		}
+		entries, err := loadAuditLog(db, tables, "", feedLimit)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		feed.Objects = nil
+		for _, e := range entries {
+			feed.Add(auditEntryView(e, true))
+		}
+		if len(entries) == 0 {
+			feed.Add(widget.NewLabel(T("Изменений не записано")))
+		}
+		feed.Refresh()
+	}
+	tableSelect.OnChanged = func(string) { update() }
+	tableSelect.SetSelected(allTables)
+
+	window.SetContent(container.NewBorder(
+		container.NewHBox(widget.NewLabel(T("Показать:")), tableSelect),
+		nil, nil, nil,
+		container.NewScroll(feed),
+	))
+	return window
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Периоды бюджета в том виде, в котором они хранятся в budget_limits.period
+const (
+	budgetPeriodMonthly = "monthly"
+	budgetPeriodWeekly  = "weekly"
+	budgetPeriodYearly  = "yearly"
+	budgetPeriodCustom  = "custom"
+)
+
+var budgetPeriods = []struct {
+	Code  string
+	Label string
+}{
+	{budgetPeriodMonthly, "Месяц"},
+	{budgetPeriodWeekly, "Неделя"},
+	{budgetPeriodYearly, "Год"},
+	{budgetPeriodCustom, "Свой период"},
+}
+
+func budgetPeriodLabels() []string {
+	labels := make([]string, 0, len(budgetPeriods))
+	for _, p := range budgetPeriods {
+		labels = append(labels, T(p.Label))
+	}
+	return labels
+}
+
+func budgetPeriodLabel(code string) string {
+	for _, p := range budgetPeriods {
+		if p.Code == code {
+			return T(p.Label)
+		}
+	}
+	return code
+}
+
+// isBudgetPeriod — известен ли код периода бюджета
+func isBudgetPeriod(code string) bool {
+	for _, p := range budgetPeriods {
+		if p.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func budgetPeriodCode(label string) string {
+	for _, p := range budgetPeriods {
+		if T(p.Label) == label {
+			return p.Code
+		}
+	}
+	return budgetPeriodMonthly
+}
+
+type Budget struct {
+	Category  string
+	Limit     float64
+	Period    string
+	StartDate string
+	EndDate   string
+	Rollover  string
+}
+
+// BudgetProgress — состояние бюджета категории в текущем периоде
+type BudgetProgress struct {
+	Budget
+	Start     string
+	End       string
+	Spent     float64
+	Remaining float64
+	Percent   float64
+}
+
+// budgetPeriodRange возвращает границы периода бюджета, в который попадает now.
+// Для своего периода границы берутся из самого бюджета.
+func budgetPeriodRange(b Budget, now time.Time) (time.Time, time.Time, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch b.Period {
+	case budgetPeriodWeekly:
+		// Неделя начинается с понедельника
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		return start, start.AddDate(0, 0, 6), nil
+	case budgetPeriodYearly:
+		start := time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(1, 0, -1), nil
+	case budgetPeriodCustom:
+		start, err := time.ParseInLocation("2006-01-02", b.StartDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf(T("неверная начальная дата бюджета %q"), b.Category)
+		}
+		end, err := time.ParseInLocation("2006-01-02", b.EndDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf(T("неверная конечная дата бюджета %q"), b.Category)
+		}
+		return start, end, nil
+	default:
+		start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.Local)
+		return start, start.AddDate(0, 1, -1), nil
+	}
+}
+
+func loadBudgets(db dbExecutor) ([]Budget, error) {
+	rows, err := db.Query("SELECT category, limit_amount, period, start_date, end_date, rollover FROM budget_limits ORDER BY category")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var budgets []Budget
+	for rows.Next() {
+		var b Budget
+		if err := rows.Scan(&b.Category, &b.Limit, &b.Period, &b.StartDate, &b.EndDate, &b.Rollover); err != nil {
+			continue
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+func saveBudget(db *sql.DB, b Budget) error {
+	if b.Category == "" {
+		return errors.New(T("не указана категория"))
+	}
+	if b.Limit <= 0 {
+		return errors.New(T("неверная сумма"))
+	}
+	if b.Period == budgetPeriodCustom {
+		if _, _, err := budgetPeriodRange(b, time.Now()); err != nil {
+			return err
+		}
+		if b.EndDate < b.StartDate {
+			return errors.New(T("конечная дата раньше начальной"))
+		}
+	} else {
+		b.StartDate, b.EndDate = "", ""
+	}
+	if b.Rollover == "" {
+		b.Rollover = rolloverNone
+	}
+	_, err := db.Exec(`
+		INSERT INTO budget_limits (category, limit_amount, period, start_date, end_date, rollover)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(category) DO UPDATE SET limit_amount = excluded.limit_amount, period = excluded.period,
+			start_date = excluded.start_date, end_date = excluded.end_date, rollover = excluded.rollover
+	`, b.Category, b.Limit, b.Period, b.StartDate, b.EndDate, b.Rollover)
+	return err
+}
+
+// deleteBudget убирает лимит категории
+func deleteBudget(db *sql.DB, category string) error {
+	_, err := db.Exec("DELETE FROM budget_limits WHERE category = ?", category)
+	return err
+}
+
+// findBudget возвращает лимит категории, если он задан
+func findBudget(db dbExecutor, category string) (Budget, bool, error) {
+	budgets, err := loadBudgets(db)
+	if err != nil {
+		return Budget{}, false, err
+	}
+	for _, b := range budgets {
+		if b.Category == category {
+			return b, true, nil
+		}
+	}
+	return Budget{}, false, nil
+}
+
+// categorySpent считает расходы категории за период по строкам транзакций,
+// поэтому части разделенных транзакций учитываются в своих категориях
+func categorySpent(db *sql.DB, category, start, end string) (float64, error) {
+	var spent float64
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0)
+		FROM transaction_lines
+		WHERE type = ? AND category = ? AND date BETWEEN ? AND ?
+	`, typeExpense, category, start, end).Scan(&spent)
+	return spent, err
+}
+
+func computeBudgetProgress(db *sql.DB, b Budget, now time.Time) (BudgetProgress, error) {
+	start, end, err := budgetPeriodRange(b, now)
+	if err != nil {
+		return BudgetProgress{}, err
+	}
+	p := BudgetProgress{
+		Budget: b,
+		Start:  start.Format("2006-01-02"),
+		End:    end.Format("2006-01-02"),
+	}
+	p.Spent, err = categorySpent(db, b.Category, p.Start, p.End)
+	if err != nil {
+		return BudgetProgress{}, err
+	}
+	p.Remaining = b.Limit - p.Spent
+	if b.Limit > 0 {
+		p.Percent = p.Spent / b.Limit * 100
+	}
+	return p, nil
+}
+
+// budgetProgress возвращает потраченное, остаток и процент по всем бюджетам на дату now
+func budgetProgress(db *sql.DB, now time.Time) ([]BudgetProgress, error) {
+	budgets, err := loadBudgets(db)
+	if err != nil {
+		return nil, err
+	}
+	var progress []BudgetProgress
+	for _, b := range budgets {
+		p, err := computeBudgetProgress(db, b, now)
+		if err != nil {
+			return nil, err
+		}
+		progress = append(progress, p)
+	}
+	return progress, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// categoryColumns — где еще, кроме лимитов и конвертов, хранится имя категории
+var categoryColumns = []struct {
+	Table, Key, Column string
+}{
+	{"transactions", "id", "category"},
+	{"transaction_splits", "id", "category"},
+	{"recurring_items", "id", "category"},
+	{"rules", "id", "set_category"},
+	{"payees", "id", "default_category"},
+	{"category_aliases", "alias", "category"},
+}
+
+// envelopeShare — сколько было положено в конверт исходной категории за месяц
+type envelopeShare struct {
+	Month  string
+	Amount float64
+	// Existed — у новой категории в этом месяце уже был свой конверт
+	Existed bool
+}
+
+// CategoryMerge — что изменило объединение категорий; по этой записи оно отменяется
+type CategoryMerge struct {
+	From, To string
+	// Rows — ключи строк, в которых категория заменена, по таблицам
+	Rows      map[string][]interface{}
+	Budget    Budget
+	HadBudget bool // у исходной категории был лимит
+	// MovedBudget — лимит перенесен на новую категорию, у которой своего не было;
+	// иначе лимит исходной категории удален
+	MovedBudget bool
+	Envelopes   []envelopeShare
+}
+
+// categoryNames — все категории транзакций по алфавиту
+func categoryNames(db *sql.DB) ([]string, error) {
+	categories, err := loadCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range categories {
+		if c.Category != "" && !seen[c.Category] {
+			seen[c.Category] = true
+			names = append(names, c.Category)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// mergeCategories переносит всё из категории from в категорию to: транзакции и их
+// части, регулярные платежи, правила, получателей, алиасы, лимит и конверты.
+// Сверка фиксирует суммы и даты, поэтому категории сверенных транзакций тоже меняются.
+func mergeCategories(db *sql.DB, from, to string) (CategoryMerge, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	m := CategoryMerge{From: from, To: to, Rows: make(map[string][]interface{})}
+	if from == "" || to == "" {
+		return m, errors.New(T("не указана категория"))
+	}
+	if from == to {
+		return m, errors.New(T("категории совпадают"))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return m, err
+	}
+	defer tx.Rollback()
+
+	for _, c := range categoryColumns {
+		rows, err := tx.Query(fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?", c.Key, c.Table, c.Column), from)
+		if err != nil {
+			return m, err
+		}
+		var keys []interface{}
+		for rows.Next() {
+			var key interface{}
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return m, err
+			}
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return m, err
+		}
+		if len(keys) == 0 {
+			continue
+		}
+		m.Rows[c.Table] = keys
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", c.Table, c.Column, c.Column), to, from); err != nil {
+			return m, err
+		}
+	}
+
+	// Лимит переходит к новой категории, только если у неё не было своего
+	err = tx.QueryRow("SELECT limit_amount, period, start_date, end_date, rollover FROM budget_limits WHERE category = ?", from).
+		Scan(&m.Budget.Limit, &m.Budget.Period, &m.Budget.StartDate, &m.Budget.EndDate, &m.Budget.Rollover)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return m, err
+	default:
+		m.Budget.Category, m.HadBudget = from, true
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM budget_limits WHERE category = ?", to).Scan(&exists); err != nil {
+			return m, err
+		}
+		m.MovedBudget = exists == 0
+		query := "DELETE FROM budget_limits WHERE category = ?"
+		args := []interface{}{from}
+		if m.MovedBudget {
+			query = "UPDATE budget_limits SET category = ? WHERE category = ?"
+			args = []interface{}{to, from}
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return m, err
+		}
+	}
+
+	// Конверты складываются по месяцам
+	rows, err := tx.Query("SELECT month, amount FROM envelope_assignments WHERE category = ? ORDER BY month", from)
+	if err != nil {
+		return m, err
+	}
+	for rows.Next() {
+		var share envelopeShare
+		if err := rows.Scan(&share.Month, &share.Amount); err != nil {
+			rows.Close()
+			return m, err
+		}
+		m.Envelopes = append(m.Envelopes, share)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return m, err
+	}
+	for i, share := range m.Envelopes {
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM envelope_assignments WHERE category = ? AND month = ?", to, share.Month).Scan(&exists); err != nil {
+			return m, err
+		}
+		m.Envelopes[i].Existed = exists > 0
+		_, err := tx.Exec(`
+			INSERT INTO envelope_assignments (category, month, amount) VALUES (?, ?, ?)
+			ON CONFLICT(category, month) DO UPDATE SET amount = amount + excluded.amount
+		`, to, share.Month, share.Amount)
+		if err != nil {
+			return m, err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM envelope_assignments WHERE category = ?", from); err != nil {
+		return m, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return m, err
+	}
+	// Классификатор переобучится на истории при следующей подсказке
+	forgetClassifier(db)
+	return m, nil
+}
+
+// unmergeCategories возвращает исходной категории ровно то, что у неё забрало объединение
+func unmergeCategories(db *sql.DB, m CategoryMerge) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range categoryColumns {
+		for _, key := range m.Rows[c.Table] {
+			_, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? AND %s = ?", c.Table, c.Column, c.Key, c.Column),
+				m.From, key, m.To)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.HadBudget {
+		if m.MovedBudget {
+			if _, err := tx.Exec("DELETE FROM budget_limits WHERE category = ?", m.To); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(`
+			INSERT INTO budget_limits (category, limit_amount, period, start_date, end_date, rollover)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, m.From, m.Budget.Limit, m.Budget.Period, m.Budget.StartDate, m.Budget.EndDate, m.Budget.Rollover)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, share := range m.Envelopes {
+		query := "DELETE FROM envelope_assignments WHERE category = ? AND month = ?"
+		args := []interface{}{m.To, share.Month}
+		if share.Existed {
+			query = "UPDATE envelope_assignments SET amount = amount - ? WHERE category = ? AND month = ?"
+			args = []interface{}{share.Amount, m.To, share.Month}
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO envelope_assignments (category, month, amount) VALUES (?, ?, ?)",
+			m.From, share.Month, share.Amount); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	forgetClassifier(db)
+	return nil
+}
+
+// showMergeCategories спрашивает, какую категорию в какую влить, и объединяет их.
+// Новую категорию можно выбрать из существующих или ввести.
+func showMergeCategories(window fyne.Window, db *sql.DB, onMerged func()) {
+	names, err := categoryNames(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	fromSelect := widget.NewSelect(names, nil)
+	toEntry := widget.NewSelectEntry(names)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(T("Объединить"), fromSelect),
+		widget.NewFormItem(T("В категорию"), toEntry),
+	}
+	dialog.ShowForm(T("Объединение категорий"), T("Объединить"), T("Отмена"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		if err := runCommand(db, &mergeCategoriesCommand{From: fromSelect.Selected, To: toEntry.Text}); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if onMerged != nil {
+			onMerged()
+		}
+	}, window)
+}
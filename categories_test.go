@@ -0,0 +1,207 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+// categoryState — всё, что объединение категорий может изменить
+type categoryState struct {
+	Transactions map[int]string
+	Splits       map[string]float64
+	Budgets      map[string]float64
+	Envelopes    map[string]float64
+}
+
+func loadCategoryState(t *testing.T, db *sql.DB) categoryState {
+	t.Helper()
+	s := categoryState{
+		Transactions: make(map[int]string),
+		Splits:       make(map[string]float64),
+		Budgets:      make(map[string]float64),
+		Envelopes:    make(map[string]float64),
+	}
+	scan := func(query string, row func(*sql.Rows) error) {
+		rows, err := db.Query(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if err := row(rows); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scan("SELECT id, category FROM transactions", func(rows *sql.Rows) error {
+		var id int
+		var category string
+		err := rows.Scan(&id, &category)
+		s.Transactions[id] = category
+		return err
+	})
+	scan("SELECT category, SUM(amount) FROM transaction_splits GROUP BY category", func(rows *sql.Rows) error {
+		var category string
+		var amount float64
+		err := rows.Scan(&category, &amount)
+		s.Splits[category] = amount
+		return err
+	})
+	scan("SELECT category, limit_amount FROM budget_limits", func(rows *sql.Rows) error {
+		var category string
+		var limit float64
+		err := rows.Scan(&category, &limit)
+		s.Budgets[category] = limit
+		return err
+	})
+	scan("SELECT category || ' ' || month, amount FROM envelope_assignments", func(rows *sql.Rows) error {
+		var key string
+		var amount float64
+		err := rows.Scan(&key, &amount)
+		s.Envelopes[key] = amount
+		return err
+	})
+	return s
+}
+
+func TestMergeCategories(t *testing.T) {
+	// Доход на конверты, транзакции и части обеих категорий, лимит и конверты исходной
+	setup := func(t *testing.T, db *sql.DB) {
+		t.Helper()
+		transactions := []Transaction{
+			{Date: "2026-09-01", Type: typeIncome, Category: "Зарплата", Amount: 100000},
+			{Date: "2026-09-05", Type: typeExpense, Category: "Кафе", Amount: 500},
+			{Date: "2026-09-06", Type: typeExpense, Category: "Рестораны", Amount: 2500},
+			{Date: "2026-09-07", Type: typeExpense, Amount: 1000, Splits: []SplitLine{
+				{Category: "Кафе", Amount: 400}, {Category: "Продукты", Amount: 600}}},
+		}
+		for _, tr := range transactions {
+			if _, err := insertTransaction(db, tr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := saveBudget(db, Budget{Category: "Кафе", Limit: 3000, Period: budgetPeriodMonthly}); err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range []struct {
+			category, month string
+			amount          float64
+		}{
+			{"Кафе", "2026-09", 1000},
+			{"Кафе", "2026-10", 700},
+			{"Рестораны", "2026-09", 2000},
+		} {
+			if err := assignToEnvelope(db, a.category, a.month, a.amount); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		// toBudget — собственный лимит новой категории до объединения
+		toBudget float64
+		want     categoryState
+		wantErr  bool
+	}{
+		{
+			name: "в существующую категорию",
+			from: "Кафе", to: "Рестораны",
+			want: categoryState{
+				Transactions: map[int]string{1: "Зарплата", 2: "Рестораны", 3: "Рестораны", 4: ""},
+				Splits:       map[string]float64{"Рестораны": 400, "Продукты": 600},
+				Budgets:      map[string]float64{"Рестораны": 3000},
+				Envelopes:    map[string]float64{"Рестораны 2026-09": 3000, "Рестораны 2026-10": 700},
+			},
+		},
+		{
+			name: "лимит новой категории не заменяется",
+			from: "Кафе", to: "Рестораны", toBudget: 5000,
+			want: categoryState{
+				Transactions: map[int]string{1: "Зарплата", 2: "Рестораны", 3: "Рестораны", 4: ""},
+				Splits:       map[string]float64{"Рестораны": 400, "Продукты": 600},
+				Budgets:      map[string]float64{"Рестораны": 5000},
+				Envelopes:    map[string]float64{"Рестораны 2026-09": 3000, "Рестораны 2026-10": 700},
+			},
+		},
+		{
+			name: "в новую категорию",
+			from: " Кафе ", to: "Еда вне дома",
+			want: categoryState{
+				Transactions: map[int]string{1: "Зарплата", 2: "Еда вне дома", 3: "Рестораны", 4: ""},
+				Splits:       map[string]float64{"Еда вне дома": 400, "Продукты": 600},
+				Budgets:      map[string]float64{"Еда вне дома": 3000},
+				Envelopes: map[string]float64{
+					"Еда вне дома 2026-09": 1000, "Еда вне дома 2026-10": 700, "Рестораны 2026-09": 2000},
+			},
+		},
+		{name: "в ту же категорию", from: "Кафе", to: "Кафе", wantErr: true},
+		{name: "без исходной категории", from: "", to: "Кафе", wantErr: true},
+		{name: "без новой категории", from: "Кафе", to: "  ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			setup(t, db)
+			if tt.toBudget > 0 {
+				if err := saveBudget(db, Budget{Category: "Рестораны", Limit: tt.toBudget, Period: budgetPeriodMonthly}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := loadCategoryState(t, db)
+
+			merge, err := mergeCategories(db, tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ожидалась ошибка")
+				}
+				if after := loadCategoryState(t, db); !reflect.DeepEqual(after, before) {
+					t.Errorf("неудачное объединение изменило данные:\n%+v\nбыло\n%+v", after, before)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := loadCategoryState(t, db); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("после объединения\n%+v\nожидалось\n%+v", got, tt.want)
+			}
+
+			if err := unmergeCategories(db, merge); err != nil {
+				t.Fatal(err)
+			}
+			if got := loadCategoryState(t, db); !reflect.DeepEqual(got, before) {
+				t.Errorf("после отмены\n%+v\nожидалось\n%+v", got, before)
+			}
+		})
+	}
+}
+
+// Отмена объединения не трогает строки, которые после него перевели в новую категорию сами
+func TestUnmergeCategoriesKeepsLaterRows(t *testing.T) {
+	db := openTestDB(t)
+	id, err := insertTransaction(db, Transaction{Date: "2026-09-05", Type: typeExpense, Category: "Кафе", Amount: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	merge, err := mergeCategories(db, "Кафе", "Рестораны")
+	if err != nil {
+		t.Fatal(err)
+	}
+	later, err := insertTransaction(db, Transaction{Date: "2026-09-06", Type: typeExpense, Category: "Рестораны", Amount: 900})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unmergeCategories(db, merge); err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{int(id): "Кафе", int(later): "Рестораны"}
+	if got := loadCategoryState(t, db).Transactions; !reflect.DeepEqual(got, want) {
+		t.Errorf("после отмены %v, ожидалось %v", got, want)
+	}
+}
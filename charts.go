@@ -0,0 +1,393 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// chartSlice — сегмент круговой диаграммы
+type chartSlice struct {
+	Label string
+	Value float64
+}
+
+// monthTotals — доходы и расходы за месяц для столбчатой диаграммы
+type monthTotals struct {
+	Month   string
+	Income  float64
+	Expense float64
+}
+
+// balancePoint — остаток на конец дня для графика баланса
+type balancePoint struct {
+	Date    string
+	Balance float64
+}
+
+// themeColor берет цвет из активной темы, поэтому графики следуют переключателю темы.
+// Вызывается при каждой отрисовке растра, а не при создании графика.
+func themeColor(name fyne.ThemeColorName) color.Color {
+	settings := fyne.CurrentApp().Settings()
+	return settings.Theme().Color(name, settings.ThemeVariant())
+}
+
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	if x0 > x1 {
+		x0, x1 = x1, x0
+	}
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			img.Set(x, y, c)
+		}
+	}
+}
+
+// drawLine рисует отрезок толщиной в два пикселя алгоритмом Брезенхэма
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx := int(math.Abs(float64(x1 - x0)))
+	dy := -int(math.Abs(float64(y1 - y0)))
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		fillRect(img, x0, y0, x0+1, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// legendItem рисует образец цвета растром, чтобы цвет, как и на самом графике,
+// брался из темы при каждой отрисовке
+func legendItem(name fyne.ThemeColorName, text string) fyne.CanvasObject {
+	swatch := canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		fillRect(img, 0, 0, w-1, h-1, themeColor(name))
+		return img
+	})
+	swatch.SetMinSize(fyne.NewSize(14, 14))
+	return container.NewHBox(container.NewCenter(swatch), widget.NewLabel(text))
+}
+
+// newDonutChart рисует кольцевую диаграмму с легендой справа
+func newDonutChart(slices []chartSlice) fyne.CanvasObject {
+	var total float64
+	for _, s := range slices {
+		total += s.Value
+	}
+	if total <= 0 {
+		return widget.NewLabel(T("Нет данных для диаграммы"))
+	}
+
+	raster := canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		cx, cy := float64(w)/2, float64(h)/2
+		outer := math.Min(cx, cy) - 4
+		inner := outer * 0.55
+		// Цвета берутся из темы один раз на перерисовку, а не для каждой точки
+		colors := make([]color.Color, len(slices))
+		for i := range slices {
+			colors[i] = themeColor(chartColorName(i))
+		}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dx, dy := float64(x)-cx, float64(y)-cy
+				r := math.Hypot(dx, dy)
+				if r > outer || r < inner {
+					continue
+				}
+				// Угол отсчитывается от 12 часов по часовой стрелке
+				angle := math.Atan2(dy, dx) + math.Pi/2
+				if angle < 0 {
+					angle += 2 * math.Pi
+				}
+				fraction := angle / (2 * math.Pi)
+				var cumulative float64
+				for i, s := range slices {
+					cumulative += s.Value / total
+					if fraction <= cumulative || i == len(slices)-1 {
+						img.Set(x, y, colors[i])
+						break
+					}
+				}
+			}
+		}
+		return img
+	})
+	raster.SetMinSize(fyne.NewSize(300, 300))
+
+	legend := container.NewVBox()
+	for i, s := range slices {
+		legend.Add(legendItem(chartColorName(i),
+			fmt.Sprintf("%s: %s (%.1f%%)", s.Label, money(s.Value), s.Value/total*100)))
+	}
+	return container.NewBorder(nil, nil, nil, legend, raster)
+}
+
+// newIncomeExpenseChart рисует столбцы по месяцам: расходы поставлены на доходы,
+// так что высота столбца показывает оборот, а доля цветов - баланс месяца
+func newIncomeExpenseChart(months []monthTotals) fyne.CanvasObject {
+	if len(months) == 0 {
+		return widget.NewLabel(T("Нет данных для диаграммы"))
+	}
+	var maxIncome, maxExpense, maxTotal float64
+	for _, m := range months {
+		maxIncome = math.Max(maxIncome, m.Income)
+		maxExpense = math.Max(maxExpense, m.Expense)
+		maxTotal = math.Max(maxTotal, m.Income+m.Expense)
+	}
+	if maxTotal <= 0 {
+		return widget.NewLabel(T("Нет данных для диаграммы"))
+	}
+
+	raster := canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		const pad = 6
+		scale := float64(h-2*pad) / maxTotal
+		bottom := h - pad
+		slot := float64(w-2*pad) / float64(len(months))
+		barWidth := int(math.Max(slot*0.6, 1))
+
+		incomeColor := themeColor(theme.ColorNameSuccess)
+		expenseColor := themeColor(theme.ColorNameError)
+		for i, m := range months {
+			x0 := pad + int(slot*float64(i)+(slot-float64(barWidth))/2)
+			x1 := x0 + barWidth - 1
+			top := bottom - int(m.Income*scale)
+			if m.Income > 0 {
+				fillRect(img, x0, top, x1, bottom-1, incomeColor)
+			}
+			if m.Expense > 0 {
+				fillRect(img, x0, top-int(m.Expense*scale), x1, top-1, expenseColor)
+			}
+		}
+		fillRect(img, pad, bottom, w-pad, bottom, themeColor(theme.ColorNameForeground))
+		return img
+	})
+	raster.SetMinSize(fyne.NewSize(600, 250))
+
+	// Подписи месяцев помещаются под столбцами только при небольшом их числе
+	var axis fyne.CanvasObject
+	if len(months) <= 12 {
+		labels := make([]fyne.CanvasObject, 0, len(months))
+		for _, m := range months {
+			labels = append(labels, widget.NewLabelWithStyle(m.Month, fyne.TextAlignCenter, fyne.TextStyle{}))
+		}
+		axis = container.NewGridWithColumns(len(months), labels...)
+	} else {
+		axis = container.NewBorder(nil, nil, widget.NewLabel(months[0].Month), widget.NewLabel(months[len(months)-1].Month))
+	}
+
+	legend := container.NewHBox(
+		legendItem(theme.ColorNameSuccess, Tf("Доходы (макс. %s)", money(maxIncome))),
+		legendItem(theme.ColorNameError, Tf("Расходы (макс. %s)", money(maxExpense))),
+	)
+	return container.NewBorder(nil, container.NewVBox(axis, legend), nil, nil, raster)
+}
+
+// newBalanceChart рисует линию накопленного баланса по дням периода
+func newBalanceChart(points []balancePoint) fyne.CanvasObject {
+	if len(points) == 0 {
+		return widget.NewLabel(T("Нет данных для графика"))
+	}
+	low, high := math.Min(points[0].Balance, 0), math.Max(points[0].Balance, 0)
+	for _, p := range points {
+		low = math.Min(low, p.Balance)
+		high = math.Max(high, p.Balance)
+	}
+	if high == low {
+		high = low + 1
+	}
+
+	raster := canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		const pad = 6
+		toY := func(v float64) int {
+			return pad + int((high-v)/(high-low)*float64(h-2*pad))
+		}
+		toX := func(i int) int {
+			if len(points) == 1 {
+				return w / 2
+			}
+			return pad + i*(w-2*pad)/(len(points)-1)
+		}
+
+		fillRect(img, pad, toY(0), w-pad, toY(0), themeColor(theme.ColorNameForeground))
+		lineColor := themeColor(theme.ColorNamePrimary)
+		for i := 1; i < len(points); i++ {
+			drawLine(img, toX(i-1), toY(points[i-1].Balance), toX(i), toY(points[i].Balance), lineColor)
+		}
+		if len(points) == 1 {
+			fillRect(img, toX(0)-2, toY(points[0].Balance)-2, toX(0)+2, toY(points[0].Balance)+2, lineColor)
+		}
+		return img
+	})
+	raster.SetMinSize(fyne.NewSize(600, 250))
+
+	first, last := points[0], points[len(points)-1]
+	axis := container.NewBorder(nil, nil,
+		widget.NewLabel(fmt.Sprintf("%s: %s", first.Date, money(first.Balance))),
+		widget.NewLabel(fmt.Sprintf("%s: %s", last.Date, money(last.Balance))))
+	legend := legendItem(theme.ColorNamePrimary,
+		Tf("Баланс (мин. %s, макс. %s)", money(low), money(high)))
+	return container.NewBorder(nil, container.NewVBox(axis, legend), nil, nil, raster)
+}
+
+// loadMonthTotals возвращает доходы и расходы по месяцам выбранного периода; как и
+// таблица статистики, только по счетам в основной валюте
+func loadMonthTotals(db *sql.DB, period periodFilter) ([]monthTotals, error) {
+	cond, args, err := period.condition("date")
+	if err != nil {
+		return nil, err
+	}
+	inBase, baseArgs := baseCurrencyCondition("account_id")
+	rows, err := db.Query(`
+		SELECT strftime('%Y-%m', date) AS month, type, SUM(amount)
+		FROM transaction_lines
+		WHERE `+cond+` AND `+inBase+`
+		GROUP BY month, type
+		ORDER BY month
+	`, append(args, baseArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var months []monthTotals
+	for rows.Next() {
+		var month, kind string
+		var total float64
+		if err := rows.Scan(&month, &kind, &total); err != nil {
+			continue
+		}
+		if len(months) == 0 || months[len(months)-1].Month != month {
+			months = append(months, monthTotals{Month: month})
+		}
+		switch kind {
+		case typeIncome:
+			months[len(months)-1].Income += total
+		case typeExpense:
+			months[len(months)-1].Expense += total
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	start, end := period.bounds()
+	return fillMonthGaps(months, start, end), nil
+}
+
+// fillMonthGaps добавляет нулевые месяцы без операций, чтобы столбцы шли подряд
+// от начала до конца периода, а если граница не задана - от первой или до последней операции
+func fillMonthGaps(months []monthTotals, start, end string) []monthTotals {
+	first, last := start, end
+	if len(months) > 0 {
+		if first == "" {
+			first = months[0].Month
+		}
+		if last == "" {
+			last = months[len(months)-1].Month
+		}
+	}
+	if len(first) < 7 || len(last) < 7 {
+		return months
+	}
+	from, err := time.Parse("2006-01", first[:7])
+	if err != nil {
+		return months
+	}
+	to, err := time.Parse("2006-01", last[:7])
+	if err != nil {
+		return months
+	}
+
+	byMonth := make(map[string]monthTotals, len(months))
+	for _, m := range months {
+		byMonth[m.Month] = m
+	}
+	var filled []monthTotals
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		if m, ok := byMonth[key]; ok {
+			filled = append(filled, m)
+		} else {
+			filled = append(filled, monthTotals{Month: key})
+		}
+	}
+	return filled
+}
+
+// loadBalancePoints возвращает накопленный баланс на конец каждого дня с операциями.
+// Отсчет начинается с остатка на начало периода, а не с нуля. Учитываются только
+// счета в основной валюте.
+func loadBalancePoints(db *sql.DB, period periodFilter) ([]balancePoint, error) {
+	cond, args, err := period.condition("date")
+	if err != nil {
+		return nil, err
+	}
+	inBase, baseArgs := baseCurrencyCondition("account_id")
+
+	var balance float64
+	if start, _ := period.bounds(); start != "" {
+		err := db.QueryRow(`
+			SELECT COALESCE(SUM(CASE WHEN type = ? THEN amount WHEN type = ? THEN -amount ELSE 0 END), 0)
+			FROM transactions
+			WHERE date < ? AND `+inBase+`
+		`, append([]interface{}{typeIncome, typeExpense, start}, baseArgs...)...).Scan(&balance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := db.Query(`
+		SELECT date, SUM(CASE WHEN type = ? THEN amount WHEN type = ? THEN -amount ELSE 0 END)
+		FROM transactions
+		WHERE `+cond+` AND `+inBase+`
+		GROUP BY date
+		ORDER BY date
+	`, append(append([]interface{}{typeIncome, typeExpense}, args...), baseArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []balancePoint
+	for rows.Next() {
+		var p balancePoint
+		var delta float64
+		if err := rows.Scan(&p.Date, &delta); err != nil {
+			continue
+		}
+		balance += delta
+		p.Balance = balance
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadMonthTotals(t *testing.T) {
+	db := openTestDB(t)
+	useTestBaseCurrency(t, db)
+	if err := saveAccount(db, Account{Name: "Доллары", Currency: "USD"}); err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dollars, _ := accountByName(accounts, "Доллары")
+	for _, tr := range []Transaction{
+		{Date: "2026-02-10", Type: typeIncome, Category: "Зарплата", Amount: 1000},
+		{Date: "2026-02-15", Type: typeExpense, Category: "Еда", Amount: 300},
+		{Date: "2026-04-01", Type: typeExpense, Amount: 500, Splits: []SplitLine{
+			{Category: "Еда", Amount: 200}, {Category: "Дом", Amount: 300}}},
+		// Счет в другой валюте в графики не попадает
+		{Date: "2026-02-20", Type: typeExpense, Category: "Еда", Amount: 40, AccountID: dollars.ID},
+	} {
+		if _, err := insertTransaction(db, tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		period periodFilter
+		want   []monthTotals
+	}{
+		{
+			name:   "все время от первой до последней операции",
+			period: periodFilter{Kind: periodAll},
+			want: []monthTotals{
+				{Month: "2026-02", Income: 1000, Expense: 300},
+				{Month: "2026-03"},
+				{Month: "2026-04", Expense: 500},
+			},
+		},
+		{
+			name:   "период шире операций",
+			period: periodFilter{Kind: periodCustom, Start: "2026-01-20", End: "2026-05-10"},
+			want: []monthTotals{
+				{Month: "2026-01"},
+				{Month: "2026-02", Income: 1000, Expense: 300},
+				{Month: "2026-03"},
+				{Month: "2026-04", Expense: 500},
+				{Month: "2026-05"},
+			},
+		},
+		{
+			name:   "месяц без операций",
+			period: periodFilter{Kind: periodMonth, Year: "2026", Month: 3},
+			want:   []monthTotals{{Month: "2026-03"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadMonthTotals(db, tt.period)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("получено %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// classifierMinExamples — сколько размеченных транзакций нужно, чтобы начать подсказывать
+const classifierMinExamples = 5
+
+// CategorySuggestion — категория, предложенная классификатором, и уверенность от 0 до 1
+type CategorySuggestion struct {
+	Category   string
+	Confidence float64
+}
+
+// categoryClassifier — наивный байесовский классификатор категорий по словам
+// описания, типу и порядку суммы. Обучается на собственной истории и дообучается
+// на каждой сохраненной транзакции; ничего не отправляет наружу.
+type categoryClassifier struct {
+	mu       sync.Mutex
+	examples map[string]int            // транзакций в категории
+	features map[string]map[string]int // категория -> признак -> сколько раз встретился
+	totals   map[string]int            // всего признаков в категории
+	vocab    map[string]int            // в скольких категориях встречается признак
+	count    int
+}
+
+func newCategoryClassifier() *categoryClassifier {
+	return &categoryClassifier{
+		examples: make(map[string]int),
+		features: make(map[string]map[string]int),
+		totals:   make(map[string]int),
+		vocab:    make(map[string]int),
+	}
+}
+
+// Классификаторы открытых баз: профиль можно переключить, не перезапуская приложение
+var (
+	classifiersMu sync.Mutex
+	classifiers   = make(map[*sql.DB]*categoryClassifier)
+)
+
+// classifierFor возвращает классификатор базы, при первом обращении обучая его на истории
+func classifierFor(db *sql.DB) (*categoryClassifier, error) {
+	classifiersMu.Lock()
+	defer classifiersMu.Unlock()
+	if c, ok := classifiers[db]; ok {
+		return c, nil
+	}
+	transactions, err := loadTransactions(db, periodFilter{Kind: periodAll})
+	if err != nil {
+		return nil, err
+	}
+	c := newCategoryClassifier()
+	for _, t := range transactions {
+		c.learn(t, 1)
+	}
+	classifiers[db] = c
+	return c, nil
+}
+
+// learnTransaction дообучает уже загруженный классификатор базы на новой транзакции
+func learnTransaction(db *sql.DB, t Transaction) {
+	classifiersMu.Lock()
+	c, ok := classifiers[db]
+	classifiersMu.Unlock()
+	if ok {
+		c.learn(t, 1)
+	}
+}
+
+// relearnTransaction учитывает исправление: старая разметка забывается, новая запоминается
+func relearnTransaction(db *sql.DB, before, after Transaction) {
+	classifiersMu.Lock()
+	c, ok := classifiers[db]
+	classifiersMu.Unlock()
+	if ok {
+		c.learn(before, -1)
+		c.learn(after, 1)
+	}
+}
+
+// unlearnTransaction убирает удаленную транзакцию из обучения
+func unlearnTransaction(db *sql.DB, t Transaction) {
+	classifiersMu.Lock()
+	c, ok := classifiers[db]
+	classifiersMu.Unlock()
+	if ok {
+		c.learn(t, -1)
+	}
+}
+
+// forgetClassifier освобождает классификатор закрываемой базы
+func forgetClassifier(db *sql.DB) {
+	classifiersMu.Lock()
+	delete(classifiers, db)
+	classifiersMu.Unlock()
+}
+
+// classifierFeatures — слова описания, тип и порядок суммы (по полдекады)
+func classifierFeatures(t Transaction, amount float64) []string {
+	var features []string
+	words := strings.FieldsFunc(strings.ToLower(t.Description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		// Номера карт, магазинов и чеков только мешают
+		if len([]rune(word)) < 2 || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		features = append(features, "w:"+word)
+	}
+	if t.Type != "" {
+		features = append(features, "t:"+t.Type)
+	}
+	if amount > 0 {
+		features = append(features, "a:"+strconv.Itoa(int(math.Floor(math.Log10(amount)*2))))
+	}
+	return features
+}
+
+// learn добавляет (weight = 1) или убирает (weight = -1) транзакцию из обучения.
+// Разделенная транзакция учит каждую свою категорию на сумме соответствующей части.
+func (c *categoryClassifier) learn(t Transaction, weight int) {
+	if t.Type == typeTransfer {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, line := range transactionLines(t) {
+		if line.Category == "" {
+			continue
+		}
+		c.examples[line.Category] += weight
+		c.count += weight
+		counts := c.features[line.Category]
+		if counts == nil {
+			counts = make(map[string]int)
+			c.features[line.Category] = counts
+		}
+		for _, f := range classifierFeatures(t, line.Amount) {
+			if counts[f] == 0 && weight > 0 {
+				c.vocab[f]++
+			}
+			counts[f] += weight
+			c.totals[line.Category] += weight
+			if counts[f] <= 0 {
+				delete(counts, f)
+				if c.vocab[f]--; c.vocab[f] <= 0 {
+					delete(c.vocab, f)
+				}
+			}
+		}
+		if c.examples[line.Category] <= 0 {
+			delete(c.examples, line.Category)
+			delete(c.features, line.Category)
+			delete(c.totals, line.Category)
+		}
+	}
+}
+
+// suggest возвращает до limit категорий с наибольшей апостериорной вероятностью
+func (c *categoryClassifier) suggest(t Transaction, limit int) []CategorySuggestion {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.count < classifierMinExamples || t.Type == typeTransfer {
+		return nil
+	}
+	features := classifierFeatures(t, t.Amount)
+	if len(features) == 0 {
+		return nil
+	}
+
+	// Логарифмы вероятностей со сглаживанием Лапласа
+	vocabulary := float64(len(c.vocab) + 1)
+	scores := make(map[string]float64, len(c.examples))
+	best := math.Inf(-1)
+	for category, n := range c.examples {
+		score := math.Log(float64(n) / float64(c.count))
+		for _, f := range features {
+			score += math.Log((float64(c.features[category][f]) + 1) / (float64(c.totals[category]) + vocabulary))
+		}
+		scores[category] = score
+		best = math.Max(best, score)
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - best)
+	}
+
+	suggestions := make([]CategorySuggestion, 0, len(scores))
+	for category, score := range scores {
+		suggestions = append(suggestions, CategorySuggestion{Category: category, Confidence: math.Exp(score-best) / sum})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		return suggestions[i].Category < suggestions[j].Category
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// suggestCategories — подсказки для транзакции по истории базы
+func suggestCategories(db *sql.DB, t Transaction, limit int) []CategorySuggestion {
+	c, err := classifierFor(db)
+	if err != nil {
+		return nil
+	}
+	return c.suggest(t, limit)
+}
+
+// describeSuggestion — подпись подсказки, например "Продукты 82%"
+func describeSuggestion(s CategorySuggestion) string {
+	return fmt.Sprintf("%s %.0f%%", s.Category, s.Confidence*100)
+}
@@ -0,0 +1,138 @@
+package main
+
+import "testing"
+
+func TestCategoryClassifier(t *testing.T) {
+	expense := func(description, category string, amount float64) Transaction {
+		return Transaction{Type: typeExpense, Description: description, Category: category, Amount: amount}
+	}
+	history := []Transaction{
+		expense("ПЯТЕРОЧКА 1234 МОСКВА", "Продукты", 850),
+		expense("Пятерочка у дома", "Продукты", 1200),
+		expense("ПЕРЕКРЕСТОК", "Продукты", 2300),
+		expense("Яндекс Такси", "Транспорт", 450),
+		expense("Такси до аэропорта", "Транспорт", 1900),
+		expense("Кофейня", "Кафе", 300),
+		{Type: typeIncome, Description: "Зарплата ООО Ромашка", Category: "Зарплата", Amount: 90000},
+	}
+
+	tests := []struct {
+		name    string
+		history []Transaction
+		forget  []Transaction
+		query   Transaction
+		want    string // пусто - подсказок быть не должно
+	}{
+		{
+			name:    "магазин из истории",
+			history: history,
+			query:   expense("ПЯТЕРОЧКА 9876", "", 640),
+			want:    "Продукты",
+		},
+		{
+			name:    "слово в другом регистре",
+			history: history,
+			query:   expense("такси", "", 500),
+			want:    "Транспорт",
+		},
+		{
+			name:    "доход по типу и порядку суммы",
+			history: history,
+			query:   Transaction{Type: typeIncome, Description: "Аванс ООО Ромашка", Amount: 45000},
+			want:    "Зарплата",
+		},
+		{
+			name:    "мало примеров",
+			history: history[:classifierMinExamples-1],
+			query:   expense("Пятерочка", "", 500),
+		},
+		{
+			name:    "переводы не подсказываются",
+			history: history,
+			query:   Transaction{Type: typeTransfer, Description: "Пятерочка", Amount: 500},
+		},
+		{
+			name:    "забытая категория не подсказывается",
+			history: history,
+			forget:  history[3:5],
+			query:   expense("Такси", "", 500),
+			want:    "Продукты",
+		},
+		{
+			name: "части учат свои категории",
+			history: append([]Transaction{
+				{Type: typeExpense, Description: "Гипермаркет Лента", Amount: 5000, Splits: []SplitLine{
+					{Category: "Продукты", Amount: 3000}, {Category: "Хозтовары", Amount: 2000}}},
+				{Type: typeExpense, Description: "Лента хозтовары", Category: "Хозтовары", Amount: 1500},
+			}, history...),
+			query: expense("Лента хозтовары", "", 1800),
+			want:  "Хозтовары",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCategoryClassifier()
+			for _, h := range tt.history {
+				c.learn(h, 1)
+			}
+			for _, h := range tt.forget {
+				c.learn(h, -1)
+			}
+			suggestions := c.suggest(tt.query, 3)
+			if tt.want == "" {
+				if len(suggestions) != 0 {
+					t.Errorf("ожидалось без подсказок, получено %v", suggestions)
+				}
+				return
+			}
+			if len(suggestions) == 0 {
+				t.Fatal("нет подсказок")
+			}
+			if suggestions[0].Category != tt.want {
+				t.Errorf("первая подсказка %v, ожидалась %q", suggestions, tt.want)
+			}
+			var total float64
+			for i, s := range suggestions {
+				if s.Confidence <= 0 || s.Confidence > 1 {
+					t.Errorf("уверенность %v вне (0, 1]", s.Confidence)
+				}
+				if i > 0 && s.Confidence > suggestions[i-1].Confidence {
+					t.Errorf("подсказки не упорядочены: %v", suggestions)
+				}
+				total += s.Confidence
+			}
+			if total > 1+1e-9 {
+				t.Errorf("сумма уверенностей %v больше 1", total)
+			}
+			for _, f := range tt.forget {
+				for _, s := range suggestions {
+					if s.Category == f.Category {
+						t.Errorf("подсказана забытая категория %q", s.Category)
+					}
+				}
+			}
+		})
+	}
+}
+
+// Обучение и забывание одной и той же транзакции возвращают классификатор в исходное состояние
+func TestCategoryClassifierUnlearn(t *testing.T) {
+	c := newCategoryClassifier()
+	base := Transaction{Type: typeExpense, Description: "Аптека", Category: "Здоровье", Amount: 700}
+	c.learn(base, 1)
+	extra := Transaction{Type: typeExpense, Description: "Кино Октябрь", Category: "Развлечения", Amount: 600}
+	c.learn(extra, 1)
+	c.learn(extra, -1)
+
+	if c.count != 1 || len(c.examples) != 1 || c.examples["Здоровье"] != 1 {
+		t.Errorf("после забывания осталось: count=%d examples=%v", c.count, c.examples)
+	}
+	for feature := range c.vocab {
+		if feature == "w:кино" || feature == "w:октябрь" {
+			t.Errorf("в словаре остался признак %q", feature)
+		}
+	}
+	if _, ok := c.features["Развлечения"]; ok {
+		t.Error("остались признаки забытой категории")
+	}
+}
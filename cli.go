@@ -0,0 +1,544 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Формат вывода подкоманд
+const (
+	outputTable = "table"
+	outputCSV   = "csv"
+	outputJSON  = "json"
+)
+
+const cliUsage = `Использование: finance_tracker [--db файл | --profile имя] <команда> [флаги]
+
+Без команды запускается графический интерфейс. По умолчанию база хранится
+в $XDG_DATA_HOME/finance-tracker (~/.local/share/finance-tracker), у каждого
+профиля своя база. Флаг --db или переменная FINANCE_DB задают файл явно.
+
+Команды:
+  add          добавить транзакцию
+  list         список транзакций за период
+  stats        доходы и расходы по категориям за период
+  budget set   задать лимит бюджета
+  budget show  показать исполнение бюджетов
+  export       выгрузить транзакции в CSV или JSON
+  import       загрузить транзакции из CSV или JSON
+  serve        запустить HTTP API (JSON) с доступом по токену
+
+Флаги периода (list, stats, export; в API - параметры запроса с теми же именами):
+  -period all|year|month|custom  -year 2026  -month 10  -from 2026-01-01  -to 2026-03-31
+
+Справка по флагам команды: finance_tracker <команда> -h
+`
+
+// Коды периодов и типов для командной строки
+var cliPeriods = map[string]string{
+	"all":    periodAll,
+	"year":   periodYear,
+	"month":  periodMonth,
+	"custom": periodCustom,
+}
+
+var cliTypes = map[string]string{
+	"income":   typeIncome,
+	"expense":  typeExpense,
+	"transfer": typeTransfer,
+}
+
+// runCLI выполняет подкоманду и возвращает код завершения процесса
+func runCLI(options globalOptions, stdin io.Reader, stdout, stderr io.Writer) int {
+	args := options.args
+	if args[0] == "help" {
+		fmt.Fprint(stdout, T(cliUsage))
+		return 0
+	}
+
+	db, _, _, err := options.openDatabase()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	var command func(*sql.DB, []string, io.Reader, io.Writer, io.Writer) error
+	switch args[0] {
+	case "add":
+		command = cliAdd
+	case "list":
+		command = cliList
+	case "stats":
+		command = cliStats
+	case "budget":
+		command = cliBudget
+	case "export":
+		command = cliExport
+	case "import":
+		command = cliImport
+	case "serve":
+		command = cliServe
+	default:
+		fmt.Fprintf(stderr, T("Неизвестная команда %q\n\n%s"), args[0], T(cliUsage))
+		return 2
+	}
+	if err := command(db, args[1:], stdin, stdout, stderr); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintln(stderr, T("Ошибка:"), err)
+		return 1
+	}
+	return 0
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+// periodFlags — флаги, задающие период так же, как фильтр в окне статистики
+type periodFlags struct {
+	kind  string
+	year  string
+	month int
+	start string
+	end   string
+}
+
+func addPeriodFlags(fs *flag.FlagSet) *periodFlags {
+	p := &periodFlags{}
+	fs.StringVar(&p.kind, "period", "all", T("период: all, year, month или custom"))
+	fs.StringVar(&p.year, "year", "", T("год для периодов year и month"))
+	fs.IntVar(&p.month, "month", 0, T("номер месяца (1-12) для периода month"))
+	fs.StringVar(&p.start, "from", "", T("начальная дата периода custom (YYYY-MM-DD)"))
+	fs.StringVar(&p.end, "to", "", T("конечная дата периода custom (YYYY-MM-DD)"))
+	return p
+}
+
+func (p *periodFlags) filter() (periodFilter, error) {
+	kind, ok := cliPeriods[p.kind]
+	if !ok {
+		return periodFilter{}, fmt.Errorf(T("неизвестный период %q"), p.kind)
+	}
+	filter := periodFilter{Kind: kind, Year: p.year, Month: p.month, Start: p.start, End: p.end}
+	if _, _, err := filter.condition("date"); err != nil {
+		return periodFilter{}, err
+	}
+	return filter, nil
+}
+
+func checkOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputCSV, outputJSON:
+		return nil
+	}
+	return fmt.Errorf(T("неизвестный формат %q: ожидается table, csv или json"), format)
+}
+
+// writeRows выводит таблицу с выравниванием или в CSV
+func writeRows(w io.Writer, format string, header []string, rows [][]string) error {
+	if format == outputCSV {
+		writer := csv.NewWriter(w)
+		writer.Write(header)
+		writer.WriteAll(rows)
+		return writer.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// splitFlags собирает повторяющийся флаг -split "категория:сумма[:заметка]"
+type splitFlags []SplitLine
+
+func (s *splitFlags) String() string { return "" }
+
+func (s *splitFlags) Set(value string) error {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) < 2 {
+		return errors.New(T("ожидается категория:сумма[:заметка]"))
+	}
+	amount, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return fmt.Errorf(T("неверная сумма %q"), parts[1])
+	}
+	line := SplitLine{Category: parts[0], Amount: amount}
+	if len(parts) == 3 {
+		line.Note = parts[2]
+	}
+	*s = append(*s, line)
+	return nil
+}
+
+func cliAdd(db *sql.DB, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("add", stderr)
+	kind := fs.String("type", "expense", T("тип: income, expense или transfer"))
+	category := fs.String("category", "", T("категория"))
+	amount := fs.Float64("amount", 0, T("сумма"))
+	description := fs.String("description", "", T("описание"))
+	date := fs.String("date", time.Now().Format("2006-01-02"), T("дата (YYYY-MM-DD)"))
+	account := fs.String("account", "", T("счет (по умолчанию основной)"))
+	toAccount := fs.String("to-account", "", T("счет зачисления для перевода"))
+	tags := fs.String("tags", "", T("теги через запятую"))
+	var splits splitFlags
+	fs.Var(&splits, "split", T("часть транзакции категория:сумма[:заметка], флаг повторяется"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	t := Transaction{
+		Date:        *date,
+		Category:    *category,
+		Amount:      *amount,
+		Description: *description,
+		Type:        cliTypes[*kind],
+		Splits:      splits,
+		Tags:        mergeTags("", *tags),
+	}
+	if t.Type == "" {
+		return fmt.Errorf(T("неизвестный тип %q"), *kind)
+	}
+	// Перевод без счета зачисления только уменьшил бы остаток, как расход
+	if t.Type == typeTransfer && *toAccount == "" {
+		return errors.New(T("для перевода укажите счет зачисления флагом -to-account"))
+	}
+	if t.Type != typeTransfer && *toAccount != "" {
+		return errors.New(T("флаг -to-account задается только для перевода"))
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		return err
+	}
+	// Перевод без счета списания считался бы переводом из портфеля
+	t.AccountID = defaultAccountID
+	if *account != "" {
+		acc, ok := accountByName(accounts, *account)
+		if !ok {
+			return fmt.Errorf(T("нет счета %q"), *account)
+		}
+		t.AccountID = acc.ID
+	}
+	if *toAccount != "" {
+		acc, ok := accountByName(accounts, *toAccount)
+		if !ok {
+			return fmt.Errorf(T("нет счета %q"), *toAccount)
+		}
+		t.TransferAccountID = acc.ID
+	}
+	t, err = applyStoredMarkup(db, t)
+	if err != nil {
+		return err
+	}
+	if err := validateTransaction(db, t); err != nil {
+		return err
+	}
+	id, err := insertTransaction(db, t)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(stdout, id)
+
+	// Предупреждения о бюджетах выводятся так же, как уведомления в окне
+	var categories []string
+	for _, line := range transactionLines(t) {
+		categories = append(categories, line.Category)
+	}
+	notifications, err := checkBudgetAlerts(db, time.Now(), categories)
+	if err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		fmt.Fprintf(stderr, "%s: %s\n", n.Title, n.Content)
+	}
+	return nil
+}
+
+func cliList(db *sql.DB, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("list", stderr)
+	period := addPeriodFlags(fs)
+	format := fs.String("format", outputTable, T("формат вывода: table, csv или json"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	filter, err := period.filter()
+	if err != nil {
+		return err
+	}
+	transactions, err := loadTransactions(db, filter)
+	if err != nil {
+		return err
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case outputJSON:
+		return writeJSON(stdout, transactions)
+	case outputCSV:
+		data, err := transactionsToCSV(transactions, accounts)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(stdout, data)
+		return err
+	}
+
+	accountNamesByID := map[int]string{0: T(portfolioAccountName)}
+	for _, acc := range accounts {
+		accountNamesByID[acc.ID] = acc.Name
+	}
+	var rows [][]string
+	for _, t := range transactions {
+		for i, line := range transactionLines(t) {
+			row := []string{strconv.Itoa(t.ID), t.Date, accountNamesByID[t.AccountID], typeLabel(t.Type),
+				line.Category, fmt.Sprintf("%.2f", line.Amount), t.Description}
+			if i > 0 {
+				// Части разделенной транзакции показываем под первой строкой
+				row = []string{"", "", "", "", line.Category, fmt.Sprintf("%.2f", line.Amount), line.Note}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return writeRows(stdout, *format, []string{"ID", T("Дата"), T("Счет"), T("Тип"), T("Категория"), T("Сумма"), T("Описание")}, rows)
+}
+
+// CategoryTotal — сумма по категории в отчете stats
+type CategoryTotal struct {
+	Type     string
+	Category string
+	Total    float64
+}
+
+// StatsReport — итоги за период, как в окне статистики
+type StatsReport struct {
+	Period     string
+	Income     float64
+	Expense    float64
+	Balance    float64
+	Categories []CategoryTotal
+}
+
+func buildStatsReport(db *sql.DB, period periodFilter) (StatsReport, error) {
+	report := StatsReport{Period: period.description()}
+	totals, err := categoryTotals(db, period)
+	if err != nil {
+		return report, err
+	}
+	for key, total := range totals {
+		report.Categories = append(report.Categories, CategoryTotal{Type: key[0], Category: key[1], Total: total})
+		switch key[0] {
+		case typeIncome:
+			report.Income += total
+		case typeExpense:
+			report.Expense += total
+		}
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Total > b.Total
+	})
+	report.Balance = report.Income - report.Expense
+	return report, nil
+}
+
+func cliStats(db *sql.DB, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("stats", stderr)
+	period := addPeriodFlags(fs)
+	format := fs.String("format", outputTable, T("формат вывода: table, csv или json"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkOutputFormat(*format); err != nil {
+		return err
+	}
+	filter, err := period.filter()
+	if err != nil {
+		return err
+	}
+	report, err := buildStatsReport(db, filter)
+	if err != nil {
+		return err
+	}
+
+	if *format == outputJSON {
+		return writeJSON(stdout, report)
+	}
+	if *format == outputTable {
+		fmt.Fprintf(stdout, T("Период: %s\nОбщий доход: %.2f\nОбщий расход: %.2f\nБаланс: %.2f\n\n"),
+			report.Period, report.Income, report.Expense, report.Balance)
+	}
+	var rows [][]string
+	for _, c := range report.Categories {
+		rows = append(rows, []string{typeLabel(c.Type), c.Category, fmt.Sprintf("%.2f", c.Total)})
+	}
+	return writeRows(stdout, *format, []string{T("Тип"), T("Категория"), T("Сумма")}, rows)
+}
+
+func cliBudget(db *sql.DB, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(T("ожидается budget set или budget show"))
+	}
+	switch args[0] {
+	case "set":
+		fs := newFlagSet("budget set", stderr)
+		category := fs.String("category", "", T("категория"))
+		limit := fs.Float64("limit", 0, T("лимит"))
+		period := fs.String("period", budgetPeriodMonthly, T("период бюджета: monthly, weekly, yearly или custom"))
+		start := fs.String("from", "", T("начальная дата периода custom (YYYY-MM-DD)"))
+		end := fs.String("to", "", T("конечная дата периода custom (YYYY-MM-DD)"))
+		rollover := fs.String("rollover", rolloverNone, T("перенос остатка: none, positive или full"))
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if !isBudgetPeriod(*period) {
+			return fmt.Errorf(T("неизвестный период бюджета %q"), *period)
+		}
+		if !isRolloverRule(*rollover) {
+			return fmt.Errorf(T("неизвестное правило переноса %q"), *rollover)
+		}
+		return saveBudget(db, Budget{
+			Category: *category, Limit: *limit, Period: *period,
+			StartDate: *start, EndDate: *end, Rollover: *rollover,
+		})
+	case "show":
+		fs := newFlagSet("budget show", stderr)
+		format := fs.String("format", outputTable, T("формат вывода: table, csv или json"))
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if err := checkOutputFormat(*format); err != nil {
+			return err
+		}
+		progress, err := budgetProgress(db, time.Now())
+		if err != nil {
+			return err
+		}
+		if *format == outputJSON {
+			return writeJSON(stdout, progress)
+		}
+		var rows [][]string
+		for _, p := range progress {
+			rows = append(rows, []string{
+				p.Category, budgetPeriodLabel(p.Period), p.Start, p.End,
+				fmt.Sprintf("%.2f", p.Limit), fmt.Sprintf("%.2f", p.Spent),
+				fmt.Sprintf("%.2f", p.Remaining), fmt.Sprintf("%.0f%%", p.Percent),
+			})
+		}
+		return writeRows(stdout, *format,
+			[]string{T("Категория"), T("Период"), T("Начало"), T("Конец"), T("Лимит"), T("Потрачено"), T("Остаток"), "%"}, rows)
+	default:
+		return fmt.Errorf(T("неизвестная команда budget %s"), args[0])
+	}
+}
+
+func cliExport(db *sql.DB, args []string, _ io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("export", stderr)
+	period := addPeriodFlags(fs)
+	format := fs.String("format", formatCSV, T("формат: csv или json"))
+	output := fs.String("o", "", T("файл для записи (по умолчанию стандартный вывод)"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filter, err := period.filter()
+	if err != nil {
+		return err
+	}
+	transactions, err := loadTransactions(db, filter)
+	if err != nil {
+		return err
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		return err
+	}
+
+	var data string
+	switch *format {
+	case formatCSV:
+		data, err = transactionsToCSV(transactions, accounts)
+	case formatJSON:
+		data, err = transactionsToJSON(transactions)
+	default:
+		return fmt.Errorf(T("неизвестный формат %q: ожидается csv или json"), *format)
+	}
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = io.WriteString(stdout, data)
+		return err
+	}
+	return os.WriteFile(*output, []byte(data), 0644)
+}
+
+func cliImport(db *sql.DB, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("import", stderr)
+	format := fs.String("format", "", T("формат: csv или json (по умолчанию по расширению файла)"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New(T("укажите файл для импорта или - для стандартного ввода"))
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	input := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		return err
+	}
+	transactions, err := parseTransactions(input, *format, accounts)
+	if err != nil {
+		return err
+	}
+	saved, err := importTransactions(db, transactions)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, T("Импортировано транзакций: %d\n"), len(saved))
+	return nil
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Режимы окна статистики
+const (
+	statsModeOverview = "Обзор"
+	statsModeCompare  = "Сравнение"
+	statsModePayees   = "По получателям"
+)
+
+// С чем сравнивается выбранный период
+const (
+	compareWithPrevious = "Предыдущий период"
+	compareWithYearAgo  = "Тот же период год назад"
+	compareWithCustom   = "Другой период"
+)
+
+var compareOptions = []string{compareWithPrevious, compareWithYearAgo, compareWithCustom}
+
+// highlightCount — сколько наибольших ростов выделять в каждом типе
+const highlightCount = 3
+
+// CategoryDelta — изменение суммы категории между двумя периодами
+type CategoryDelta struct {
+	Type       string
+	Category   string
+	Before     float64
+	After      float64
+	Delta      float64
+	Percent    float64
+	HasPercent bool
+	Trend      []float64
+}
+
+// shift сдвигает период на заданное число лет, месяцев и дней
+func (p periodFilter) shift(years, months, days int) (periodFilter, error) {
+	switch p.Kind {
+	case periodYear:
+		year, err := strconv.Atoi(p.Year)
+		if err != nil {
+			return p, errors.New(T("выберите год"))
+		}
+		p.Year = strconv.Itoa(year + years)
+		return p, nil
+	case periodMonth:
+		year, err := strconv.Atoi(p.Year)
+		if err != nil || p.Month < 1 || p.Month > 12 {
+			return p, errors.New(T("выберите год и месяц"))
+		}
+		t := time.Date(year, time.Month(p.Month), 1, 0, 0, 0, 0, time.Local).AddDate(years, months, 0)
+		p.Year, p.Month = strconv.Itoa(t.Year()), int(t.Month())
+		return p, nil
+	case periodCustom:
+		start, err1 := time.Parse("2006-01-02", p.Start)
+		end, err2 := time.Parse("2006-01-02", p.End)
+		if err1 != nil || err2 != nil {
+			return p, errors.New(T("введите начальную и конечную даты"))
+		}
+		p.Start = start.AddDate(years, months, days).Format("2006-01-02")
+		p.End = end.AddDate(years, months, days).Format("2006-01-02")
+		return p, nil
+	default:
+		return p, errors.New(T("для сравнения выберите год, месяц или период"))
+	}
+}
+
+// previous возвращает предшествующий период той же длины
+func (p periodFilter) previous() (periodFilter, error) {
+	switch p.Kind {
+	case periodYear:
+		return p.shift(-1, 0, 0)
+	case periodMonth:
+		return p.shift(0, -1, 0)
+	case periodCustom:
+		start, err1 := time.Parse("2006-01-02", p.Start)
+		end, err2 := time.Parse("2006-01-02", p.End)
+		if err1 != nil || err2 != nil {
+			return p, errors.New(T("введите начальную и конечную даты"))
+		}
+		days := int(end.Sub(start).Hours()/24) + 1
+		return p.shift(0, 0, -days)
+	default:
+		return p.shift(0, 0, 0)
+	}
+}
+
+func (p periodFilter) yearAgo() (periodFilter, error) {
+	return p.shift(-1, 0, 0)
+}
+
+// categoryTotals возвращает суммы по типу и категории за период. Суммы в разных
+// валютах не складываются, поэтому учитываются только счета в основной валюте.
+func categoryTotals(db *sql.DB, period periodFilter) (map[[2]string]float64, error) {
+	cond, args, err := period.condition("date")
+	if err != nil {
+		return nil, err
+	}
+	inBase, baseArgs := baseCurrencyCondition("account_id")
+	rows, err := db.Query(`
+		SELECT type, category, SUM(amount)
+		FROM transaction_lines
+		WHERE `+cond+` AND `+inBase+`
+		GROUP BY type, category
+	`, append(args, baseArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[[2]string]float64)
+	for rows.Next() {
+		var kind, category string
+		var total float64
+		if err := rows.Scan(&kind, &category, &total); err != nil {
+			continue
+		}
+		totals[[2]string{kind, category}] = total
+	}
+	return totals, rows.Err()
+}
+
+// categoryTrends возвращает суммы по категориям за 12 месяцев, заканчивая месяцем
+// lastMonth; как и в categoryTotals, только по счетам в основной валюте
+func categoryTrends(db *sql.DB, lastMonth time.Time) (map[[2]string][]float64, error) {
+	first := lastMonth.AddDate(0, -11, 0)
+	inBase, baseArgs := baseCurrencyCondition("account_id")
+	rows, err := db.Query(`
+		SELECT type, category, strftime('%Y-%m', date) AS month, SUM(amount)
+		FROM transaction_lines
+		WHERE strftime('%Y-%m', date) BETWEEN ? AND ? AND `+inBase+`
+		GROUP BY type, category, month
+	`, append([]interface{}{first.Format("2006-01"), lastMonth.Format("2006-01")}, baseArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trends := make(map[[2]string][]float64)
+	for rows.Next() {
+		var kind, category, month string
+		var total float64
+		if err := rows.Scan(&kind, &category, &month, &total); err != nil {
+			continue
+		}
+		t, err := time.Parse("2006-01", month)
+		if err != nil {
+			continue
+		}
+		index := (t.Year()-first.Year())*12 + int(t.Month()) - int(first.Month())
+		key := [2]string{kind, category}
+		if trends[key] == nil {
+			trends[key] = make([]float64, 12)
+		}
+		if index >= 0 && index < 12 {
+			trends[key][index] = total
+		}
+	}
+	return trends, rows.Err()
+}
+
+// compareCategories сравнивает суммы категорий периода after с периодом before.
+// Результат упорядочен по типу, затем по убыванию прироста.
+func compareCategories(db *sql.DB, before, after periodFilter) ([]CategoryDelta, error) {
+	beforeTotals, err := categoryTotals(db, before)
+	if err != nil {
+		return nil, err
+	}
+	afterTotals, err := categoryTotals(db, after)
+	if err != nil {
+		return nil, err
+	}
+
+	// Тренд заканчивается последним месяцем сравниваемого периода
+	_, end := after.bounds()
+	lastMonth, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		lastMonth = time.Now()
+	}
+	trends, err := categoryTrends(db, lastMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[[2]string]bool)
+	for key := range beforeTotals {
+		keys[key] = true
+	}
+	for key := range afterTotals {
+		keys[key] = true
+	}
+
+	var deltas []CategoryDelta
+	for key := range keys {
+		d := CategoryDelta{
+			Type:     key[0],
+			Category: key[1],
+			Before:   beforeTotals[key],
+			After:    afterTotals[key],
+			Trend:    trends[key],
+		}
+		d.Delta = d.After - d.Before
+		if d.Before != 0 {
+			d.Percent = d.Delta / d.Before * 100
+			d.HasPercent = true
+		}
+		deltas = append(deltas, d)
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Type != deltas[j].Type {
+			return deltas[i].Type < deltas[j].Type
+		}
+		return deltas[i].Delta > deltas[j].Delta
+	})
+	return deltas, nil
+}
+
+// newSparkline рисует небольшой график значений без осей
+func newSparkline(values []float64) fyne.CanvasObject {
+	raster := canvas.NewRaster(func(w, h int) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		if len(values) < 2 {
+			return img
+		}
+		high := 0.0
+		for _, v := range values {
+			high = math.Max(high, v)
+		}
+		if high == 0 {
+			high = 1
+		}
+		toY := func(v float64) int { return h - 3 - int(v/high*float64(h-6)) }
+		toX := func(i int) int { return 1 + i*(w-3)/(len(values)-1) }
+		color := themeColor(theme.ColorNamePrimary)
+		for i := 1; i < len(values); i++ {
+			drawLine(img, toX(i-1), toY(values[i-1]), toX(i), toY(values[i]), color)
+		}
+		return img
+	})
+	raster.SetMinSize(fyne.NewSize(120, 28))
+	return raster
+}
+
+// showComparison выводит в target таблицу изменений по категориям
+func showComparison(db *sql.DB, before, after periodFilter, target *fyne.Container) error {
+	deltas, err := compareCategories(db, before, after)
+	if err != nil {
+		return err
+	}
+
+	target.Add(widget.NewLabelWithStyle(T("Сравнение периодов"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	target.Add(widget.NewLabel(fmt.Sprintf("%s → %s", before.description(), after.description())))
+	target.Add(widget.NewSeparator())
+	if len(deltas) == 0 {
+		target.Add(widget.NewLabel(T("Нет данных для отображения")))
+		return nil
+	}
+
+	target.Add(container.NewGridWithColumns(7,
+		widget.NewLabelWithStyle(T("Тип"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Категория"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Было"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Стало"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Изменение"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("%", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("12 месяцев"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	))
+
+	highlighted := make(map[string]int)
+	for _, d := range deltas {
+		percent := "—"
+		if d.HasPercent {
+			percent = fmt.Sprintf("%+.1f%%", d.Percent)
+		}
+
+		// Наибольшие приросты выделяем цветом: для расходов это тревожный знак
+		delta := canvas.NewText(signedMoney(d.Delta), themeColor(theme.ColorNameForeground))
+		if d.Delta > 0 && highlighted[d.Type] < highlightCount {
+			highlighted[d.Type]++
+			delta.TextStyle = fyne.TextStyle{Bold: true}
+			if d.Type == typeExpense {
+				delta.Color = themeColor(theme.ColorNameError)
+			} else {
+				delta.Color = themeColor(theme.ColorNameSuccess)
+			}
+		}
+
+		target.Add(container.NewGridWithColumns(7,
+			widget.NewLabel(typeLabel(d.Type)),
+			widget.NewLabel(d.Category),
+			widget.NewLabel(money(d.Before)),
+			widget.NewLabel(money(d.After)),
+			container.NewCenter(delta),
+			widget.NewLabel(percent),
+			newSparkline(d.Trend),
+		))
+	}
+	return nil
+}
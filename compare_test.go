@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Статистика не складывает суммы в разных валютах: счет в евро в нее не входит
+func TestStatsInBaseCurrency(t *testing.T) {
+	db := openTestDB(t)
+	useTestBaseCurrency(t, db)
+	if err := saveAccount(db, Account{Name: "Евро", Currency: "EUR"}); err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	euro, _ := accountByName(accounts, "Евро")
+	for _, tr := range []Transaction{
+		{Date: "2026-09-01", Type: typeIncome, Category: "Зарплата", Amount: 50000},
+		{Date: "2026-09-02", Type: typeIncome, Category: "Зарплата", Amount: 500, AccountID: euro.ID},
+		{Date: "2026-09-05", Type: typeExpense, Category: "Еда", Amount: 3000},
+		{Date: "2026-09-06", Type: typeExpense, Category: "Еда", Amount: 20, AccountID: euro.ID},
+		{Date: "2026-09-07", Type: typeExpense, Category: "Отель", Amount: 300, AccountID: euro.ID},
+	} {
+		if _, err := insertTransaction(db, tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := buildStatsReport(db, periodFilter{Kind: periodAll})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CategoryTotal{
+		{Type: typeExpense, Category: "Еда", Total: 3000},
+		{Type: typeIncome, Category: "Зарплата", Total: 50000},
+	}
+	if report.Income != 50000 || report.Expense != 3000 || !reflect.DeepEqual(report.Categories, want) {
+		t.Errorf("доход %v, расход %v, категории %+v; ожидалось 50000, 3000 и %+v",
+			report.Income, report.Expense, report.Categories, want)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"image/color"
 
 	"fyne.io/fyne/v2"
@@ -19,7 +20,44 @@ func newCustomTheme(dark bool) *customTheme {
 	}
 }
 
+// Палитры серий графиков статистики
+var chartPaletteDark = []color.NRGBA{
+	{R: 66, G: 165, B: 245, A: 255},  // Голубой
+	{R: 239, G: 83, B: 80, A: 255},   // Красный
+	{R: 102, G: 187, B: 106, A: 255}, // Зеленый
+	{R: 255, G: 202, B: 40, A: 255},  // Желтый
+	{R: 171, G: 71, B: 188, A: 255},  // Фиолетовый
+	{R: 38, G: 198, B: 218, A: 255},  // Бирюзовый
+	{R: 255, G: 112, B: 67, A: 255},  // Оранжевый
+	{R: 141, G: 110, B: 99, A: 255},  // Коричневый
+}
+
+var chartPaletteLight = []color.NRGBA{
+	{R: 219, G: 112, B: 147, A: 255}, // Розовый
+	{R: 75, G: 0, B: 130, A: 255},    // Темно-фиолетовый
+	{R: 199, G: 21, B: 133, A: 255},  // Темно-розовый
+	{R: 147, G: 112, B: 219, A: 255}, // Сиреневый
+	{R: 255, G: 160, B: 122, A: 255}, // Лососевый
+	{R: 186, G: 85, B: 211, A: 255},  // Орхидея
+	{R: 240, G: 128, B: 128, A: 255}, // Коралловый
+	{R: 106, G: 90, B: 205, A: 255},  // Грифельно-синий
+}
+
+// chartColorName возвращает имя цвета i-й серии графика
+func chartColorName(i int) fyne.ThemeColorName {
+	return fyne.ThemeColorName(fmt.Sprintf("chart%d", i%len(chartPaletteDark)))
+}
+
 func (t *customTheme) Color(name fyne.ThemeColorName, variant fyne.ThemeVariant) color.Color {
+	for i := range chartPaletteDark {
+		if name == chartColorName(i) {
+			if t.isDark {
+				return chartPaletteDark[i]
+			}
+			return chartPaletteLight[i]
+		}
+	}
+
 	if t.isDark {
 		return theme.DarkTheme().Color(name, variant)
 	}
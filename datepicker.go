@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Сокращенные названия дней недели, начиная с воскресенья (как time.Weekday)
+var weekdayNames = []string{"Вс", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб"}
+
+// dateField — поле даты с кнопкой календаря. Дату можно ввести вручную
+// в формате языка интерфейса или выбрать день в календаре.
+type dateField struct {
+	entry   *widget.Entry
+	content *fyne.Container
+	window  fyne.Window
+	month   time.Time // первый день месяца, открытого в календаре
+	popup   *widget.PopUp
+}
+
+func newDateField(window fyne.Window, placeholder string) *dateField {
+	d := &dateField{entry: widget.NewEntry(), window: window}
+	d.entry.SetPlaceHolder(datePlaceholder(placeholder))
+	calendarButton := widget.NewButtonWithIcon("", theme.CalendarIcon(), d.showCalendar)
+	d.content = container.NewBorder(nil, nil, nil, calendarButton, d.entry)
+	return d
+}
+
+// Date возвращает введенную дату в формате базы (YYYY-MM-DD)
+func (d *dateField) Date() (string, error) {
+	return parseDate(d.entry.Text)
+}
+
+func (d *dateField) SetDate(iso string) {
+	d.entry.SetText(formatDate(iso))
+}
+
+func (d *dateField) showCalendar() {
+	selected := time.Now()
+	if date, err := d.Date(); err == nil {
+		selected, _ = time.Parse("2006-01-02", date)
+	}
+	d.month = time.Date(selected.Year(), selected.Month(), 1, 0, 0, 0, 0, time.Local)
+
+	body := container.NewVBox()
+	var fill func()
+	fill = func() {
+		title := widget.NewLabelWithStyle(T(monthNames[d.month.Month()-1])+" "+strconv.Itoa(d.month.Year()),
+			fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+		prev := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+			d.month = d.month.AddDate(0, -1, 0)
+			fill()
+		})
+		next := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+			d.month = d.month.AddDate(0, 1, 0)
+			fill()
+		})
+
+		first := currentLocale().FirstWeekday
+		grid := container.NewGridWithColumns(7)
+		for i := 0; i < 7; i++ {
+			grid.Add(widget.NewLabelWithStyle(T(weekdayNames[(int(first)+i)%7]), fyne.TextAlignCenter, fyne.TextStyle{}))
+		}
+		// Пустые клетки до первого числа месяца
+		for i := 0; i < (int(d.month.Weekday())-int(first)+7)%7; i++ {
+			grid.Add(widget.NewLabel(""))
+		}
+		for day := d.month; day.Month() == d.month.Month(); day = day.AddDate(0, 0, 1) {
+			date := day
+			button := widget.NewButton(strconv.Itoa(day.Day()), func() {
+				d.SetDate(date.Format("2006-01-02"))
+				d.popup.Hide()
+			})
+			if date.Format("2006-01-02") == selected.Format("2006-01-02") {
+				button.Importance = widget.HighImportance
+			}
+			grid.Add(button)
+		}
+
+		body.Objects = []fyne.CanvasObject{
+			container.NewBorder(nil, nil, prev, next, title),
+			grid,
+			widget.NewButton(T("Сегодня"), func() {
+				d.SetDate(time.Now().Format("2006-01-02"))
+				d.popup.Hide()
+			}),
+			widget.NewButton(T("Отмена"), func() { d.popup.Hide() }),
+		}
+		body.Refresh()
+	}
+	fill()
+
+	d.popup = widget.NewModalPopUp(body, d.window.Canvas())
+	d.popup.Show()
+}
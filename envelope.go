@@ -0,0 +1,471 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Правила переноса остатка конверта на следующий месяц (budget_limits.rollover)
+const (
+	rolloverNone     = "none"     // каждый месяц начинается с нуля
+	rolloverPositive = "positive" // переносится только неизрасходованный остаток
+	rolloverFull     = "full"     // переносится и остаток, и перерасход
+)
+
+var rolloverRules = []struct {
+	Code  string
+	Label string
+}{
+	{rolloverNone, "Без переноса"},
+	{rolloverPositive, "Переносить остаток"},
+	{rolloverFull, "Переносить остаток и перерасход"},
+}
+
+func rolloverLabels() []string {
+	labels := make([]string, 0, len(rolloverRules))
+	for _, r := range rolloverRules {
+		labels = append(labels, T(r.Label))
+	}
+	return labels
+}
+
+func rolloverLabel(code string) string {
+	for _, r := range rolloverRules {
+		if r.Code == code {
+			return T(r.Label)
+		}
+	}
+	return code
+}
+
+// isRolloverRule — известен ли код правила переноса
+func isRolloverRule(code string) bool {
+	for _, r := range rolloverRules {
+		if r.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func rolloverCode(label string) string {
+	for _, r := range rolloverRules {
+		if T(r.Label) == label {
+			return r.Code
+		}
+	}
+	return rolloverNone
+}
+
+// EnvelopeMonth — строка помесячной ведомости конверта
+type EnvelopeMonth struct {
+	Month      string
+	CarriedIn  float64
+	Assigned   float64
+	Spent      float64
+	Available  float64
+	CarriedOut float64
+}
+
+func nextMonth(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return t.AddDate(0, 1, 0).Format("2006-01")
+}
+
+func previousMonth(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return t.AddDate(0, -1, 0).Format("2006-01")
+}
+
+// carryOver применяет правило переноса к остатку конверта на конец месяца
+func carryOver(rule string, available float64) float64 {
+	switch rule {
+	case rolloverFull:
+		return available
+	case rolloverPositive:
+		if available > 0 {
+			return available
+		}
+	}
+	return 0
+}
+
+// envelopeLedger строит помесячную ведомость конверта категории от первого месяца
+// с назначениями или расходами до месяца through включительно
+func envelopeLedger(db dbExecutor, b Budget, through string) ([]EnvelopeMonth, error) {
+	assigned := make(map[string]float64)
+	rows, err := db.Query("SELECT month, amount FROM envelope_assignments WHERE category = ? AND month <= ?", b.Category, through)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var month string
+		var amount float64
+		if err := rows.Scan(&month, &amount); err == nil {
+			assigned[month] += amount
+		}
+	}
+	rows.Close()
+
+	// Конверты ведутся в основной валюте, расходы с валютных счетов в них не входят
+	spent := make(map[string]float64)
+	inBase, baseArgs := baseCurrencyCondition("account_id")
+	rows, err = db.Query(`
+		SELECT strftime('%Y-%m', date) AS month, SUM(amount)
+		FROM transaction_lines
+		WHERE type = ? AND category = ? AND strftime('%Y-%m', date) <= ? AND `+inBase+`
+		GROUP BY month
+	`, append([]interface{}{typeExpense, b.Category, through}, baseArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var month string
+		var amount float64
+		if err := rows.Scan(&month, &amount); err == nil {
+			spent[month] += amount
+		}
+	}
+	rows.Close()
+
+	first := through
+	for month := range assigned {
+		if month < first {
+			first = month
+		}
+	}
+	for month := range spent {
+		if month < first {
+			first = month
+		}
+	}
+
+	var ledger []EnvelopeMonth
+	var carried float64
+	for month := first; month <= through; month = nextMonth(month) {
+		m := EnvelopeMonth{
+			Month:     month,
+			CarriedIn: carried,
+			Assigned:  assigned[month],
+			Spent:     spent[month],
+		}
+		m.Available = m.CarriedIn + m.Assigned - m.Spent
+		m.CarriedOut = carryOver(b.Rollover, m.Available)
+		carried = m.CarriedOut
+		ledger = append(ledger, m)
+	}
+	return ledger, nil
+}
+
+// availableToBudget — сколько дохода можно распределить в месяце. Деньги,
+// назначенные в любом месяце, берутся из дохода по этот месяц включительно, поэтому
+// остаток считается на каждый месяц от month до последнего с доходами или
+// назначениями, и доступен наименьший: иначе, вернувшись на месяц назад, можно
+// было бы распределить уже распределенный позже доход второй раз.
+func availableToBudget(db dbExecutor, month string) (float64, error) {
+	// Распределяется только доход в основной валюте
+	changes := make(map[string]float64)
+	inBase, baseArgs := baseCurrencyCondition("account_id")
+	rows, err := db.Query(`
+		SELECT strftime('%Y-%m', date) AS month, SUM(amount) FROM transactions
+		WHERE type = ? AND `+inBase+` GROUP BY month
+	`, append([]interface{}{typeIncome}, baseArgs...)...)
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var m string
+		var amount float64
+		if err := rows.Scan(&m, &amount); err == nil {
+			changes[m] += amount
+		}
+	}
+	rows.Close()
+	rows, err = db.Query("SELECT month, SUM(amount) FROM envelope_assignments GROUP BY month")
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var m string
+		var amount float64
+		if err := rows.Scan(&m, &amount); err == nil {
+			changes[m] -= amount
+		}
+	}
+	rows.Close()
+
+	months := []string{month}
+	for m := range changes {
+		if m != month {
+			months = append(months, m)
+		}
+	}
+	sort.Strings(months)
+
+	var balance float64
+	available := math.Inf(1)
+	for _, m := range months {
+		balance += changes[m]
+		if m >= month {
+			available = math.Min(available, balance)
+		}
+	}
+	return available, nil
+}
+
+// assignToEnvelope добавляет сумму к назначению конверта за месяц; отрицательная
+// сумма возвращает деньги в нераспределенный доход, но не больше, чем есть в конверте
+func assignToEnvelope(db dbExecutor, category, month string, amount float64) error {
+	if category == "" {
+		return errors.New(T("не указана категория"))
+	}
+	if amount > 0 {
+		available, err := availableToBudget(db, month)
+		if err != nil {
+			return err
+		}
+		if toCents(amount) > toCents(available) {
+			return fmt.Errorf(T("недостаточно средств для распределения: доступно %s"), money(available))
+		}
+	}
+	if amount < 0 {
+		b, ok, err := findBudget(db, category)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			b = Budget{Category: category, Rollover: rolloverNone}
+		}
+		ledger, err := envelopeLedger(db, b, month)
+		if err != nil {
+			return err
+		}
+		var inEnvelope float64
+		if len(ledger) > 0 {
+			inEnvelope = ledger[len(ledger)-1].Available
+		}
+		if toCents(-amount) > toCents(inEnvelope) {
+			return fmt.Errorf(T("в конверте только %s"), money(inEnvelope))
+		}
+	}
+	_, err := db.Exec(`
+		INSERT INTO envelope_assignments (category, month, amount) VALUES (?, ?, ?)
+		ON CONFLICT(category, month) DO UPDATE SET amount = amount + excluded.amount
+	`, category, month, amount)
+	return err
+}
+
+// fillEnvelopes доводит назначение каждого конверта за месяц до лимита бюджета,
+// пока хватает нераспределенного дохода; последний конверт может остаться
+// заполненным частично. Все назначения сохраняются одной SQL-транзакцией.
+func fillEnvelopes(db *sql.DB, month string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	budgets, err := loadBudgets(tx)
+	if err != nil {
+		return err
+	}
+	for _, b := range budgets {
+		ledger, err := envelopeLedger(tx, b, month)
+		if err != nil {
+			return err
+		}
+		var assigned float64
+		if len(ledger) > 0 {
+			assigned = ledger[len(ledger)-1].Assigned
+		}
+		available, err := availableToBudget(tx, month)
+		if err != nil {
+			return err
+		}
+		need := math.Min(b.Limit-assigned, available)
+		if toCents(need) <= 0 {
+			continue
+		}
+		if err := assignToEnvelope(tx, b.Category, month, need); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func envelopeWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Конверты"))
+	restoreWindowSize(window, "envelopes", fyne.NewSize(1000, 800))
+
+	month := time.Now().Format("2006-01")
+	monthLabel := widget.NewLabelWithStyle(month, fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+	availableLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+
+	categorySelect := widget.NewSelect(nil, nil)
+	amountEntry := widget.NewEntry()
+	amountEntry.SetPlaceHolder(T("Сумма (отрицательная - вернуть)"))
+
+	monthContainer := container.NewVBox()
+	historyContainer := container.NewVBox()
+
+	ledgerRow := func(first string, m EnvelopeMonth) *fyne.Container {
+		return container.NewGridWithColumns(5,
+			widget.NewLabel(first),
+			widget.NewLabel(money(m.CarriedIn)),
+			widget.NewLabel(money(m.Assigned)),
+			widget.NewLabel(money(m.Spent)),
+			widget.NewLabel(money(m.Available)),
+		)
+	}
+	ledgerHeader := func(first string) *fyne.Container {
+		return container.NewGridWithColumns(5,
+			widget.NewLabelWithStyle(first, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Перенесено"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Назначено"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Потрачено"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Доступно"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		)
+	}
+
+	// История выбранной категории по месяцам
+	updateHistory := func() {
+		historyContainer.Objects = nil
+		budgets, err := loadBudgets(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		for _, b := range budgets {
+			if b.Category != categorySelect.Selected {
+				continue
+			}
+			ledger, err := envelopeLedger(db, b, month)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			historyContainer.Add(widget.NewLabelWithStyle(
+				Tf("История: %s (%s)", b.Category, rolloverLabel(b.Rollover)),
+				fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			historyContainer.Add(ledgerHeader(T("Месяц")))
+			for i := len(ledger) - 1; i >= 0; i-- {
+				historyContainer.Add(ledgerRow(ledger[i].Month, ledger[i]))
+			}
+		}
+		historyContainer.Refresh()
+	}
+
+	update := func() {
+		monthLabel.SetText(month)
+		monthContainer.Objects = nil
+
+		available, err := availableToBudget(db, month)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		availableLabel.SetText(Tf("Доступно для распределения: %s", money(available)))
+
+		budgets, err := loadBudgets(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		var categories []string
+		monthContainer.Add(ledgerHeader(T("Категория")))
+		for _, b := range budgets {
+			categories = append(categories, b.Category)
+			ledger, err := envelopeLedger(db, b, month)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if len(ledger) == 0 {
+				continue
+			}
+			monthContainer.Add(ledgerRow(b.Category, ledger[len(ledger)-1]))
+		}
+		if len(budgets) == 0 {
+			monthContainer.Add(widget.NewLabel(T("Конверты строятся по категориям бюджета - сначала задайте лимиты")))
+		}
+		monthContainer.Refresh()
+
+		categorySelect.Options = categories
+		categorySelect.Refresh()
+		updateHistory()
+	}
+	categorySelect.OnChanged = func(string) { updateHistory() }
+
+	prevButton := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		month = previousMonth(month)
+		update()
+	})
+	nextButton := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), func() {
+		month = nextMonth(month)
+		update()
+	})
+
+	assignButton := widget.NewButton(T("Назначить"), func() {
+		amount, err := parseAmount(amountEntry.Text)
+		if err != nil || amount == 0 {
+			dialog.ShowError(errors.New(T("неверная сумма")), window)
+			return
+		}
+		if err := assignToEnvelope(db, categorySelect.Selected, month, amount); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		amountEntry.SetText("")
+		update()
+	})
+
+	fillButton := widget.NewButton(T("Распределить по лимитам"), func() {
+		if err := fillEnvelopes(db, month); err != nil {
+			dialog.ShowError(err, window)
+		}
+		update()
+	})
+
+	update()
+
+	monthScroll := container.NewScroll(monthContainer)
+	monthScroll.SetMinSize(fyne.NewSize(900, 300))
+	historyScroll := container.NewScroll(historyContainer)
+	historyScroll.SetMinSize(fyne.NewSize(900, 250))
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(T("Конверты"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewHBox(prevButton, monthLabel, nextButton),
+		availableLabel,
+		container.NewHBox(
+			widget.NewLabel(T("Категория:")),
+			categorySelect,
+			container.NewGridWrap(fyne.NewSize(250, 36), amountEntry),
+			assignButton,
+			fillButton,
+		),
+		widget.NewSeparator(),
+		monthScroll,
+		widget.NewSeparator(),
+		historyScroll,
+	)
+
+	window.SetContent(content)
+	return window
+}
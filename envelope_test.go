@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+// Доход и расходы со счета в другой валюте в конверты не попадают
+func TestEnvelopesInBaseCurrency(t *testing.T) {
+	db := openTestDB(t)
+	useTestBaseCurrency(t, db)
+	if err := saveAccount(db, Account{Name: "Доллары", Currency: "usd"}); err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dollars, _ := accountByName(accounts, "Доллары")
+	for _, tr := range []Transaction{
+		{Date: "2026-09-01", Type: typeIncome, Category: "Зарплата", Amount: 50000},
+		{Date: "2026-09-02", Type: typeIncome, Category: "Зарплата", Amount: 1000, AccountID: dollars.ID},
+		{Date: "2026-09-05", Type: typeExpense, Category: "Еда", Amount: 3000},
+		{Date: "2026-09-06", Type: typeExpense, Category: "Еда", Amount: 40, AccountID: dollars.ID},
+	} {
+		if _, err := insertTransaction(db, tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Транзакция из старой базы без счета относится к основному
+	if _, err := db.Exec("UPDATE transactions SET account_id = 0 WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := assignToEnvelope(db, "Еда", "2026-09", 10000); err != nil {
+		t.Fatal(err)
+	}
+
+	available, err := availableToBudget(db, "2026-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available != 40000 {
+		t.Errorf("доступно %v, ожидалось 40000", available)
+	}
+	ledger, err := envelopeLedger(db, Budget{Category: "Еда", Rollover: rolloverFull}, "2026-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ledger) != 1 || ledger[0].Spent != 3000 || ledger[0].Available != 7000 {
+		t.Errorf("ведомость конверта %+v, ожидались расходы 3000 и остаток 7000", ledger)
+	}
+}
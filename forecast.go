@@ -0,0 +1,462 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Периодичность регулярных операций (recurring_items.frequency)
+const (
+	frequencyWeekly  = "weekly"
+	frequencyMonthly = "monthly"
+	frequencyYearly  = "yearly"
+)
+
+var frequencies = []struct {
+	Code  string
+	Label string
+}{
+	{frequencyWeekly, "Еженедельно"},
+	{frequencyMonthly, "Ежемесячно"},
+	{frequencyYearly, "Ежегодно"},
+}
+
+func frequencyLabels() []string {
+	labels := make([]string, 0, len(frequencies))
+	for _, f := range frequencies {
+		labels = append(labels, T(f.Label))
+	}
+	return labels
+}
+
+func frequencyLabel(code string) string {
+	for _, f := range frequencies {
+		if f.Code == code {
+			return T(f.Label)
+		}
+	}
+	return code
+}
+
+func frequencyCode(label string) string {
+	for _, f := range frequencies {
+		if T(f.Label) == label {
+			return f.Code
+		}
+	}
+	return frequencyMonthly
+}
+
+// forecastLookbackMonths — за сколько полных месяцев усредняются переменные расходы
+const forecastLookbackMonths = 3
+
+// RecurringItem — регулярная операция: зарплата, аренда, подписка
+type RecurringItem struct {
+	ID          int
+	Description string
+	Category    string
+	Type        string
+	Amount      float64
+	Frequency   string
+	NextDate    string
+}
+
+// ForecastDay — прогноз остатка на конец дня
+type ForecastDay struct {
+	Date    string
+	Balance float64
+	Events  []string
+}
+
+// Forecast — результат прогноза движения денег
+type Forecast struct {
+	StartBalance float64
+	Days         []ForecastDay
+	DailySpend   map[string]float64
+	// LowBalanceDate — первый день, когда остаток опускается ниже порога; пусто, если такого нет
+	LowBalanceDate string
+	LowestBalance  float64
+}
+
+// addMonthsClamped прибавляет месяцы, не перескакивая в следующий месяц для 29-31 числа
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, months, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// occurrences возвращает даты регулярной операции в интервале [from, to]
+func (r RecurringItem) occurrences(from, to time.Time) []time.Time {
+	start, err := time.ParseInLocation("2006-01-02", r.NextDate, time.Local)
+	if err != nil {
+		return nil
+	}
+	var dates []time.Time
+	for k := 0; ; k++ {
+		var date time.Time
+		switch r.Frequency {
+		case frequencyWeekly:
+			date = start.AddDate(0, 0, 7*k)
+		case frequencyYearly:
+			date = addMonthsClamped(start, 12*k)
+		default:
+			date = addMonthsClamped(start, k)
+		}
+		if date.After(to) {
+			return dates
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+}
+
+func loadRecurringItems(db *sql.DB) ([]RecurringItem, error) {
+	rows, err := db.Query("SELECT id, description, category, type, amount, frequency, next_date FROM recurring_items ORDER BY next_date")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []RecurringItem
+	for rows.Next() {
+		var r RecurringItem
+		if err := rows.Scan(&r.ID, &r.Description, &r.Category, &r.Type, &r.Amount, &r.Frequency, &r.NextDate); err != nil {
+			continue
+		}
+		items = append(items, r)
+	}
+	return items, rows.Err()
+}
+
+func saveRecurringItem(db *sql.DB, r RecurringItem) error {
+	if r.Type != typeIncome && r.Type != typeExpense {
+		return errors.New(T("выберите тип"))
+	}
+	if r.Amount <= 0 {
+		return errors.New(T("неверная сумма"))
+	}
+	if _, err := time.Parse("2006-01-02", r.NextDate); err != nil {
+		return errors.New(T("неверная дата: ожидается YYYY-MM-DD"))
+	}
+	_, err := db.Exec(`
+		INSERT INTO recurring_items (description, category, type, amount, frequency, next_date)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, r.Description, r.Category, r.Type, r.Amount, r.Frequency, r.NextDate)
+	return err
+}
+
+// currentBalance — суммарный остаток счетов в основной валюте на дату включительно.
+// Счета в других валютах пропускаются: перевести их остатки не по чему.
+func currentBalance(db *sql.DB, date string) (float64, error) {
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		return 0, err
+	}
+	var balance float64
+	for _, a := range accounts {
+		if !a.inBaseCurrency() {
+			continue
+		}
+		amount, err := accountBalance(db, a.ID, date)
+		if err != nil {
+			return 0, err
+		}
+		balance += amount
+	}
+	return balance, nil
+}
+
+// averageDailySpending усредняет расходы по категориям за последние полные месяцы.
+// Категории из excluded пропускаются: их уже учитывают регулярные операции.
+func averageDailySpending(db *sql.DB, today time.Time, excluded map[string]bool) (map[string]float64, error) {
+	end := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, 0, -1)
+	start := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, 1-forecastLookbackMonths, 0)
+	days := end.Sub(start).Hours()/24 + 1
+
+	rows, err := db.Query(`
+		SELECT category, SUM(amount)
+		FROM transaction_lines
+		WHERE type = ? AND date BETWEEN ? AND ?
+		GROUP BY category
+	`, typeExpense, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	daily := make(map[string]float64)
+	for rows.Next() {
+		var category string
+		var total float64
+		if err := rows.Scan(&category, &total); err != nil {
+			continue
+		}
+		if !excluded[category] {
+			daily[category] = total / days
+		}
+	}
+	return daily, rows.Err()
+}
+
+// buildForecast прогнозирует остаток на каждый день следующих months месяцев
+// от текущего остатка, регулярных операций и средних переменных расходов
+func buildForecast(db *sql.DB, today time.Time, months int, threshold float64) (Forecast, error) {
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	balance, err := currentBalance(db, today.Format("2006-01-02"))
+	if err != nil {
+		return Forecast{}, err
+	}
+	items, err := loadRecurringItems(db)
+	if err != nil {
+		return Forecast{}, err
+	}
+
+	// Платежи по кредитам идут по графику, а не по средним расходам
+	excluded := map[string]bool{categoryLoanInterest: true, categoryLoanPrincipal: true}
+	for _, r := range items {
+		if r.Type == typeExpense && r.Category != "" {
+			excluded[r.Category] = true
+		}
+	}
+	daily, err := averageDailySpending(db, today, excluded)
+	if err != nil {
+		return Forecast{}, err
+	}
+	var dailyTotal float64
+	for _, amount := range daily {
+		dailyTotal += amount
+	}
+
+	from := today.AddDate(0, 0, 1)
+	to := today.AddDate(0, months, 0)
+	events := make(map[string][]RecurringItem)
+	for _, r := range items {
+		for _, date := range r.occurrences(from, to) {
+			key := date.Format("2006-01-02")
+			events[key] = append(events[key], r)
+		}
+	}
+	loans, err := loadLoans(db)
+	if err != nil {
+		return Forecast{}, err
+	}
+	for _, loan := range loans {
+		payments, err := loadLoanPayments(db, loan.ID)
+		if err != nil {
+			return Forecast{}, err
+		}
+		for _, row := range amortizationSchedule(loan, payments) {
+			date, err := time.ParseInLocation("2006-01-02", row.Date, time.Local)
+			if row.Paid || err != nil || date.Before(from) || date.After(to) {
+				continue
+			}
+			events[row.Date] = append(events[row.Date], RecurringItem{
+				Description: loan.Name, Type: typeExpense, Amount: row.Payment,
+			})
+		}
+	}
+
+	f := Forecast{StartBalance: balance, DailySpend: daily, LowestBalance: balance}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := day.Format("2006-01-02")
+		forecastDay := ForecastDay{Date: key}
+		balance -= dailyTotal
+		for _, r := range events[key] {
+			if r.Type == typeIncome {
+				balance += r.Amount
+			} else {
+				balance -= r.Amount
+			}
+			forecastDay.Events = append(forecastDay.Events, fmt.Sprintf("%s %s: %s", typeLabel(r.Type), r.Description, money(r.Amount)))
+		}
+		forecastDay.Balance = balance
+		f.Days = append(f.Days, forecastDay)
+
+		if balance < f.LowestBalance {
+			f.LowestBalance = balance
+		}
+		if f.LowBalanceDate == "" && balance < threshold {
+			f.LowBalanceDate = key
+		}
+	}
+	return f, nil
+}
+
+func forecastWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Прогноз движения денег"))
+	restoreWindowSize(window, "forecast", fyne.NewSize(1000, 800))
+
+	monthsSelect := widget.NewSelect([]string{"1", "3", "6", "12"}, nil)
+	monthsSelect.SetSelected("3")
+	thresholdEntry := widget.NewEntry()
+	thresholdEntry.SetText("0")
+
+	forecastContainer := container.NewVBox()
+	recurringContainer := container.NewVBox()
+
+	updateForecast := func() {
+		forecastContainer.Objects = nil
+		months, _ := strconv.Atoi(monthsSelect.Selected)
+		threshold, err := parseAmount(thresholdEntry.Text)
+		if err != nil {
+			forecastContainer.Add(widget.NewLabel(T("Неверный порог остатка")))
+			forecastContainer.Refresh()
+			return
+		}
+
+		f, err := buildForecast(db, time.Now(), months, threshold)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+
+		forecastContainer.Add(widget.NewLabel(Tf("Текущий остаток: %s", money(f.StartBalance))))
+		if len(f.Days) > 0 {
+			forecastContainer.Add(widget.NewLabel(Tf("Остаток на %s: %s", formatDate(f.Days[len(f.Days)-1].Date), money(f.Days[len(f.Days)-1].Balance))))
+		}
+		forecastContainer.Add(widget.NewLabel(Tf("Минимальный остаток: %s", money(f.LowestBalance))))
+		if f.LowBalanceDate != "" {
+			warning := widget.NewLabelWithStyle(
+				Tf("Внимание: остаток опустится ниже %s %s", money(threshold), formatDate(f.LowBalanceDate)),
+				fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			forecastContainer.Add(container.NewHBox(widget.NewIcon(theme.WarningIcon()), warning))
+		}
+
+		points := make([]balancePoint, 0, len(f.Days))
+		for _, d := range f.Days {
+			points = append(points, balancePoint{Date: d.Date, Balance: d.Balance})
+		}
+		forecastContainer.Add(newBalanceChart(points))
+
+		// Средние расходы, заложенные в прогноз
+		forecastContainer.Add(widget.NewLabelWithStyle(
+			Tf("Средние расходы в день (за %d мес.)", forecastLookbackMonths),
+			fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		categories := make([]string, 0, len(f.DailySpend))
+		for category := range f.DailySpend {
+			categories = append(categories, category)
+		}
+		sort.Slice(categories, func(i, j int) bool { return f.DailySpend[categories[i]] > f.DailySpend[categories[j]] })
+		for _, category := range categories {
+			forecastContainer.Add(widget.NewLabel(fmt.Sprintf("%s: %s", category, money(f.DailySpend[category]))))
+		}
+
+		// Ближайшие регулярные операции
+		forecastContainer.Add(widget.NewLabelWithStyle(T("Запланированные операции"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		for _, d := range f.Days {
+			for _, event := range d.Events {
+				forecastContainer.Add(widget.NewLabel(Tf("%s | %s | остаток %s", formatDate(d.Date), event, money(d.Balance))))
+			}
+		}
+		forecastContainer.Refresh()
+	}
+
+	var updateRecurring func()
+	updateRecurring = func() {
+		recurringContainer.Objects = nil
+		items, err := loadRecurringItems(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		for _, r := range items {
+			r := r
+			deleteButton := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				if _, err := db.Exec("DELETE FROM recurring_items WHERE id = ?", r.ID); err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				updateRecurring()
+				updateForecast()
+			})
+			recurringContainer.Add(container.NewBorder(nil, nil, nil, deleteButton, widget.NewLabel(
+				Tf("%s | %s | %s | %s | %s, с %s",
+					typeLabel(r.Type), r.Description, r.Category, money(r.Amount), frequencyLabel(r.Frequency), formatDate(r.NextDate)))))
+		}
+		recurringContainer.Refresh()
+	}
+
+	typeSelect := widget.NewSelect([]string{typeLabel(typeIncome), typeLabel(typeExpense)}, nil)
+	descriptionEntry := widget.NewEntry()
+	descriptionEntry.SetPlaceHolder(T("Описание"))
+	categoryEntry := widget.NewEntry()
+	categoryEntry.SetPlaceHolder(T("Категория"))
+	amountEntry := widget.NewEntry()
+	amountEntry.SetPlaceHolder(T("Сумма"))
+	frequencySelect := widget.NewSelect(frequencyLabels(), nil)
+	frequencySelect.SetSelected(frequencyLabel(frequencyMonthly))
+	nextDateEntry := widget.NewEntry()
+	nextDateEntry.SetPlaceHolder(datePlaceholder("Ближайшая дата"))
+
+	addButton := widget.NewButtonWithIcon(T("Добавить"), theme.ContentAddIcon(), func() {
+		amount, err := parseAmount(amountEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		nextDate, err := parseDate(nextDateEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		err = saveRecurringItem(db, RecurringItem{
+			Description: descriptionEntry.Text,
+			Category:    categoryEntry.Text,
+			Type:        typeCode(typeSelect.Selected),
+			Amount:      amount,
+			Frequency:   frequencyCode(frequencySelect.Selected),
+			NextDate:    nextDate,
+		})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		descriptionEntry.SetText("")
+		amountEntry.SetText("")
+		updateRecurring()
+		updateForecast()
+	})
+
+	monthsSelect.OnChanged = func(string) { updateForecast() }
+	thresholdEntry.OnChanged = func(string) { updateForecast() }
+
+	updateRecurring()
+	updateForecast()
+
+	forecastScroll := container.NewScroll(forecastContainer)
+	forecastScroll.SetMinSize(fyne.NewSize(950, 450))
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(T("Прогноз движения денег"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewHBox(
+			widget.NewLabel(T("Месяцев вперед:")),
+			monthsSelect,
+			widget.NewLabel(T("Предупреждать, если остаток ниже:")),
+			container.NewGridWrap(fyne.NewSize(120, 36), thresholdEntry),
+		),
+		forecastScroll,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(T("Регулярные операции"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(3, typeSelect, descriptionEntry, categoryEntry),
+		container.NewGridWithColumns(4, amountEntry, frequencySelect, nextDateEntry, addButton),
+		recurringContainer,
+	)
+
+	window.SetContent(container.NewScroll(content))
+	return window
+}
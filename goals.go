@@ -0,0 +1,378 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SavingsGoal — цель накоплений; взносы переводятся на привязанный счет
+type SavingsGoal struct {
+	ID        int
+	Name      string
+	Target    float64
+	Deadline  string
+	AccountID int
+	Created   string
+}
+
+// GoalProgress — состояние цели на дату
+type GoalProgress struct {
+	SavingsGoal
+	Saved         float64
+	Percent       float64
+	MonthsLeft    int
+	MonthlyNeeded float64
+	Expected      float64 // сколько должно быть накоплено при равномерных взносах
+	Behind        bool
+}
+
+func loadGoals(db *sql.DB) ([]SavingsGoal, error) {
+	rows, err := db.Query("SELECT id, name, target, deadline, account_id, created FROM savings_goals ORDER BY deadline")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []SavingsGoal
+	for rows.Next() {
+		var g SavingsGoal
+		if err := rows.Scan(&g.ID, &g.Name, &g.Target, &g.Deadline, &g.AccountID, &g.Created); err != nil {
+			continue
+		}
+		goals = append(goals, g)
+	}
+	return goals, rows.Err()
+}
+
+func saveGoal(db *sql.DB, g SavingsGoal) error {
+	if g.Name == "" {
+		return errors.New(T("не указано название цели"))
+	}
+	if g.Target <= 0 {
+		return errors.New(T("неверная сумма цели"))
+	}
+	if _, err := time.Parse("2006-01-02", g.Deadline); err != nil {
+		return errors.New(T("неверный срок: ожидается YYYY-MM-DD"))
+	}
+	if g.AccountID == 0 {
+		return errors.New(T("выберите счет цели"))
+	}
+	_, err := db.Exec("INSERT INTO savings_goals (name, target, deadline, account_id, created) VALUES (?, ?, ?, ?, ?)",
+		g.Name, g.Target, g.Deadline, g.AccountID, time.Now().Format("2006-01-02"))
+	return err
+}
+
+// contributeToGoal записывает взнос переводом со счета fromAccountID на счет цели
+func contributeToGoal(db *sql.DB, g SavingsGoal, fromAccountID int, amount float64, date string) error {
+	if amount <= 0 {
+		return errors.New(T("неверная сумма"))
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return errors.New(T("неверная дата: ожидается YYYY-MM-DD"))
+	}
+	if fromAccountID == g.AccountID {
+		return errors.New(T("счет списания совпадает со счетом цели"))
+	}
+	// Перевод без записи о взносе цель бы не учла, поэтому они сохраняются вместе
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	t := Transaction{
+		Date:              date,
+		Category:          g.Name,
+		Amount:            amount,
+		Description:       T("Взнос в цель"),
+		Type:              typeTransfer,
+		AccountID:         fromAccountID,
+		TransferAccountID: g.AccountID,
+	}
+	id, err := insertTransactionTx(tx, t)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO goal_contributions (transaction_id, goal_id) VALUES (?, ?)", id, g.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	learnTransaction(db, t)
+	return nil
+}
+
+// goalSaved — сумма взносов в цель на дату включительно
+func goalSaved(db *sql.DB, goalID int, date string) (float64, error) {
+	var saved float64
+	err := db.QueryRow(`
+		SELECT COALESCE(SUM(t.amount), 0)
+		FROM goal_contributions c
+		JOIN transactions t ON t.id = c.transaction_id
+		WHERE c.goal_id = ? AND t.date <= ?
+	`, goalID, date).Scan(&saved)
+	return saved, err
+}
+
+// monthsUntil — сколько месячных взносов осталось до срока, включая текущий месяц
+func monthsUntil(today, deadline time.Time) int {
+	if deadline.Before(today) {
+		return 0
+	}
+	return (deadline.Year()-today.Year())*12 + int(deadline.Month()) - int(today.Month()) + 1
+}
+
+func computeGoalProgress(db *sql.DB, g SavingsGoal, today time.Time) (GoalProgress, error) {
+	p := GoalProgress{SavingsGoal: g}
+	saved, err := goalSaved(db, g.ID, today.Format("2006-01-02"))
+	if err != nil {
+		return p, err
+	}
+	p.Saved = saved
+	p.Percent = math.Min(saved/g.Target*100, 100)
+
+	deadline, err := time.ParseInLocation("2006-01-02", g.Deadline, time.Local)
+	if err != nil {
+		return p, err
+	}
+	created, err := time.ParseInLocation("2006-01-02", g.Created, time.Local)
+	if err != nil {
+		created = today
+	}
+
+	remaining := math.Max(g.Target-saved, 0)
+	p.MonthsLeft = monthsUntil(today, deadline)
+	p.MonthlyNeeded = remaining
+	if p.MonthsLeft > 0 {
+		p.MonthlyNeeded = remaining / float64(p.MonthsLeft)
+	}
+
+	// Равномерный план: от даты создания цели до срока
+	total := deadline.Sub(created).Hours() / 24
+	elapsed := today.Sub(created).Hours() / 24
+	p.Expected = g.Target
+	if total > 0 && elapsed < total {
+		p.Expected = g.Target * math.Max(elapsed, 0) / total
+	}
+	p.Behind = remaining > 0 && saved < p.Expected
+	return p, nil
+}
+
+func goalsProgress(db *sql.DB, today time.Time) ([]GoalProgress, error) {
+	goals, err := loadGoals(db)
+	if err != nil {
+		return nil, err
+	}
+	var result []GoalProgress
+	for _, g := range goals {
+		p, err := computeGoalProgress(db, g, today)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// checkGoalAlerts возвращает уведомления об отстающих целях; о каждой цели
+// напоминаем не чаще раза в месяц, отметки хранятся в goal_alerts
+func checkGoalAlerts(db *sql.DB, now time.Time) ([]*fyne.Notification, error) {
+	progress, err := goalsProgress(db, now)
+	if err != nil {
+		return nil, err
+	}
+	var notifications []*fyne.Notification
+	for _, p := range progress {
+		if !p.Behind {
+			continue
+		}
+		res, err := db.Exec("INSERT OR IGNORE INTO goal_alerts (goal_id, month, sent_at) VALUES (?, ?, ?)",
+			p.ID, now.Format("2006-01"), now.Format("2006-01-02 15:04:05"))
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		notifications = append(notifications, &fyne.Notification{
+			Title: T("Цель отстает от графика"),
+			Content: Tf("%s: накоплено %s из %s (по плану %s), нужно %s в месяц до %s",
+				p.Name, money(p.Saved), money(p.Target), money(p.Expected), money(p.MonthlyNeeded), formatDate(p.Deadline)),
+		})
+	}
+	return notifications, nil
+}
+
+func sendGoalAlerts(a fyne.App, db *sql.DB) {
+	notifications, err := checkGoalAlerts(db, time.Now())
+	if err != nil {
+		a.SendNotification(&fyne.Notification{
+			Title:   T("Ошибка"),
+			Content: T("Не удалось проверить цели: ") + err.Error(),
+		})
+		return
+	}
+	for _, n := range notifications {
+		a.SendNotification(n)
+	}
+}
+
+// fillGoalsProgress выводит в target прогресс целей для главного окна
+func fillGoalsProgress(db *sql.DB, target *fyne.Container) {
+	target.Objects = nil
+	progress, err := goalsProgress(db, time.Now())
+	if err != nil || len(progress) == 0 {
+		target.Refresh()
+		return
+	}
+	target.Add(widget.NewLabelWithStyle(T("Цели накоплений"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	for _, p := range progress {
+		bar := widget.NewProgressBar()
+		bar.Max = 100
+		bar.SetValue(p.Percent)
+		status := Tf("%s: %s из %s", p.Name, money(p.Saved), money(p.Target))
+		if p.Behind {
+			status += T(" — отстает")
+		}
+		target.Add(widget.NewLabel(status))
+		target.Add(bar)
+	}
+	target.Refresh()
+}
+
+// goalsWindow — окно целей; onChange вызывается после каждого взноса или новой цели
+func goalsWindow(a fyne.App, db *sql.DB, onChange func()) fyne.Window {
+	window := a.NewWindow(T("Цели накоплений"))
+	restoreWindowSize(window, "goals", fyne.NewSize(900, 700))
+
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+
+	goalsContainer := container.NewVBox()
+	var update func()
+	update = func() {
+		goalsContainer.Objects = nil
+		progress, err := goalsProgress(db, time.Now())
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if len(progress) == 0 {
+			goalsContainer.Add(widget.NewLabel(T("Целей пока нет")))
+		}
+		for _, p := range progress {
+			goal := p.SavingsGoal
+			bar := widget.NewProgressBar()
+			bar.Max = 100
+			bar.SetValue(p.Percent)
+
+			status := Tf("Накоплено %s из %s, срок %s, нужно %s в месяц",
+				money(p.Saved), money(p.Target), formatDate(p.Deadline), money(p.MonthlyNeeded))
+			if p.Behind {
+				status += Tf(" — отстает от плана (%s)", money(p.Expected))
+			}
+
+			amountEntry := widget.NewEntry()
+			amountEntry.SetPlaceHolder(T("Сумма взноса"))
+			dateEntry := widget.NewEntry()
+			dateEntry.SetText(formatDate(time.Now().Format("2006-01-02")))
+			fromSelect := widget.NewSelect(accountNames(accounts), nil)
+			for _, acc := range accounts {
+				if acc.ID != goal.AccountID {
+					fromSelect.SetSelected(acc.Name)
+					break
+				}
+			}
+			contributeButton := widget.NewButton(T("Внести"), func() {
+				amount, err := parseAmount(amountEntry.Text)
+				if err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				date, err := parseDate(dateEntry.Text)
+				if err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				from, ok := accountByName(accounts, fromSelect.Selected)
+				if !ok {
+					dialog.ShowError(errors.New(T("выберите счет списания")), window)
+					return
+				}
+				if err := contributeToGoal(db, goal, from.ID, amount, date); err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				update()
+				if onChange != nil {
+					onChange()
+				}
+			})
+
+			goalsContainer.Add(widget.NewLabelWithStyle(goal.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			goalsContainer.Add(bar)
+			goalsContainer.Add(widget.NewLabel(status))
+			goalsContainer.Add(container.NewGridWithColumns(4, amountEntry, dateEntry, fromSelect, contributeButton))
+			goalsContainer.Add(widget.NewSeparator())
+		}
+		goalsContainer.Refresh()
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(T("Название"))
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder(T("Сумма цели"))
+	deadlineEntry := widget.NewEntry()
+	deadlineEntry.SetPlaceHolder(datePlaceholder("Срок"))
+	accountSelect := widget.NewSelect(accountNames(accounts), nil)
+	accountSelect.PlaceHolder = T("Счет цели")
+
+	addButton := widget.NewButtonWithIcon(T("Добавить цель"), theme.ContentAddIcon(), func() {
+		target, err := parseAmount(targetEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверная сумма цели")), window)
+			return
+		}
+		deadline, err := parseDate(deadlineEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		account, _ := accountByName(accounts, accountSelect.Selected)
+		err = saveGoal(db, SavingsGoal{
+			Name: nameEntry.Text, Target: target, Deadline: deadline, AccountID: account.ID,
+		})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		nameEntry.SetText("")
+		targetEntry.SetText("")
+		deadlineEntry.SetText("")
+		update()
+		if onChange != nil {
+			onChange()
+		}
+	})
+
+	update()
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(T("Цели накоплений"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		goalsContainer,
+		widget.NewLabelWithStyle(T("Новая цель"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(5, nameEntry, targetEntry, deadlineEntry, accountSelect, addButton),
+	)
+	window.SetContent(container.NewScroll(content))
+	return window
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Языки интерфейса. Исходные строки в коде написаны по-русски и служат ключами
+// каталогов, поэтому русский каталог пустой: T возвращает строку как есть.
+const (
+	langRussian = "ru"
+	langEnglish = "en"
+)
+
+const prefLanguage = "language"
+
+var languages = []struct {
+	Code  string
+	Label string
+}{
+	{langRussian, "Русский"},
+	{langEnglish, "English"},
+}
+
+var catalogs = map[string]map[string]string{
+	langRussian: {},
+	langEnglish: catalogEnglish,
+}
+
+var currentLanguage = languageFromEnv()
+
+// languageFromEnv выбирает язык по LC_ALL, LC_MESSAGES или LANG;
+// для всех языков, кроме русского, используется английский
+func languageFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		if strings.HasPrefix(value, "ru") || value == "C" || value == "POSIX" {
+			return langRussian
+		}
+		return langEnglish
+	}
+	return langRussian
+}
+
+func setLanguage(code string) {
+	if _, ok := catalogs[code]; ok {
+		currentLanguage = code
+	}
+}
+
+// T переводит исходную строку на текущий язык; без перевода строка возвращается как есть
+func T(source string) string {
+	if translated, ok := catalogs[currentLanguage][source]; ok {
+		return translated
+	}
+	return source
+}
+
+// Tf переводит строку формата и подставляет в нее аргументы
+func Tf(format string, args ...interface{}) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// translateAll переводит список исходных строк, например варианты выбора
+func translateAll(sources []string) []string {
+	labels := make([]string, 0, len(sources))
+	for _, s := range sources {
+		labels = append(labels, T(s))
+	}
+	return labels
+}
+
+// untranslate возвращает исходную строку для подписи из sources. Подпись может
+// быть на любом языке: так читаются файлы, сохраненные до смены языка.
+func untranslate(label string, sources []string) string {
+	for _, s := range sources {
+		if s == label {
+			return s
+		}
+		for _, catalog := range catalogs {
+			if translated, ok := catalog[s]; ok && translated == label {
+				return s
+			}
+		}
+	}
+	return label
+}
+
+func languageLabels() []string {
+	labels := make([]string, 0, len(languages))
+	for _, l := range languages {
+		labels = append(labels, l.Label)
+	}
+	return labels
+}
+
+func languageLabel(code string) string {
+	for _, l := range languages {
+		if l.Code == code {
+			return l.Label
+		}
+	}
+	return code
+}
+
+func languageCode(label string) string {
+	for _, l := range languages {
+		if l.Label == label {
+			return l.Code
+		}
+	}
+	return langRussian
+}
@@ -0,0 +1,586 @@
+package main
+
+// catalogEnglish — английский перевод исходных строк интерфейса
+var catalogEnglish = map[string]string{
+	// Общие подписи
+	"Ошибка":      "Error",
+	"Успех":       "Success",
+	"Информация":  "Information",
+	"Сохранить":   "Save",
+	"Добавить":    "Add",
+	"Создать":     "Create",
+	"Отмена":      "Cancel",
+	"Обновить":    "Refresh",
+	"Название":    "Name",
+	"Имя":         "Name",
+	"Дата":        "Date",
+	"Тип":         "Type",
+	"Категория":   "Category",
+	"Категория:":  "Category:",
+	"Сумма":       "Amount",
+	"Описание":    "Description",
+	"Заметка":     "Note",
+	"Счет":        "Account",
+	"Счета":       "Accounts",
+	"Период":      "Period",
+	"Период:":     "Period:",
+	"Период: %s":  "Period: %s",
+	"Месяц":       "Month",
+	"Месяц:":      "Month:",
+	"Год":         "Year",
+	"Год:":        "Year:",
+	"Год: %s":     "Year: %s",
+	"Неделя":      "Week",
+	"Свой период": "Custom period",
+	"Начало":      "Start",
+	"Конец":       "End",
+	"Лимит":       "Limit",
+	"Потрачено":   "Spent",
+	"Остаток":     "Remaining",
+	"Осталось":    "Left",
+	"Доступно":    "Available",
+	"Статус":      "Status",
+	"Формат:":     "Format:",
+	"Режим:":      "Mode:",
+	"Язык":        "Language",
+
+	// Типы транзакций
+	"Доход":   "Income",
+	"Расход":  "Expense",
+	"Перевод": "Transfer",
+
+	// Главное окно
+	"Учет доходов и расходов": "Income and expense tracker",
+	"Темная тема":             "Dark theme",
+	"Настройки":               "Settings",
+	"Добавить транзакцию":     "Add transaction",
+	"Просмотреть транзакции":  "View transactions",
+	"Полноэкранный режим":     "Full screen",
+	"Выход":                   "Exit",
+	"Статистика":              "Statistics",
+	"Бюджет":                  "Budget",
+	"Прогноз":                 "Forecast",
+	"Капитал":                 "Net worth",
+	"Кредиты":                 "Loans",
+	"Инвестиции":              "Investments",
+	"Цели":                    "Goals",
+	"Экспорт данных":          "Export data",
+	"Не удалось загрузить изображение: ": "Failed to load image: ",
+	"Не удалось загрузить изображение":   "Failed to load image",
+
+	// База данных
+	"не удалось создать таблицу: %w":            "failed to create table: %w",
+	"не удалось обновить таблицу: %w":           "failed to update table: %w",
+	"не удалось создать представление: %w":      "failed to create view: %w",
+	"не удалось подключиться к базе данных: %w": "failed to connect to database: %w",
+
+	// Транзакции
+	"Неверная сумма":                         "Invalid amount",
+	"неверная сумма":                         "invalid amount",
+	"неверная сумма %q":                      "invalid amount %q",
+	"Не удалось сохранить транзакцию":        "Failed to save transaction",
+	"Транзакция сохранена":                   "Transaction saved",
+	"Просмотр транзакций":                    "Transactions",
+	"Не удалось загрузить транзакции":        "Failed to load transactions",
+	"неизвестный тип %q":                     "unknown type %q",
+	"Выберите тип":                           "Choose a type",
+	"выберите счет":                          "choose an account",
+	"введите сумму больше нуля":              "enter an amount greater than zero",
+	"выберите тип":                           "choose a type",
+	"не указана категория":                   "category is not set",
+	"неверная дата: ожидается YYYY-MM-DD":    "invalid date: expected YYYY-MM-DD",
+	"неверная дата %q: ожидается YYYY-MM-DD": "invalid date %q: expected YYYY-MM-DD",
+	"неверная дата %q: ожидается %s":         "invalid date %q: expected %s",
+	"транзакция %d: %w":                      "transaction %d: %w",
+	"транзакция %d не найдена":               "transaction %d not found",
+	"неверный ID транзакции":                 "invalid transaction ID",
+
+	// Разделение транзакций
+	"транзакцию нужно разделить минимум на две части":         "a transaction must be split into at least two parts",
+	"не указана категория в части %d":                         "category is not set in part %d",
+	"неверная сумма в части %d":                               "invalid amount in part %d",
+	"сумма частей (%s) не совпадает с суммой транзакции (%s)": "parts total (%s) does not match the transaction amount (%s)",
+	"Разделить по категориям":                                 "Split by category",
+	"Не распределено: %s":                                     "Unallocated: %s",
+
+	// Быстрый ввод
+	"Быстрый ввод: кофе 250 вчера": "Quick add: coffee 250 yesterday",
+	"не найдена сумма":             "no amount found",
+
+	// Получатели
+	"Получатели":                     "Payees",
+	"Получатель":                     "Payee",
+	"Новый получатель":               "New payee",
+	"Категория по умолчанию":         "Default category",
+	"Описания без получателя":        "Descriptions without a payee",
+	"Удалить получателя «%s»?":       "Delete payee \"%s\"?",
+	"Обновлено транзакций: %d":       "Transactions updated: %d",
+	"не указано название получателя": "payee name is missing",
+	"По получателям":                 "By payee",
+	"Расходы по получателям":         "Spending by payee",
+	"Без получателя":                 "No payee",
+	"Операций":                       "Transactions",
+	"Средний чек":                    "Average",
+	"Доля":                           "Share",
+	"Шаблоны, по одному на строку; /выражение/ - регулярное выражение": "Patterns, one per line; /expression/ is a regular expression",
+
+	// Импорт и подсказки категорий
+	"Импорт данных":                "Import data",
+	"Выбрать файл":                 "Choose file",
+	"Файл не выбран":               "No file selected",
+	"Импортировать":                "Import",
+	"Импортировано транзакций: %d": "Transactions imported: %d",
+	"Подсказка":                    "Suggestion",
+	"по правилу":                   "by rule",
+	"Похоже на:":                   "Looks like:",
+
+	// Сверка с банком и изменение транзакций
+	"Сверка с банком":                  "Bank reconciliation",
+	"Дата выписки":                     "Statement date",
+	"Остаток по выписке":               "Statement balance",
+	"Остаток по проведенным: %s":       "Cleared balance: %s",
+	"Введите остаток по выписке":       "Enter the statement balance",
+	"Разница: %s":                      "Difference: %s",
+	"Последняя сверка: %s, остаток %s": "Last reconciled: %s, balance %s",
+	"Счет еще не сверялся":             "The account has not been reconciled yet",
+	"Несверенных транзакций нет":       "No unreconciled transactions",
+	"Отметить все":                     "Mark all",
+	"Завершить сверку":                 "Finish reconciliation",
+	"Сверка завершена, проведенные транзакции заблокированы":   "Reconciliation finished, cleared transactions are locked",
+	"остаток по проведенным (%s) не совпадает с выпиской (%s)": "cleared balance (%s) does not match the statement (%s)",
+	"сверка завершается в окне сверки":                         "reconciliation is finished in the reconciliation window",
+	"Не проведена":             "Uncleared",
+	"Проведена":                "Cleared",
+	"Сверена":                  "Reconciled",
+	"Отметить проведенной":     "Mark cleared",
+	"Снять отметку проведения": "Mark uncleared",
+	"Снять сверку":             "Unlock",
+	"Транзакция уже сверена с выпиской. Снять отметку и разрешить изменения?": "This transaction is reconciled with a statement. Unlock it and allow changes?",
+	"транзакция сверена с банком; чтобы изменить её, снимите отметку сверки":  "the transaction is reconciled with the bank; unlock it to make changes",
+	"транзакция создана как %s; изменять и удалять её отдельно нельзя":        "the transaction was created as a %s and cannot be changed or deleted on its own",
+	"сделка с ценной бумагой": "security trade",
+	"взнос в цель":            "goal contribution",
+	"платеж по кредиту":       "loan payment",
+	"Изменить":                "Edit",
+	"Изменить транзакцию":     "Edit transaction",
+	"Удалить транзакцию?":     "Delete the transaction?",
+	"Закрыть":                 "Close",
+	"переводы не изменяются в этой форме: удалите перевод и создайте заново": "transfers cannot be edited in this form: delete the transfer and create it again",
+
+	// Журнал изменений
+	"Журнал изменений":      "Activity log",
+	"История":               "History",
+	"История транзакции %d": "Transaction %d history",
+	"Изменений не записано": "No changes recorded",
+	"Показать:":             "Show:",
+	"Все":                   "All",
+	"Транзакция":            "Transaction",
+	"Часть транзакции":      "Transaction split",
+	"Добавление":            "Insertion",
+	"Счет зачисления":       "Destination account",
+	"Теги":                  "Tags",
+	"Перенос остатка":       "Rollover",
+	"не удалось создать журнал изменений: %w": "failed to create the activity log: %w",
+
+	// Отмена и повтор
+	"Отменить":                          "Undo",
+	"Повторить":                         "Redo",
+	"нечего отменять":                   "nothing to undo",
+	"нечего повторять":                  "nothing to redo",
+	"добавление транзакции %s":          "add transaction %s",
+	"изменение транзакции %s":           "edit transaction %s",
+	"удаление транзакции %s":            "delete transaction %s",
+	"изменение бюджета «%s»":            "change budget \"%s\"",
+	"объединение категорий «%s» и «%s»": "merge categories \"%s\" and \"%s\"",
+	"импорт транзакций: %d":             "import of %d transactions",
+	"не указан номер транзакции":        "transaction number is not specified",
+	"Объединить категории":              "Merge categories",
+	"Объединение категорий":             "Merge categories",
+	"Объединить":                        "Merge",
+	"В категорию":                       "Into category",
+	"категории совпадают":               "the categories are the same",
+
+	// Правила
+	"Правила":                           "Rules",
+	"Правило":                           "Rule",
+	"Условия":                           "Conditions",
+	"Действия":                          "Actions",
+	"Приоритет":                         "Priority",
+	"Включено":                          "Enabled",
+	" (выключено)":                      " (disabled)",
+	"Описание содержит":                 "Description contains",
+	"Регулярное выражение":              "Regular expression",
+	"Сумма от":                          "Amount from",
+	"Сумма до":                          "Amount to",
+	"Любой счет":                        "Any account",
+	"Любой тип":                         "Any type",
+	"Теги через запятую":                "Comma-separated tags",
+	"теги через запятую":                "comma-separated tags",
+	"Новое описание":                    "New description",
+	"Новое правило":                     "New rule",
+	"Удалить":                           "Delete",
+	"Удаление":                          "Deletion",
+	"Удалить правило «%s»?":             "Delete rule \"%s\"?",
+	"Проверить на истории":              "Test against history",
+	"Подходит транзакций: %d":           "Matching transactions: %d",
+	"не указано название правила":       "rule name is missing",
+	"неверный приоритет":                "invalid priority",
+	"неверный диапазон суммы":           "invalid amount range",
+	"задайте хотя бы одно условие":      "add at least one condition",
+	"задайте хотя бы одно действие":     "add at least one action",
+	"неверное регулярное выражение: %v": "invalid regular expression: %v",
+
+	// Периоды
+	"Все время":            "All time",
+	"По годам":             "By year",
+	"По месяцам":           "By month",
+	"Выбрать период":       "Custom range",
+	"Год не выбран":        "Year not selected",
+	"Период не выбран":     "Period not selected",
+	"С %s по %s":           "From %s to %s",
+	"выберите год":         "choose a year",
+	"выберите год и месяц": "choose a year and month",
+	"введите начальную и конечную даты": "enter the start and end dates",
+	"Сегодня":         "Today",
+	"Пн":              "Mo",
+	"Вт":              "Tu",
+	"Ср":              "We",
+	"Чт":              "Th",
+	"Пт":              "Fr",
+	"Сб":              "Sa",
+	"Вс":              "Su",
+	"Январь":          "January",
+	"Февраль":         "February",
+	"Март":            "March",
+	"Апрель":          "April",
+	"Май":             "May",
+	"Июнь":            "June",
+	"Июль":            "July",
+	"Август":          "August",
+	"Сентябрь":        "September",
+	"Октябрь":         "October",
+	"Ноябрь":          "November",
+	"Декабрь":         "December",
+	"Начальная дата":  "Start date",
+	"Конечная дата":   "End date",
+	"Начальная дата:": "Start date:",
+	"Конечная дата:":  "End date:",
+	"конечная дата раньше начальной": "end date is before start date",
+
+	// Статистика и сравнение
+	"Общий доход: %s":             "Total income: %s",
+	"Общий расход: %s":            "Total expense: %s",
+	"Баланс: %s":                  "Balance: %s",
+	"Расходы по категориям":       "Expenses by category",
+	"Доходы и расходы по месяцам": "Income and expenses by month",
+	"Накопленный баланс":          "Cumulative balance",
+	"Нет данных для отображения":  "No data to display",
+	"Нет данных для диаграммы":    "No data for the chart",
+	"Нет данных для графика":      "No data for the graph",
+	"Доходы (макс. %s)":           "Income (max %s)",
+	"Расходы (макс. %s)":          "Expenses (max %s)",
+	"Баланс (мин. %s, макс. %s)":  "Balance (min %s, max %s)",
+	"Обзор":                   "Overview",
+	"Сравнение":               "Comparison",
+	"Предыдущий период":       "Previous period",
+	"Тот же период год назад": "Same period a year ago",
+	"Другой период":           "Another period",
+	"Сравнить с:":             "Compare with:",
+	"Сравнение периодов":      "Period comparison",
+	"Было":                    "Before",
+	"Стало":                   "After",
+	"Изменение":               "Change",
+	"12 месяцев":              "12 months",
+	"для сравнения выберите год, месяц или период": "choose a year, month or range to compare",
+
+	// Бюджет и уведомления
+	"Управление бюджетом":                          "Budget management",
+	"Лимит бюджета":                                "Budget limit",
+	"Бюджеты не заданы":                            "No budgets set",
+	"Выполнение":                                   "Progress",
+	"Сохранить лимит":                              "Save limit",
+	"Сохранить пороги":                             "Save thresholds",
+	"Перенос:":                                     "Rollover:",
+	"Пороги уведомлений, %:":                       "Alert thresholds, %:",
+	"неизвестный период бюджета %q":                "unknown budget period %q",
+	"неизвестное правило переноса %q":              "unknown rollover rule %q",
+	"неверная начальная дата бюджета %q":           "invalid budget start date %q",
+	"неверная конечная дата бюджета %q":            "invalid budget end date %q",
+	"неверный порог %q":                            "invalid threshold %q",
+	"не указаны пороги уведомлений":                "no alert thresholds given",
+	"Бюджет почти исчерпан":                        "Budget almost used up",
+	"Превышен бюджет":                              "Budget exceeded",
+	"%s: израсходовано %.0f%% (%s из %s, %s – %s)": "%s: %.0f%% spent (%s of %s, %s – %s)",
+	"Не удалось проверить бюджеты: ":               "Failed to check budgets: ",
+
+	// Конверты
+	"Конверты":                        "Envelopes",
+	"Без переноса":                    "No rollover",
+	"Переносить остаток":              "Roll over remainder",
+	"Переносить остаток и перерасход": "Roll over remainder and overspending",
+	"Сумма (отрицательная - вернуть)": "Amount (negative to return)",
+	"Перенесено":                      "Carried over",
+	"Назначено":                       "Assigned",
+	"История: %s (%s)":                "History: %s (%s)",
+	"Доступно для распределения: %s":  "Available to assign: %s",
+	"Конверты строятся по категориям бюджета - сначала задайте лимиты": "Envelopes follow budget categories - set limits first",
+	"Назначить":               "Assign",
+	"Распределить по лимитам": "Assign by limits",
+	"недостаточно средств для распределения: доступно %s": "not enough funds to assign: %s available",
+	"в конверте только %s": "the envelope only holds %s",
+
+	// Прогноз
+	"Прогноз движения денег":                 "Cash flow forecast",
+	"Неверный порог остатка":                 "Invalid balance threshold",
+	"Текущий остаток: %s":                    "Current balance: %s",
+	"Остаток на %s: %s":                      "Balance on %s: %s",
+	"Минимальный остаток: %s":                "Minimum balance: %s",
+	"Внимание: остаток опустится ниже %s %s": "Warning: balance will drop below %s on %s",
+	"Средние расходы в день (за %d мес.)":    "Average daily spending (%d mo.)",
+	"Запланированные операции":               "Scheduled operations",
+	"%s | %s | остаток %s":                   "%s | %s | balance %s",
+	"%s | %s | %s | %s | %s, с %s":           "%s | %s | %s | %s | %s, from %s",
+	"Ближайшая дата":                         "Next date",
+	"Месяцев вперед:":                        "Months ahead:",
+	"Предупреждать, если остаток ниже:":      "Warn if balance is below:",
+	"Регулярные операции":                    "Recurring operations",
+	"Еженедельно":                            "Weekly",
+	"Ежемесячно":                             "Monthly",
+	"Ежегодно":                               "Yearly",
+
+	// Цели
+	"не указано название цели":                                     "goal name is not set",
+	"неверная сумма цели":                                          "invalid goal amount",
+	"неверный срок: ожидается YYYY-MM-DD":                          "invalid deadline: expected YYYY-MM-DD",
+	"выберите счет цели":                                           "choose the goal account",
+	"выберите счет списания":                                       "choose the source account",
+	"счет списания совпадает со счетом цели":                       "source account is the goal account",
+	"Взнос в цель":                                                 "Goal contribution",
+	"Цель отстает от графика":                                      "Goal is behind schedule",
+	"%s: накоплено %s из %s (по плану %s), нужно %s в месяц до %s": "%s: saved %s of %s (planned %s), %s per month needed until %s",
+	"Не удалось проверить цели: ":                                  "Failed to check goals: ",
+	"Цели накоплений":                                              "Savings goals",
+	"%s: %s из %s":                                                 "%s: %s of %s",
+	" — отстает":                                                   " — behind",
+	"Целей пока нет":                                               "No goals yet",
+	"Накоплено %s из %s, срок %s, нужно %s в месяц":                "Saved %s of %s, deadline %s, %s per month needed",
+	" — отстает от плана (%s)":                                     " — behind plan (%s)",
+	"Сумма взноса":                                                 "Contribution amount",
+	"Внести":                                                       "Contribute",
+	"Сумма цели":                                                   "Target amount",
+	"Срок":                                                         "Deadline",
+	"Счет цели":                                                    "Goal account",
+	"Добавить цель":                                                "Add goal",
+	"Новая цель":                                                   "New goal",
+
+	// Импорт и экспорт
+	"неизвестный формат %q":         "unknown format %q",
+	"неверный JSON: %w":             "invalid JSON: %w",
+	"тело запроса больше %d байт":   "request body is larger than %d bytes",
+	"пустой файл":                   "empty file",
+	"в заголовке нет столбца %q":    "header has no %q column",
+	"строка %d: %w":                 "line %d: %w",
+	"строка %d: неверная сумма":     "line %d: invalid amount",
+	"строка %d: неверная дата %q":   "line %d: invalid date %q",
+	"строка %d: неверная цена %q":   "line %d: invalid price %q",
+	"Экспортировать":                "Export",
+	"Нет данных для экспорта":       "No data to export",
+	"Данные успешно экспортированы": "Data exported successfully",
+
+	// Инвестиции
+	"Покупка":   "Buy",
+	"Продажа":   "Sell",
+	"Дивиденды": "Dividends",
+	"Портфель":  "Portfolio",
+	"продажа %s превышает количество бумаг в портфеле": "selling %s exceeds the quantity held",
+	"не указан тикер":                 "ticker is not set",
+	"неверная цена":                   "invalid price",
+	"неверная комиссия":               "invalid fee",
+	"комиссия больше суммы сделки":    "fee exceeds the trade amount",
+	"комиссия больше суммы дивиденда": "fee exceeds the dividend amount",
+	"неверное количество":             "invalid quantity",
+	"неизвестный вид сделки":          "unknown trade kind",
+	"Бумага":    "Security",
+	"Тикер":     "Ticker",
+	"Кол-во":    "Qty",
+	"Цена":      "Price",
+	"Дата цены": "Price date",
+	"Стоимость": "Value",
+	"Вложено":   "Invested",
+	"Нереализ.": "Unrealized",
+	"Реализ.":   "Realized",
+	"Стоимость: %s   Вложено: %s   Нереализованный результат: %s   Реализованный: %s   Дивиденды: %s": "Value: %s   Invested: %s   Unrealized: %s   Realized: %s   Dividends: %s",
+	"выберите бумагу":               "choose a security",
+	"Количество":                    "Quantity",
+	"Цена (для дивидендов - сумма)": "Price (total for dividends)",
+	"Комиссия":                      "Fee",
+	"Записать сделку":               "Record trade",
+	"Сохранить цену":                "Save price",
+	"Импорт цен из CSV":             "Import prices from CSV",
+	"Загружено цен: %d":             "Prices loaded: %d",
+	"Добавить бумагу":               "Add security",
+	"Сделка":                        "Trade",
+	"Цены":                          "Prices",
+	"Новая бумага":                  "New security",
+
+	// Кредиты
+	"Аннуитетный":                 "Annuity",
+	"Дифференцированный":          "Differentiated",
+	"Сократить срок":              "Shorten term",
+	"Уменьшить платеж":            "Reduce payment",
+	"не указано название кредита": "loan name is not set",
+	"неверная сумма кредита":      "invalid loan amount",
+	"неверная ставка":             "invalid rate",
+	"неверный срок":               "invalid term",
+	"неверная дата первого платежа: ожидается YYYY-MM-DD": "invalid first payment date: expected YYYY-MM-DD",
+	"Дата платежа":                                 "Payment date",
+	"Сумма досрочного погашения":                   "Early repayment amount",
+	"Остаток долга: %s   Переплата по графику: %s": "Outstanding: %s   Scheduled interest: %s",
+	"   Следующий платеж: %s, %s":                  "   Next payment: %s, %s",
+	"   Кредит погашен":                            "   Loan repaid",
+	"Платеж":                                       "Payment",
+	"Проценты":                                     "Interest",
+	"Основной долг":                                "Principal",
+	"План":                                         "Planned",
+	"Оплачен":                                      "Paid",
+	"Досрочно":                                     "Early",
+	"Внести очередной платеж":                      "Make next payment",
+	"выберите кредит":                              "choose a loan",
+	"Кредит уже погашен":                           "Loan is already repaid",
+	"Досрочное погашение":                          "Early repayment",
+	"сумма больше остатка долга (%s)":              "amount exceeds the outstanding balance (%s)",
+	"Ипотека":                                      "Mortgage",
+	"Кредит":                                       "Loan",
+	"Кредит:":                                      "Loan:",
+	"Сумма кредита":                                "Loan amount",
+	"Ставка, % годовых":                            "Rate, % per year",
+	"Срок, месяцев":                                "Term, months",
+	"Первый платеж":                                "First payment",
+	"Добавить кредит":                              "Add loan",
+	"Новый кредит":                                 "New loan",
+
+	// Капитал
+	"не указано название счета":                                   "account name is not set",
+	"не указано название":                                         "name is not set",
+	"неизвестный класс %q":                                        "unknown class %q",
+	"стоимость не может быть отрицательной":                       "value cannot be negative",
+	"Чистый капитал: %s":                                          "Net worth: %s",
+	"Счета: %s   Инвестиции: %s   Активы: %s   Обязательства: %s": "Accounts: %s   Investments: %s   Assets: %s   Liabilities: %s",
+	"Счета в других валютах (в капитал не входят): %s":            "Accounts in other currencies (not included in net worth): %s",
+	"Активы":                           "Assets",
+	"Обязательства":                    "Liabilities",
+	"Актив":                            "Asset",
+	"Обязательство":                    "Liability",
+	"Название счета":                   "Account name",
+	"Начальный остаток":                "Opening balance",
+	"Добавить счет":                    "Add account",
+	"Текущая стоимость":                "Current value",
+	"Записать оценку":                  "Record valuation",
+	"выберите актив или обязательство": "choose an asset or liability",
+	"Новый счет":                       "New account",
+	"Новый актив или обязательство":    "New asset or liability",
+	"Переоценка":                       "Revaluation",
+	"История капитала":                 "Net worth history",
+	"Недвижимость":                     "Real estate",
+	"Транспорт":                        "Vehicles",
+	"Денежный счет":                    "Cash account",
+	"Прочее":                           "Other",
+	"Кредитная карта":                  "Credit card",
+
+	// Профили
+	"не удалось определить домашний каталог: %w":                 "cannot determine home directory: %w",
+	"недопустимое имя профиля %q: разрешены буквы, цифры, _ и -": "invalid profile name %q: letters, digits, _ and - are allowed",
+	"файл базы данных (или переменная ":                          "database file (or variable ",
+	"профиль: отдельная база в каталоге данных":                  "profile: a separate database in the data directory",
+	"не удалось перенести базу из %s: %w":                        "failed to move database from %s: %w",
+	"База: ":              "Database: ",
+	"Имя профиля":         "Profile name",
+	"Новый профиль":       "New profile",
+	"профиль %q уже есть": "profile %q already exists",
+	"Профиль:":            "Profile:",
+
+	// Настройки
+	"не выбран": "not chosen",
+	"код валюты должен состоять из трех букв, например RUB": "currency code must be three letters, for example RUB",
+	"Сбросить размеры окон":                                 "Reset window sizes",
+	"Размеры окон сброшены":                                 "Window sizes reset",
+	"Оформление":                                            "Appearance",
+	"Тип транзакции":                                        "Transaction type",
+	"Период статистики":                                     "Statistics period",
+	"Период экспорта":                                       "Export period",
+	"Формат экспорта":                                       "Export format",
+	"Каталог экспорта":                                      "Export directory",
+	"Основная валюта":                                       "Main currency",
+	"Валюта новых счетов":                                   "Currency for new accounts",
+
+	// Командная строка и API
+	"Неизвестная команда %q\n\n%s": "Unknown command %q\n\n%s",
+	"Ошибка:": "Error:",
+	"период: all, year, month или custom":                  "period: all, year, month or custom",
+	"год для периодов year и month":                        "year for year and month periods",
+	"номер месяца (1-12) для периода month":                "month number (1-12) for the month period",
+	"начальная дата периода custom (YYYY-MM-DD)":           "start date of the custom period (YYYY-MM-DD)",
+	"конечная дата периода custom (YYYY-MM-DD)":            "end date of the custom period (YYYY-MM-DD)",
+	"неизвестный период %q":                                "unknown period %q",
+	"неизвестный формат %q: ожидается table, csv или json": "unknown format %q: expected table, csv or json",
+	"ожидается категория:сумма[:заметка]":                  "expected category:amount[:note]",
+	"тип: income, expense или transfer":                    "type: income, expense or transfer",
+	"категория":         "category",
+	"сумма":             "amount",
+	"описание":          "description",
+	"дата (YYYY-MM-DD)": "date (YYYY-MM-DD)",
+	"счет (по умолчанию основной)":                                 "account (main by default)",
+	"счет зачисления для перевода":                                 "destination account of a transfer",
+	"часть транзакции категория:сумма[:заметка], флаг повторяется": "transaction part category:amount[:note], repeatable",
+	"нет счета %q": "no account %q",
+	"для перевода укажите счет зачисления флагом -to-account":             "specify the destination account of a transfer with -to-account",
+	"флаг -to-account задается только для перевода":                       "-to-account is only allowed for transfers",
+	"счет зачисления совпадает со счетом списания":                        "the destination account is the same as the source account",
+	"счет зачисления указывается только для перевода":                     "a destination account is only allowed for transfers",
+	"у перевода не указан счет зачисления":                                "the transfer has no destination account",
+	"нет счета с номером %d":                                              "no account with ID %d",
+	"формат вывода: table, csv или json":                                  "output format: table, csv or json",
+	"Период: %s\nОбщий доход: %.2f\nОбщий расход: %.2f\nБаланс: %.2f\n\n": "Period: %s\nTotal income: %.2f\nTotal expense: %.2f\nBalance: %.2f\n\n",
+	"ожидается budget set или budget show":                                "expected budget set or budget show",
+	"лимит": "limit",
+	"период бюджета: monthly, weekly, yearly или custom":             "budget period: monthly, weekly, yearly or custom",
+	"перенос остатка: none, positive или full":                       "rollover: none, positive or full",
+	"неизвестная команда budget %s":                                  "unknown budget command %s",
+	"формат: csv или json":                                           "format: csv or json",
+	"файл для записи (по умолчанию стандартный вывод)":               "output file (standard output by default)",
+	"неизвестный формат %q: ожидается csv или json":                  "unknown format %q: expected csv or json",
+	"формат: csv или json (по умолчанию по расширению файла)":        "format: csv or json (by file extension by default)",
+	"укажите файл для импорта или - для стандартного ввода":          "give a file to import or - for standard input",
+	"Импортировано транзакций: %d\n":                                 "Transactions imported: %d\n",
+	"адрес для входящих подключений":                                 "listen address",
+	"токен доступа (или переменная ":                                 "access token (or variable ",
+	"задайте токен доступа флагом -token или переменной %s":          "set the access token with -token or the %s variable",
+	"API доступно на http://%s/api/ (описание: /api/openapi.json)\n": "API available at http://%s/api/ (description: /api/openapi.json)\n",
+	"неверный токен":                                                 "invalid token",
+	"метод не поддерживается":                                        "method not allowed",
+	"неверный месяц %q":                                              "invalid month %q",
+	"неверное значение %s":                                           "invalid %s value",
+	"limit должен быть от 1 до %d":                                   "limit must be between 1 and %d",
+
+	cliUsage: `Usage: finance_tracker [--db file | --profile name] <command> [flags]
+
+Without a command the graphical interface starts. By default the database is
+stored in $XDG_DATA_HOME/finance-tracker (~/.local/share/finance-tracker), each
+profile has its own database. The --db flag or FINANCE_DB variable set the file.
+
+Commands:
+  add          add a transaction
+  list         list transactions for a period
+  stats        income and expenses by category for a period
+  budget set   set a budget limit
+  budget show  show budget progress
+  export       export transactions to CSV or JSON
+  import       import transactions from CSV or JSON
+  serve        run the HTTP API (JSON) with token access
+
+Period flags (list, stats, export; the API takes query parameters with the same names):
+  -period all|year|month|custom  -year 2026  -month 10  -from 2026-01-01  -to 2026-03-31
+
+Command flags help: finance_tracker <command> -h
+`,
+}
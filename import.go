@@ -0,0 +1,366 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Форматы файлов, которые понимают экспорт и импорт
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+// parseTransactions читает транзакции в формате экспорта. В CSV строки с одинаковым
+// ID идут подряд и становятся частями одной разделенной транзакции, а счета
+// записаны названиями и ищутся среди accounts.
+func parseTransactions(r io.Reader, format string, accounts []Account) ([]Transaction, error) {
+	switch format {
+	case formatJSON:
+		var transactions []Transaction
+		if err := json.NewDecoder(r).Decode(&transactions); err != nil {
+			return nil, fmt.Errorf(T("неверный JSON: %w"), err)
+		}
+		for i := range transactions {
+			transactions[i].Type = typeCode(transactions[i].Type)
+		}
+		return transactions, nil
+	case formatCSV:
+		return parseTransactionsCSV(r, accounts)
+	default:
+		return nil, fmt.Errorf(T("неизвестный формат %q"), format)
+	}
+}
+
+func parseTransactionsCSV(r io.Reader, accounts []Account) ([]Transaction, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New(T("пустой файл"))
+	}
+	// Заголовок может быть на любом языке интерфейса
+	columns := []string{"ID", "Дата", "Счет", "Счет зачисления", "Тип", "Категория", "Сумма", "Описание", "Заметка", "Теги"}
+	index := make(map[string]int)
+	for i, name := range header {
+		index[untranslate(strings.TrimSpace(name), columns)] = i
+	}
+	for _, name := range []string{"Дата", "Тип", "Категория", "Сумма"} {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf(T("в заголовке нет столбца %q"), T(name))
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := index[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	// Пустой счет - основной, его подставит resetIncoming
+	account := func(record []string, name string) (int, error) {
+		value := field(record, name)
+		if value == "" {
+			return 0, nil
+		}
+		acc, ok := accountByName(accounts, value)
+		if !ok {
+			return 0, fmt.Errorf(T("нет счета %q"), value)
+		}
+		return acc.ID, nil
+	}
+
+	var transactions []Transaction
+	lastID, lastNote := "", ""
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf(T("строка %d: %w"), line, err)
+		}
+		amount, err := strconv.ParseFloat(field(record, "Сумма"), 64)
+		if err != nil {
+			return nil, fmt.Errorf(T("строка %d: неверная сумма"), line)
+		}
+		part := SplitLine{Category: field(record, "Категория"), Amount: amount, Note: field(record, "Заметка")}
+
+		// Продолжение разделенной транзакции
+		id := field(record, "ID")
+		if id != "" && id == lastID {
+			t := &transactions[len(transactions)-1]
+			if len(t.Splits) == 0 {
+				t.Splits = []SplitLine{{Category: t.Category, Amount: t.Amount, Note: lastNote}}
+			}
+			t.Splits = append(t.Splits, part)
+			t.Amount += amount
+			continue
+		}
+		accountID, err := account(record, "Счет")
+		if err != nil {
+			return nil, fmt.Errorf(T("строка %d: %w"), line, err)
+		}
+		transferAccountID, err := account(record, "Счет зачисления")
+		if err != nil {
+			return nil, fmt.Errorf(T("строка %d: %w"), line, err)
+		}
+		lastID, lastNote = id, part.Note
+		transactions = append(transactions, Transaction{
+			Date:              field(record, "Дата"),
+			Type:              typeCode(field(record, "Тип")),
+			Category:          part.Category,
+			Amount:            amount,
+			Description:       field(record, "Описание"),
+			AccountID:         accountID,
+			TransferAccountID: transferAccountID,
+			Tags:              mergeTags("", field(record, "Теги")),
+		})
+	}
+	return transactions, nil
+}
+
+// resetIncoming сбрасывает поля, которые нельзя принимать из файла или API: номер
+// получателя из другой базы ничего не значит, а сверенной транзакция становится
+// только в окне сверки. Получателя заново найдет разметка по описанию. Счет
+// списания по умолчанию - основной, в том числе у перевода: без счета списания
+// бывают только переводы из портфеля, а их создают сделки.
+func resetIncoming(t Transaction) Transaction {
+	t.ID, t.PayeeID, t.Status = 0, 0, statusUncleared
+	if t.AccountID == 0 {
+		t.AccountID = defaultAccountID
+	}
+	return t
+}
+
+// validateTransaction проверяет транзакцию перед сохранением вне формы ввода
+func validateTransaction(db dbExecutor, t Transaction) error {
+	if t.Type != typeIncome && t.Type != typeExpense && t.Type != typeTransfer {
+		return fmt.Errorf(T("неизвестный тип %q"), t.Type)
+	}
+	if t.Amount <= 0 {
+		return errors.New(T("неверная сумма"))
+	}
+	if _, err := time.Parse("2006-01-02", t.Date); err != nil {
+		return fmt.Errorf(T("неверная дата %q: ожидается YYYY-MM-DD"), t.Date)
+	}
+	if len(t.Splits) > 0 {
+		if err := validateSplits(t.Amount, t.Splits); err != nil {
+			return err
+		}
+	}
+	return validateAccounts(db, t)
+}
+
+// validateAccounts проверяет, что счета транзакции существуют, у перевода есть
+// счет зачисления, отличный от счета списания, а у дохода и расхода его нет:
+// остаток счета учитывает все транзакции, где он стоит с любой стороны
+func validateAccounts(db dbExecutor, t Transaction) error {
+	from := t.AccountID
+	if from == 0 {
+		from = defaultAccountID
+	}
+	switch {
+	case t.Type != typeTransfer && t.TransferAccountID != 0:
+		return errors.New(T("счет зачисления указывается только для перевода"))
+	case t.Type == typeTransfer && t.TransferAccountID == 0:
+		return errors.New(T("у перевода не указан счет зачисления"))
+	case t.Type == typeTransfer && t.TransferAccountID == from:
+		return errors.New(T("счет зачисления совпадает со счетом списания"))
+	}
+	for _, id := range []int{from, t.TransferAccountID} {
+		if id == 0 {
+			continue
+		}
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE id = ?", id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return fmt.Errorf(T("нет счета с номером %d"), id)
+		}
+	}
+	return nil
+}
+
+// importTransactions сохраняет транзакции как новые, размечая их правилами, и
+// возвращает сохраненные с их номерами. Импорт пишется одной SQL-транзакцией,
+// чтобы ошибка в файле не оставила половину импорта.
+func importTransactions(db *sql.DB, transactions []Transaction) ([]Transaction, error) {
+	for i, t := range transactions {
+		if err := validateTransaction(db, resetIncoming(t)); err != nil {
+			return nil, fmt.Errorf(T("транзакция %d: %w"), i+1, err)
+		}
+	}
+	markup, err := loadAutoMarkup(db)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	saved := make([]Transaction, 0, len(transactions))
+	for i, t := range transactions {
+		t = markup.apply(resetIncoming(t))
+		id, err := insertTransactionTx(tx, t)
+		if err != nil {
+			return nil, fmt.Errorf(T("транзакция %d: %w"), i+1, err)
+		}
+		t.ID = int(id)
+		saved = append(saved, t)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	for _, t := range saved {
+		learnTransaction(db, t)
+	}
+	return saved, nil
+}
+
+// importRow — строка предпросмотра импорта
+type importRow struct {
+	category   *widget.SelectEntry
+	confidence *widget.Label
+}
+
+// importWindow загружает файл экспорта и перед сохранением показывает транзакции.
+// Транзакциям без категории её подбирают правила, а если они не сработали -
+// классификатор по истории; подсказку можно поправить или выбрать другую из списка.
+func importWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Импорт данных"))
+	restoreWindowSize(window, "import", fyne.NewSize(1000, 700))
+
+	var transactions []Transaction
+	var rows []*importRow
+	fileLabel := widget.NewLabel(T("Файл не выбран"))
+	rowsContainer := container.NewVBox()
+	importButton := widget.NewButtonWithIcon(T("Импортировать"), theme.DownloadIcon(), nil)
+	importButton.Disable()
+
+	markup, err := loadAutoMarkup(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+
+	showPreview := func() {
+		rows = nil
+		rowsContainer.Objects = []fyne.CanvasObject{container.NewGridWithColumns(5,
+			widget.NewLabelWithStyle(T("Дата"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Сумма"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Описание"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Категория"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Подсказка"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		)}
+		for _, t := range transactions {
+			row := &importRow{confidence: widget.NewLabel("")}
+			marked := markup.apply(t)
+			var options []string
+			suggestions := suggestCategories(db, marked, 3)
+			for _, s := range suggestions {
+				options = append(options, s.Category)
+			}
+			row.category = widget.NewSelectEntry(options)
+			switch {
+			case len(t.Splits) > 0:
+				parts := make([]string, 0, len(t.Splits))
+				for _, line := range t.Splits {
+					parts = append(parts, line.Category)
+				}
+				row.category.SetText(strings.Join(parts, ", "))
+				row.category.Disable()
+			case t.Category != "":
+				row.category.SetText(t.Category)
+			case marked.Category != "":
+				row.category.SetText(marked.Category)
+				row.confidence.SetText(T("по правилу"))
+			case len(suggestions) > 0:
+				row.category.SetText(suggestions[0].Category)
+				row.confidence.SetText(describeSuggestion(suggestions[0]))
+			}
+			rows = append(rows, row)
+			rowsContainer.Add(container.NewGridWithColumns(5,
+				widget.NewLabel(formatDate(t.Date)),
+				widget.NewLabel(typeLabel(t.Type)+" "+money(t.Amount)),
+				widget.NewLabel(marked.Description),
+				row.category,
+				row.confidence,
+			))
+		}
+		rowsContainer.Refresh()
+		if len(transactions) > 0 {
+			importButton.Enable()
+		} else {
+			importButton.Disable()
+		}
+	}
+
+	openButton := widget.NewButtonWithIcon(T("Выбрать файл"), theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+			format := strings.TrimPrefix(strings.ToLower(reader.URI().Extension()), ".")
+			accounts, err := loadAccounts(db)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			parsed, err := parseTransactions(reader, format, accounts)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			transactions = parsed
+			fileLabel.SetText(reader.URI().Name())
+			showPreview()
+		}, window)
+	})
+
+	importButton.OnTapped = func() {
+		// Категория из предпросмотра становится категорией транзакции; правила при
+		// сохранении допишут теги и описание, но выбранную категорию не тронут
+		for i := range transactions {
+			if len(transactions[i].Splits) == 0 {
+				transactions[i].Category = strings.TrimSpace(rows[i].category.Text)
+			}
+		}
+		if err := runCommand(db, &importCommand{Transactions: transactions}); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		dialog.ShowInformation(T("Успех"), Tf("Импортировано транзакций: %d", len(transactions)), window)
+		transactions = nil
+		showPreview()
+	}
+
+	content := container.NewBorder(
+		container.NewHBox(openButton, fileLabel),
+		importButton, nil, nil,
+		container.NewScroll(rowsContainer),
+	)
+	window.SetContent(content)
+	return window
+}
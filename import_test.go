@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Выгрузка в CSV и загрузка обратно сохраняют счета, теги и части
+func TestTransactionsCSVRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	if err := saveAccount(db, Account{Name: "Карта", Currency: "RUB"}); err != nil {
+		t.Fatal(err)
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card, _ := accountByName(accounts, "Карта")
+	saved := []Transaction{
+		{ID: 1, Date: "2026-09-01", Type: typeIncome, Category: "Зарплата", Amount: 100000, AccountID: card.ID},
+		{ID: 2, Date: "2026-09-02", Type: typeTransfer, Category: "Перевод", Amount: 5000, AccountID: card.ID,
+			TransferAccountID: defaultAccountID, Description: "на наличные"},
+		{ID: 3, Date: "2026-09-03", Type: typeExpense, Amount: 1000, AccountID: defaultAccountID, Tags: "дача, отпуск",
+			Splits: []SplitLine{{Category: "Продукты", Amount: 700, Note: "овощи"}, {Category: "Хозтовары", Amount: 300}}},
+	}
+	data, err := transactionsToCSV(saved, accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseTransactions(strings.NewReader(data), formatCSV, accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != len(saved) {
+		t.Fatalf("загружено %d транзакций, ожидалось %d", len(parsed), len(saved))
+	}
+	// Номера из файла не загружаются, их заново выдаст база
+	for i := range saved {
+		saved[i].ID = 0
+		if len(parsed[i].Splits) > 0 {
+			// Категория разделенной транзакции не хранится, при сохранении ее все равно очистят
+			parsed[i].Category = ""
+		}
+	}
+	if !reflect.DeepEqual(parsed, saved) {
+		t.Errorf("после загрузки\n%+v\nожидалось\n%+v", parsed, saved)
+	}
+}
+
+func TestImportTransactionsChecksAccounts(t *testing.T) {
+	accounts := []Account{{ID: defaultAccountID, Name: "Основной"}}
+	header := "ID,Дата,Счет,Счет зачисления,Тип,Категория,Сумма\n"
+	tests := []struct {
+		name    string
+		rows    string
+		wantErr bool
+	}{
+		{name: "расход без счета", rows: "1,2026-09-01,,,Расход,Еда,100\n"},
+		{name: "неизвестный счет", rows: "1,2026-09-01,Вклад,,Расход,Еда,100\n", wantErr: true},
+		{name: "перевод без счета зачисления", rows: "1,2026-09-01,,,Перевод,Перевод,100\n", wantErr: true},
+		{name: "перевод на тот же счет", rows: "1,2026-09-01,Основной,Основной,Перевод,Перевод,100\n", wantErr: true},
+		{name: "расход со счетом зачисления", rows: "1,2026-09-01,,Основной,Расход,Еда,100\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			transactions, err := parseTransactions(strings.NewReader(header+tt.rows), formatCSV, accounts)
+			if err == nil {
+				_, err = importTransactions(db, transactions)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
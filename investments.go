@@ -0,0 +1,602 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Виды сделок с бумагами (security_trades.kind)
+const (
+	tradeBuy      = "buy"
+	tradeSell     = "sell"
+	tradeDividend = "dividend"
+)
+
+// categoryDividends — категория дохода, в которую попадают дивиденды
+const categoryDividends = "Дивиденды"
+
+// portfolioAccountName — как в списке транзакций показывается сторона перевода,
+// которая уходит в портфель или приходит из него
+const portfolioAccountName = "Портфель"
+
+var tradeKinds = []struct {
+	Code  string
+	Label string
+}{
+	{tradeBuy, "Покупка"},
+	{tradeSell, "Продажа"},
+	{tradeDividend, "Дивиденды"},
+}
+
+func tradeKindLabel(code string) string {
+	for _, k := range tradeKinds {
+		if k.Code == code {
+			return T(k.Label)
+		}
+	}
+	return code
+}
+
+// Security — ценная бумага; деньги по сделкам проходят через счет AccountID
+type Security struct {
+	ID        int
+	Ticker    string
+	Name      string
+	AccountID int
+}
+
+// Trade — сделка с бумагой. Для дивидендов Price хранит всю выплату, Quantity не используется.
+type Trade struct {
+	ID         int
+	SecurityID int
+	Date       string
+	Kind       string
+	Quantity   float64
+	Price      float64
+	Fee        float64
+}
+
+// Lot — купленная партия, еще не проданная целиком
+type Lot struct {
+	Date     string
+	Quantity float64
+	UnitCost float64 // цена за штуку с учетом комиссии
+}
+
+// Holding — позиция по бумаге на дату
+type Holding struct {
+	Security
+	Lots        []Lot
+	Quantity    float64
+	CostBasis   float64
+	Price       float64
+	PriceDate   string
+	MarketValue float64
+	Unrealized  float64
+	Realized    float64
+	Dividends   float64
+}
+
+// applyTrades раскладывает сделки по партиям: продажа списывает самые старые партии (FIFO)
+func applyTrades(trades []Trade, date string) (lots []Lot, realized, dividends float64, err error) {
+	for _, t := range trades {
+		if t.Date > date {
+			break
+		}
+		switch t.Kind {
+		case tradeBuy:
+			lots = append(lots, Lot{Date: t.Date, Quantity: t.Quantity, UnitCost: (t.Quantity*t.Price + t.Fee) / t.Quantity})
+		case tradeSell:
+			remaining := t.Quantity
+			var cost float64
+			for remaining > 1e-9 {
+				if len(lots) == 0 {
+					return nil, 0, 0, fmt.Errorf(T("продажа %s превышает количество бумаг в портфеле"), t.Date)
+				}
+				used := math.Min(remaining, lots[0].Quantity)
+				cost += used * lots[0].UnitCost
+				lots[0].Quantity -= used
+				remaining -= used
+				if lots[0].Quantity <= 1e-9 {
+					lots = lots[1:]
+				}
+			}
+			realized += t.Quantity*t.Price - t.Fee - cost
+		case tradeDividend:
+			dividends += t.Price
+		}
+	}
+	return lots, realized, dividends, nil
+}
+
+func loadSecurities(db *sql.DB) ([]Security, error) {
+	rows, err := db.Query("SELECT id, ticker, name, account_id FROM securities ORDER BY ticker")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var securities []Security
+	for rows.Next() {
+		var s Security
+		if err := rows.Scan(&s.ID, &s.Ticker, &s.Name, &s.AccountID); err != nil {
+			continue
+		}
+		securities = append(securities, s)
+	}
+	return securities, rows.Err()
+}
+
+func saveSecurity(db *sql.DB, s Security) error {
+	s.Ticker = strings.ToUpper(strings.TrimSpace(s.Ticker))
+	if s.Ticker == "" {
+		return errors.New(T("не указан тикер"))
+	}
+	if s.AccountID == 0 {
+		s.AccountID = defaultAccountID
+	}
+	_, err := db.Exec("INSERT INTO securities (ticker, name, account_id) VALUES (?, ?, ?)", s.Ticker, s.Name, s.AccountID)
+	return err
+}
+
+func loadTrades(db *sql.DB, securityID int) ([]Trade, error) {
+	rows, err := db.Query(`
+		SELECT id, security_id, date, kind, quantity, price, fee
+		FROM security_trades WHERE security_id = ? ORDER BY date, id
+	`, securityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []Trade
+	for rows.Next() {
+		var t Trade
+		if err := rows.Scan(&t.ID, &t.SecurityID, &t.Date, &t.Kind, &t.Quantity, &t.Price, &t.Fee); err != nil {
+			continue
+		}
+		trades = append(trades, t)
+	}
+	return trades, rows.Err()
+}
+
+// savePrice записывает цену бумаги на дату, заменяя прежнюю
+func savePrice(db dbExecutor, securityID int, date string, price float64) error {
+	_, err := db.Exec(`
+		INSERT INTO security_prices (security_id, date, price) VALUES (?, ?, ?)
+		ON CONFLICT (security_id, date) DO UPDATE SET price = excluded.price
+	`, securityID, date, price)
+	return err
+}
+
+// latestPrice — последняя известная цена на дату включительно
+func latestPrice(db *sql.DB, securityID int, date string) (float64, string, error) {
+	var price float64
+	var priceDate string
+	err := db.QueryRow(`
+		SELECT price, date FROM security_prices
+		WHERE security_id = ? AND date <= ?
+		ORDER BY date DESC LIMIT 1
+	`, securityID, date).Scan(&price, &priceDate)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	return price, priceDate, err
+}
+
+// recordTrade сохраняет сделку и движение денег по счету бумаги: покупка и продажа -
+// переводы между счетом и портфелем, дивиденды - доход
+func recordTrade(db *sql.DB, s Security, t Trade) error {
+	if _, err := time.Parse("2006-01-02", t.Date); err != nil {
+		return errors.New(T("неверная дата: ожидается YYYY-MM-DD"))
+	}
+	if t.Price <= 0 {
+		return errors.New(T("неверная цена"))
+	}
+	if t.Fee < 0 {
+		return errors.New(T("неверная комиссия"))
+	}
+	if t.Kind != tradeDividend && t.Quantity <= 0 {
+		return errors.New(T("неверное количество"))
+	}
+
+	cash := Transaction{Date: t.Date, Category: s.Ticker, Description: tradeKindLabel(t.Kind) + " " + s.Ticker}
+	switch t.Kind {
+	case tradeBuy:
+		cash.Type, cash.AccountID, cash.Amount = typeTransfer, s.AccountID, t.Quantity*t.Price+t.Fee
+	case tradeSell:
+		trades, err := loadTrades(db, s.ID)
+		if err != nil {
+			return err
+		}
+		// Проверяем, что к дате продажи бумаг хватает, с учетом более поздних продаж
+		trades = append(trades, t)
+		sort.SliceStable(trades, func(i, j int) bool { return trades[i].Date < trades[j].Date })
+		if _, _, _, err := applyTrades(trades, "9999-12-31"); err != nil {
+			return err
+		}
+		if t.Fee > t.Quantity*t.Price {
+			return errors.New(T("комиссия больше суммы сделки"))
+		}
+		cash.Type, cash.TransferAccountID, cash.Amount = typeTransfer, s.AccountID, t.Quantity*t.Price-t.Fee
+	case tradeDividend:
+		// Для дивиденда цена - это вся выплата
+		if t.Fee > t.Price {
+			return errors.New(T("комиссия больше суммы дивиденда"))
+		}
+		cash.Type, cash.AccountID, cash.Category, cash.Amount = typeIncome, s.AccountID, categoryDividends, t.Price-t.Fee
+	default:
+		return errors.New(T("неизвестный вид сделки"))
+	}
+
+	// Движение денег и сделка сохраняются только вместе
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	id, err := insertTransactionTx(tx, cash)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO security_trades (security_id, date, kind, quantity, price, fee, transaction_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, s.ID, t.Date, t.Kind, t.Quantity, t.Price, t.Fee, id)
+	if err != nil {
+		return err
+	}
+	if t.Kind != tradeDividend {
+		// Цена сделки - тоже котировка, если на этот день другой нет
+		_, err = tx.Exec("INSERT OR IGNORE INTO security_prices (security_id, date, price) VALUES (?, ?, ?)", s.ID, t.Date, t.Price)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	learnTransaction(db, cash)
+	return nil
+}
+
+// importPrices читает котировки из CSV со строками "тикер,дата,цена".
+// Возвращает число загруженных цен; строки с неизвестным тикером пропускаются.
+// Файл загружается целиком или, при ошибке в любой строке, не загружается вовсе.
+func importPrices(db *sql.DB, r io.Reader) (int, error) {
+	securities, err := loadSecurities(db)
+	if err != nil {
+		return 0, err
+	}
+	byTicker := make(map[string]int)
+	for _, s := range securities {
+		byTicker[s.Ticker] = s.ID
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return 0, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	imported := 0
+	for i, record := range records {
+		if len(record) < 3 {
+			continue
+		}
+		id, ok := byTicker[strings.ToUpper(strings.TrimSpace(record[0]))]
+		if !ok {
+			continue
+		}
+		date := strings.TrimSpace(record[1])
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			if i == 0 {
+				continue // заголовок
+			}
+			return 0, fmt.Errorf(T("строка %d: неверная дата %q"), i+1, date)
+		}
+		price, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(record[2]), ",", ".", 1), 64)
+		if err != nil || price <= 0 {
+			return 0, fmt.Errorf(T("строка %d: неверная цена %q"), i+1, record[2])
+		}
+		if err := savePrice(tx, id, date, price); err != nil {
+			return 0, err
+		}
+		imported++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
+
+func computeHolding(db *sql.DB, s Security, date string) (Holding, error) {
+	h := Holding{Security: s}
+	trades, err := loadTrades(db, s.ID)
+	if err != nil {
+		return h, err
+	}
+	h.Lots, h.Realized, h.Dividends, err = applyTrades(trades, date)
+	if err != nil {
+		return h, err
+	}
+	for _, lot := range h.Lots {
+		h.Quantity += lot.Quantity
+		h.CostBasis += lot.Quantity * lot.UnitCost
+	}
+	h.Price, h.PriceDate, err = latestPrice(db, s.ID, date)
+	if err != nil {
+		return h, err
+	}
+	h.MarketValue = h.Quantity * h.Price
+	h.Unrealized = h.MarketValue - h.CostBasis
+	return h, nil
+}
+
+func portfolio(db *sql.DB, date string) ([]Holding, error) {
+	securities, err := loadSecurities(db)
+	if err != nil {
+		return nil, err
+	}
+	var holdings []Holding
+	for _, s := range securities {
+		h, err := computeHolding(db, s, date)
+		if err != nil {
+			return nil, err
+		}
+		holdings = append(holdings, h)
+	}
+	return holdings, nil
+}
+
+// portfolioValue — рыночная стоимость всех бумаг на дату
+func portfolioValue(db *sql.DB, date string) (float64, error) {
+	holdings, err := portfolio(db, date)
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, h := range holdings {
+		total += h.MarketValue
+	}
+	return total, nil
+}
+
+func portfolioWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Инвестиции"))
+	restoreWindowSize(window, "portfolio", fyne.NewSize(1100, 800))
+
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+
+	var securities []Security
+	summaryLabel := widget.NewLabel("")
+	holdingsContainer := container.NewVBox()
+	securitySelect := widget.NewSelect(nil, nil)
+	securitySelect.PlaceHolder = T("Бумага")
+
+	update := func() {
+		holdingsContainer.Objects = nil
+		var err error
+		securities, err = loadSecurities(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		tickers := make([]string, 0, len(securities))
+		for _, s := range securities {
+			tickers = append(tickers, s.Ticker)
+		}
+		securitySelect.Options = tickers
+		securitySelect.Refresh()
+
+		holdings, err := portfolio(db, time.Now().Format("2006-01-02"))
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		holdingsContainer.Add(container.NewGridWithColumns(9,
+			widget.NewLabelWithStyle(T("Тикер"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Кол-во"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Цена"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Дата цены"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Стоимость"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Вложено"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Нереализ."), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Реализ."), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Дивиденды"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		))
+		var total Holding
+		for _, h := range holdings {
+			total.MarketValue += h.MarketValue
+			total.CostBasis += h.CostBasis
+			total.Unrealized += h.Unrealized
+			total.Realized += h.Realized
+			total.Dividends += h.Dividends
+			holdingsContainer.Add(container.NewGridWithColumns(9,
+				widget.NewLabel(h.Ticker),
+				widget.NewLabel(strconv.FormatFloat(h.Quantity, 'f', -1, 64)),
+				widget.NewLabel(formatNumber(h.Price)),
+				widget.NewLabel(formatDate(h.PriceDate)),
+				widget.NewLabel(money(h.MarketValue)),
+				widget.NewLabel(money(h.CostBasis)),
+				widget.NewLabel(signedMoney(h.Unrealized)),
+				widget.NewLabel(signedMoney(h.Realized)),
+				widget.NewLabel(money(h.Dividends)),
+			))
+		}
+		summaryLabel.SetText(Tf("Стоимость: %s   Вложено: %s   Нереализованный результат: %s   Реализованный: %s   Дивиденды: %s",
+			money(total.MarketValue), money(total.CostBasis), signedMoney(total.Unrealized), signedMoney(total.Realized), money(total.Dividends)))
+		holdingsContainer.Refresh()
+	}
+
+	selectedSecurity := func() (Security, error) {
+		for _, s := range securities {
+			if s.Ticker == securitySelect.Selected {
+				return s, nil
+			}
+		}
+		return Security{}, errors.New(T("выберите бумагу"))
+	}
+
+	// Сделка
+	kindLabels := make([]string, 0, len(tradeKinds))
+	for _, k := range tradeKinds {
+		kindLabels = append(kindLabels, T(k.Label))
+	}
+	kindSelect := widget.NewSelect(kindLabels, nil)
+	kindSelect.SetSelected(kindLabels[0])
+	quantityEntry := widget.NewEntry()
+	quantityEntry.SetPlaceHolder(T("Количество"))
+	priceEntry := widget.NewEntry()
+	priceEntry.SetPlaceHolder(T("Цена (для дивидендов - сумма)"))
+	feeEntry := widget.NewEntry()
+	feeEntry.SetPlaceHolder(T("Комиссия"))
+	tradeDateEntry := widget.NewEntry()
+	tradeDateEntry.SetText(formatDate(time.Now().Format("2006-01-02")))
+
+	tradeButton := widget.NewButton(T("Записать сделку"), func() {
+		s, err := selectedSecurity()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		kind := tradeBuy
+		for _, k := range tradeKinds {
+			if T(k.Label) == kindSelect.Selected {
+				kind = k.Code
+			}
+		}
+		quantity, _ := parseAmount(quantityEntry.Text)
+		price, err := parseAmount(priceEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверная цена")), window)
+			return
+		}
+		var fee float64
+		if feeEntry.Text != "" {
+			if fee, err = parseAmount(feeEntry.Text); err != nil {
+				dialog.ShowError(errors.New(T("неверная комиссия")), window)
+				return
+			}
+		}
+		err = recordTrade(db, s, Trade{Date: entryDate(tradeDateEntry.Text), Kind: kind, Quantity: quantity, Price: price, Fee: fee})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		quantityEntry.SetText("")
+		priceEntry.SetText("")
+		feeEntry.SetText("")
+		update()
+	})
+
+	// Котировка вручную
+	quoteEntry := widget.NewEntry()
+	quoteEntry.SetPlaceHolder(T("Цена"))
+	quoteDateEntry := widget.NewEntry()
+	quoteDateEntry.SetText(formatDate(time.Now().Format("2006-01-02")))
+	quoteButton := widget.NewButton(T("Сохранить цену"), func() {
+		s, err := selectedSecurity()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		price, err := parseAmount(quoteEntry.Text)
+		if err != nil || price <= 0 {
+			dialog.ShowError(errors.New(T("неверная цена")), window)
+			return
+		}
+		date, err := parseDate(quoteDateEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if err := savePrice(db, s.ID, date, price); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		quoteEntry.SetText("")
+		update()
+	})
+
+	importButton := widget.NewButtonWithIcon(T("Импорт цен из CSV"), theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+			n, err := importPrices(db, reader)
+			if err != nil {
+				dialog.ShowError(err, window)
+			} else {
+				dialog.ShowInformation(T("Успех"), Tf("Загружено цен: %d", n), window)
+			}
+			update()
+		}, window)
+	})
+
+	// Новая бумага
+	tickerEntry := widget.NewEntry()
+	tickerEntry.SetPlaceHolder(T("Тикер"))
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(T("Название"))
+	accountSelect := widget.NewSelect(accountNames(accounts), nil)
+	if len(accounts) > 0 {
+		accountSelect.SetSelected(accounts[0].Name)
+	}
+	addButton := widget.NewButtonWithIcon(T("Добавить бумагу"), theme.ContentAddIcon(), func() {
+		account, _ := accountByName(accounts, accountSelect.Selected)
+		if err := saveSecurity(db, Security{Ticker: tickerEntry.Text, Name: nameEntry.Text, AccountID: account.ID}); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		tickerEntry.SetText("")
+		nameEntry.SetText("")
+		update()
+	})
+
+	update()
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(T("Портфель"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		summaryLabel,
+		holdingsContainer,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(T("Сделка"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(4, securitySelect, kindSelect, quantityEntry, priceEntry),
+		container.NewGridWithColumns(3, feeEntry, tradeDateEntry, tradeButton),
+		widget.NewLabelWithStyle(T("Цены"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(4, quoteEntry, quoteDateEntry, quoteButton, importButton),
+		widget.NewLabelWithStyle(T("Новая бумага"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(4, tickerEntry, nameEntry, accountSelect, addButton),
+	)
+	window.SetContent(container.NewScroll(content))
+	return window
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyTrades(t *testing.T) {
+	buy := func(date string, quantity, price, fee float64) Trade {
+		return Trade{Date: date, Kind: tradeBuy, Quantity: quantity, Price: price, Fee: fee}
+	}
+	sell := func(date string, quantity, price, fee float64) Trade {
+		return Trade{Date: date, Kind: tradeSell, Quantity: quantity, Price: price, Fee: fee}
+	}
+	dividend := func(date string, amount float64) Trade {
+		return Trade{Date: date, Kind: tradeDividend, Price: amount}
+	}
+
+	tests := []struct {
+		name      string
+		trades    []Trade
+		date      string
+		lots      []Lot
+		realized  float64
+		dividends float64
+		wantErr   bool
+	}{
+		{
+			name:   "комиссия входит в цену партии",
+			trades: []Trade{buy("2026-01-10", 10, 100, 10)},
+			date:   "2026-12-31",
+			lots:   []Lot{{Date: "2026-01-10", Quantity: 10, UnitCost: 101}},
+		},
+		{
+			name: "продажа списывает старые партии первыми",
+			trades: []Trade{
+				buy("2026-01-10", 10, 100, 0),
+				buy("2026-02-10", 10, 120, 0),
+				sell("2026-03-10", 15, 130, 5),
+			},
+			date: "2026-12-31",
+			lots: []Lot{{Date: "2026-02-10", Quantity: 5, UnitCost: 120}},
+			// 15 * 130 - 5 - (10 * 100 + 5 * 120)
+			realized: 345,
+		},
+		{
+			name: "продажа всего портфеля",
+			trades: []Trade{
+				buy("2026-01-10", 3, 50, 0),
+				sell("2026-02-10", 3, 40, 0),
+			},
+			date:     "2026-12-31",
+			realized: -30,
+		},
+		{
+			name: "сделки после даты не учитываются",
+			trades: []Trade{
+				buy("2026-01-10", 10, 100, 0),
+				sell("2026-06-10", 10, 150, 0),
+			},
+			date: "2026-05-31",
+			lots: []Lot{{Date: "2026-01-10", Quantity: 10, UnitCost: 100}},
+		},
+		{
+			name: "дивиденды не меняют партии",
+			trades: []Trade{
+				buy("2026-01-10", 10, 100, 0),
+				dividend("2026-04-01", 42.5),
+				dividend("2026-10-01", 7.5),
+			},
+			date:      "2026-12-31",
+			lots:      []Lot{{Date: "2026-01-10", Quantity: 10, UnitCost: 100}},
+			dividends: 50,
+		},
+		{
+			name: "продажа больше, чем есть",
+			trades: []Trade{
+				buy("2026-01-10", 10, 100, 0),
+				sell("2026-02-10", 11, 100, 0),
+			},
+			date:    "2026-12-31",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lots, realized, dividends, err := applyTrades(tt.trades, tt.date)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ожидалась ошибка")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(lots) != 0 || len(tt.lots) != 0 {
+				if !reflect.DeepEqual(lots, tt.lots) {
+					t.Errorf("партии %+v, ожидалось %+v", lots, tt.lots)
+				}
+			}
+			if math.Abs(realized-tt.realized) > 1e-9 {
+				t.Errorf("прибыль %v, ожидалось %v", realized, tt.realized)
+			}
+			if math.Abs(dividends-tt.dividends) > 1e-9 {
+				t.Errorf("дивиденды %v, ожидалось %v", dividends, tt.dividends)
+			}
+		})
+	}
+}
+
+func TestImportPrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		imported int
+		prices   int
+		wantErr  bool
+	}{
+		{
+			name:     "с заголовком и чужим тикером",
+			csv:      "ticker,date,price\nSBER,2026-09-01,250\nGAZP,2026-09-01,150\nsber,2026-09-02,\"251,5\"\n",
+			imported: 2, prices: 2,
+		},
+		{
+			name:    "ошибка в середине файла",
+			csv:     "SBER,2026-09-01,250\nSBER,2026-09-02,-1\nSBER,2026-09-03,252\n",
+			wantErr: true,
+		},
+		{
+			name:    "ошибка в последней строке",
+			csv:     "SBER,2026-09-01,250\nSBER,2026-09-02,251\nSBER,02.09.2026,252\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if err := saveSecurity(db, Security{Ticker: "SBER", Name: "Сбербанк"}); err != nil {
+				t.Fatal(err)
+			}
+			imported, err := importPrices(db, strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			var prices int
+			if err := db.QueryRow("SELECT COUNT(*) FROM security_prices").Scan(&prices); err != nil {
+				t.Fatal(err)
+			}
+			// Файл с ошибкой не оставляет ни одной цены
+			if imported != tt.imported || prices != tt.prices {
+				t.Errorf("загружено %d, в базе %d; ожидалось %d и %d", imported, prices, tt.imported, tt.prices)
+			}
+		})
+	}
+}
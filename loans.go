@@ -0,0 +1,599 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Типы графика платежей (loans.schedule_type)
+const (
+	scheduleAnnuity        = "annuity"
+	scheduleDifferentiated = "differentiated"
+)
+
+// Что пересчитывается после досрочного погашения (loan_payments.strategy)
+const (
+	earlyReduceTerm    = "term"
+	earlyReducePayment = "payment"
+)
+
+// Категории транзакций, в которые раскладывается платеж по кредиту
+const (
+	categoryLoanInterest  = "Кредит: проценты"
+	categoryLoanPrincipal = "Кредит: основной долг"
+)
+
+// loanMaxInstallments ограничивает график, если платеж не покрывает проценты
+const loanMaxInstallments = 1200
+
+var scheduleTypes = []struct {
+	Code  string
+	Label string
+}{
+	{scheduleAnnuity, "Аннуитетный"},
+	{scheduleDifferentiated, "Дифференцированный"},
+}
+
+var earlyStrategies = []struct {
+	Code  string
+	Label string
+}{
+	{earlyReduceTerm, "Сократить срок"},
+	{earlyReducePayment, "Уменьшить платеж"},
+}
+
+type Loan struct {
+	ID           int
+	Name         string
+	Principal    float64
+	Rate         float64 // годовая ставка, %
+	TermMonths   int
+	StartDate    string // дата первого платежа
+	ScheduleType string
+	AccountID    int
+	LiabilityID  int
+}
+
+// LoanPayment — внесенный платеж: очередной или досрочный
+type LoanPayment struct {
+	ID        int
+	LoanID    int
+	Date      string
+	Interest  float64
+	Principal float64
+	Early     bool
+	Strategy  string
+}
+
+// AmortizationRow — строка графика платежей
+type AmortizationRow struct {
+	Number    int // 0 - досрочное погашение
+	Date      string
+	Payment   float64
+	Interest  float64
+	Principal float64
+	Balance   float64
+	Paid      bool
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+// annuityPayment — ежемесячный аннуитетный платеж по остатку balance на n месяцев
+func annuityPayment(balance, monthlyRate float64, n int) float64 {
+	if n <= 0 {
+		return balance
+	}
+	if monthlyRate == 0 {
+		return round2(balance / float64(n))
+	}
+	return round2(balance * monthlyRate / (1 - math.Pow(1+monthlyRate, -float64(n))))
+}
+
+// annuityTerm — число месяцев, за которое платеж payment погасит остаток balance
+func annuityTerm(balance, monthlyRate, payment float64) int {
+	if payment <= 0 {
+		return loanMaxInstallments
+	}
+	if monthlyRate == 0 {
+		return int(math.Ceil(balance / payment))
+	}
+	x := 1 - balance*monthlyRate/payment
+	if x <= 0 {
+		return loanMaxInstallments
+	}
+	return int(math.Ceil(-math.Log(x) / math.Log(1+monthlyRate)))
+}
+
+// amortizationSchedule строит график: внесенные платежи в порядке дат, затем
+// прогноз по текущему остатку. Досрочные погашения пересчитывают срок или платеж.
+func amortizationSchedule(loan Loan, payments []LoanPayment) []AmortizationRow {
+	start, err := time.ParseInLocation("2006-01-02", loan.StartDate, time.Local)
+	if err != nil {
+		return nil
+	}
+	r := loan.Rate / 12 / 100
+	balance := loan.Principal
+	remaining := loan.TermMonths
+	payment := annuityPayment(balance, r, remaining)
+	part := round2(balance / float64(remaining))
+
+	var rows []AmortizationRow
+	number := 0
+	for _, p := range payments {
+		balance = round2(balance - p.Principal)
+		if !p.Early {
+			number++
+			remaining--
+			rows = append(rows, AmortizationRow{
+				Number: number, Date: p.Date, Payment: round2(p.Interest + p.Principal),
+				Interest: p.Interest, Principal: p.Principal, Balance: balance, Paid: true,
+			})
+			continue
+		}
+
+		rows = append(rows, AmortizationRow{
+			Date: p.Date, Payment: p.Principal, Principal: p.Principal, Balance: balance, Paid: true,
+		})
+		if balance <= 0 {
+			continue
+		}
+		switch {
+		case p.Strategy == earlyReducePayment && loan.ScheduleType == scheduleDifferentiated:
+			part = round2(balance / float64(remaining))
+		case p.Strategy == earlyReducePayment:
+			payment = annuityPayment(balance, r, remaining)
+		case loan.ScheduleType == scheduleDifferentiated:
+			remaining = int(math.Ceil(balance / part))
+		default:
+			remaining = annuityTerm(balance, r, payment)
+		}
+	}
+
+	// Прогноз оставшихся платежей
+	for balance > 0.005 && number < loanMaxInstallments {
+		interest := round2(balance * r)
+		principal := part
+		if loan.ScheduleType != scheduleDifferentiated {
+			principal = round2(payment - interest)
+		}
+		if remaining <= 1 || principal >= balance || principal <= 0 {
+			principal = balance
+		}
+		balance = round2(balance - principal)
+		rows = append(rows, AmortizationRow{
+			Number:    number + 1,
+			Date:      addMonthsClamped(start, number).Format("2006-01-02"),
+			Payment:   round2(principal + interest),
+			Interest:  interest,
+			Principal: principal,
+			Balance:   balance,
+		})
+		number++
+		remaining--
+	}
+	return rows
+}
+
+// nextInstallment возвращает первый неоплаченный платеж графика
+func nextInstallment(schedule []AmortizationRow) (AmortizationRow, bool) {
+	for _, row := range schedule {
+		if !row.Paid {
+			return row, true
+		}
+	}
+	return AmortizationRow{}, false
+}
+
+func loanBalance(schedule []AmortizationRow) float64 {
+	for i := len(schedule) - 1; i >= 0; i-- {
+		if schedule[i].Paid {
+			return schedule[i].Balance
+		}
+	}
+	if len(schedule) > 0 {
+		return round2(schedule[0].Balance + schedule[0].Principal)
+	}
+	return 0
+}
+
+func loadLoans(db *sql.DB) ([]Loan, error) {
+	rows, err := db.Query(`
+		SELECT id, name, principal, rate, term_months, start_date, schedule_type, account_id, liability_id
+		FROM loans ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loans []Loan
+	for rows.Next() {
+		var l Loan
+		if err := rows.Scan(&l.ID, &l.Name, &l.Principal, &l.Rate, &l.TermMonths, &l.StartDate,
+			&l.ScheduleType, &l.AccountID, &l.LiabilityID); err != nil {
+			continue
+		}
+		loans = append(loans, l)
+	}
+	return loans, rows.Err()
+}
+
+func loadLoanPayments(db dbExecutor, loanID int) ([]LoanPayment, error) {
+	rows, err := db.Query(`
+		SELECT id, loan_id, date, interest, principal, early, strategy
+		FROM loan_payments WHERE loan_id = ? ORDER BY date, id
+	`, loanID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []LoanPayment
+	for rows.Next() {
+		var p LoanPayment
+		if err := rows.Scan(&p.ID, &p.LoanID, &p.Date, &p.Interest, &p.Principal, &p.Early, &p.Strategy); err != nil {
+			continue
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+// createLoan сохраняет кредит и заводит для него обязательство в капитале
+func createLoan(db *sql.DB, loan Loan, kind string) error {
+	if loan.Name == "" {
+		return errors.New(T("не указано название кредита"))
+	}
+	if loan.Principal <= 0 {
+		return errors.New(T("неверная сумма кредита"))
+	}
+	if loan.Rate < 0 {
+		return errors.New(T("неверная ставка"))
+	}
+	if loan.TermMonths <= 0 {
+		return errors.New(T("неверный срок"))
+	}
+	if _, err := time.Parse("2006-01-02", loan.StartDate); err != nil {
+		return errors.New(T("неверная дата первого платежа: ожидается YYYY-MM-DD"))
+	}
+	if loan.AccountID == 0 {
+		loan.AccountID = defaultAccountID
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	liabilityID, err := saveNetWorthItem(tx, NetWorthItem{Name: loan.Name, Class: classLiability, Kind: kind})
+	if err != nil {
+		return err
+	}
+	if err := addValuation(tx, int(liabilityID), time.Now().Format("2006-01-02"), loan.Principal); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO loans (name, principal, rate, term_months, start_date, schedule_type, account_id, liability_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, loan.Name, loan.Principal, loan.Rate, loan.TermMonths, loan.StartDate, loan.ScheduleType, loan.AccountID, liabilityID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordLoanPayment записывает платеж: проценты и основной долг становятся отдельными
+// транзакциями расхода, а стоимость обязательства уменьшается до нового остатка.
+// Платеж помнит свои транзакции, чтобы их нельзя было молча изменить или удалить.
+func recordLoanPayment(db *sql.DB, loan Loan, p LoanPayment) error {
+	if _, err := time.Parse("2006-01-02", p.Date); err != nil {
+		return errors.New(T("неверная дата: ожидается YYYY-MM-DD"))
+	}
+	if p.Principal <= 0 {
+		return errors.New(T("неверная сумма"))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var saved []Transaction
+	var interestID int64
+	if p.Interest > 0 {
+		interest := Transaction{
+			Date: p.Date, Category: categoryLoanInterest, Amount: p.Interest,
+			Description: loan.Name, Type: typeExpense, AccountID: loan.AccountID,
+		}
+		if interestID, err = insertTransactionTx(tx, interest); err != nil {
+			return err
+		}
+		saved = append(saved, interest)
+	}
+	principal := Transaction{
+		Date: p.Date, Category: categoryLoanPrincipal, Amount: p.Principal,
+		Description: loan.Name, Type: typeExpense, AccountID: loan.AccountID,
+	}
+	principalID, err := insertTransactionTx(tx, principal)
+	if err != nil {
+		return err
+	}
+	saved = append(saved, principal)
+	_, err = tx.Exec(`
+		INSERT INTO loan_payments (loan_id, date, interest, principal, early, strategy, interest_transaction_id, principal_transaction_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, loan.ID, p.Date, p.Interest, p.Principal, p.Early, p.Strategy, interestID, principalID)
+	if err != nil {
+		return err
+	}
+
+	payments, err := loadLoanPayments(tx, loan.ID)
+	if err != nil {
+		return err
+	}
+	if err := addValuation(tx, loan.LiabilityID, p.Date, loanBalance(amortizationSchedule(loan, payments))); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, t := range saved {
+		learnTransaction(db, t)
+	}
+	return nil
+}
+
+func loansWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Кредиты"))
+	restoreWindowSize(window, "loans", fyne.NewSize(1000, 800))
+
+	var loans []Loan
+	var selected *Loan
+	loanSelect := widget.NewSelect(nil, nil)
+	summaryLabel := widget.NewLabel("")
+	scheduleContainer := container.NewVBox()
+
+	payDateEntry := widget.NewEntry()
+	payDateEntry.SetPlaceHolder(datePlaceholder("Дата платежа"))
+	earlyAmountEntry := widget.NewEntry()
+	earlyAmountEntry.SetPlaceHolder(T("Сумма досрочного погашения"))
+	strategyLabels := []string{T(earlyStrategies[0].Label), T(earlyStrategies[1].Label)}
+	strategySelect := widget.NewSelect(strategyLabels, nil)
+	strategySelect.SetSelected(strategyLabels[0])
+
+	showSchedule := func() {
+		scheduleContainer.Objects = nil
+		summaryLabel.SetText("")
+		if selected == nil {
+			scheduleContainer.Refresh()
+			return
+		}
+		payments, err := loadLoanPayments(db, selected.ID)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		schedule := amortizationSchedule(*selected, payments)
+
+		var totalInterest float64
+		for _, row := range schedule {
+			totalInterest += row.Interest
+		}
+		summary := Tf("Остаток долга: %s   Переплата по графику: %s", money(loanBalance(schedule)), money(totalInterest))
+		if next, ok := nextInstallment(schedule); ok {
+			summary += Tf("   Следующий платеж: %s, %s", formatDate(next.Date), money(next.Payment))
+			payDateEntry.SetText(formatDate(next.Date))
+		} else {
+			summary += T("   Кредит погашен")
+		}
+		summaryLabel.SetText(summary)
+
+		scheduleContainer.Add(container.NewGridWithColumns(7,
+			widget.NewLabelWithStyle("№", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Дата"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Платеж"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Проценты"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Основной долг"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Остаток"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Статус"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		))
+		for _, row := range schedule {
+			number := strconv.Itoa(row.Number)
+			status := T("План")
+			if row.Paid {
+				status = T("Оплачен")
+			}
+			if row.Number == 0 {
+				number = T("Досрочно")
+			}
+			scheduleContainer.Add(container.NewGridWithColumns(7,
+				widget.NewLabel(number),
+				widget.NewLabel(formatDate(row.Date)),
+				widget.NewLabel(money(row.Payment)),
+				widget.NewLabel(money(row.Interest)),
+				widget.NewLabel(money(row.Principal)),
+				widget.NewLabel(money(row.Balance)),
+				widget.NewLabel(status),
+			))
+		}
+		scheduleContainer.Refresh()
+	}
+
+	updateLoans := func() {
+		var err error
+		loans, err = loadLoans(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		names := make([]string, 0, len(loans))
+		for _, l := range loans {
+			names = append(names, l.Name)
+		}
+		loanSelect.Options = names
+		loanSelect.Refresh()
+	}
+	loanSelect.OnChanged = func(name string) {
+		selected = nil
+		for i := range loans {
+			if loans[i].Name == name {
+				selected = &loans[i]
+			}
+		}
+		showSchedule()
+	}
+
+	payButton := widget.NewButton(T("Внести очередной платеж"), func() {
+		if selected == nil {
+			dialog.ShowError(errors.New(T("выберите кредит")), window)
+			return
+		}
+		payments, err := loadLoanPayments(db, selected.ID)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		next, ok := nextInstallment(amortizationSchedule(*selected, payments))
+		if !ok {
+			dialog.ShowInformation(T("Информация"), T("Кредит уже погашен"), window)
+			return
+		}
+		err = recordLoanPayment(db, *selected, LoanPayment{
+			Date: entryDate(payDateEntry.Text), Interest: next.Interest, Principal: next.Principal,
+		})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		showSchedule()
+	})
+
+	earlyButton := widget.NewButton(T("Досрочное погашение"), func() {
+		if selected == nil {
+			dialog.ShowError(errors.New(T("выберите кредит")), window)
+			return
+		}
+		amount, err := parseAmount(earlyAmountEntry.Text)
+		if err != nil || amount <= 0 {
+			dialog.ShowError(errors.New(T("неверная сумма")), window)
+			return
+		}
+		payments, err := loadLoanPayments(db, selected.ID)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if balance := loanBalance(amortizationSchedule(*selected, payments)); amount > balance {
+			dialog.ShowError(fmt.Errorf(T("сумма больше остатка долга (%s)"), money(balance)), window)
+			return
+		}
+		strategy := earlyReduceTerm
+		for _, s := range earlyStrategies {
+			if T(s.Label) == strategySelect.Selected {
+				strategy = s.Code
+			}
+		}
+		err = recordLoanPayment(db, *selected, LoanPayment{
+			Date: entryDate(payDateEntry.Text), Principal: amount, Early: true, Strategy: strategy,
+		})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		earlyAmountEntry.SetText("")
+		showSchedule()
+	})
+
+	// Форма нового кредита
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(T("Название"))
+	kindSelect := widget.NewSelect([]string{T("Ипотека"), T("Кредит")}, nil)
+	kindSelect.SetSelected(T("Кредит"))
+	principalEntry := widget.NewEntry()
+	principalEntry.SetPlaceHolder(T("Сумма кредита"))
+	rateEntry := widget.NewEntry()
+	rateEntry.SetPlaceHolder(T("Ставка, % годовых"))
+	termEntry := widget.NewEntry()
+	termEntry.SetPlaceHolder(T("Срок, месяцев"))
+	startEntry := widget.NewEntry()
+	startEntry.SetPlaceHolder(datePlaceholder("Первый платеж"))
+	scheduleSelect := widget.NewSelect([]string{T(scheduleTypes[0].Label), T(scheduleTypes[1].Label)}, nil)
+	scheduleSelect.SetSelected(T(scheduleTypes[0].Label))
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+	accountSelect := widget.NewSelect(accountNames(accounts), nil)
+	if len(accounts) > 0 {
+		accountSelect.SetSelected(accounts[0].Name)
+	}
+
+	addButton := widget.NewButtonWithIcon(T("Добавить кредит"), theme.ContentAddIcon(), func() {
+		principal, err := parseAmount(principalEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверная сумма кредита")), window)
+			return
+		}
+		rate, err := parseAmount(rateEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверная ставка")), window)
+			return
+		}
+		term, err := strconv.Atoi(termEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверный срок")), window)
+			return
+		}
+		scheduleType := scheduleAnnuity
+		for _, t := range scheduleTypes {
+			if T(t.Label) == scheduleSelect.Selected {
+				scheduleType = t.Code
+			}
+		}
+		account, _ := accountByName(accounts, accountSelect.Selected)
+		err = createLoan(db, Loan{
+			Name: nameEntry.Text, Principal: principal, Rate: rate, TermMonths: term,
+			StartDate: entryDate(startEntry.Text), ScheduleType: scheduleType, AccountID: account.ID,
+		}, untranslate(kindSelect.Selected, liabilityKinds))
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		updateLoans()
+		loanSelect.SetSelected(nameEntry.Text)
+	})
+
+	updateLoans()
+
+	scheduleScroll := container.NewScroll(scheduleContainer)
+	scheduleScroll.SetMinSize(fyne.NewSize(950, 400))
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(T("Кредиты"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewHBox(widget.NewLabel(T("Кредит:")), loanSelect),
+		summaryLabel,
+		container.NewGridWithColumns(3, payDateEntry, payButton, widget.NewLabel("")),
+		container.NewGridWithColumns(3, earlyAmountEntry, strategySelect, earlyButton),
+		scheduleScroll,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(T("Новый кредит"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(4, nameEntry, kindSelect, principalEntry, rateEntry),
+		container.NewGridWithColumns(5, termEntry, startEntry, scheduleSelect, accountSelect, addButton),
+	)
+
+	window.SetContent(container.NewScroll(content))
+	return window
+}
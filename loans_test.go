@@ -0,0 +1,100 @@
+package main
+
+import "testing"
+
+func TestAmortizationSchedule(t *testing.T) {
+	loan := Loan{Principal: 12000, Rate: 12, TermMonths: 12, StartDate: "2026-01-15", ScheduleType: scheduleAnnuity}
+	differentiated := loan
+	differentiated.ScheduleType = scheduleDifferentiated
+	interestFree := loan
+	interestFree.Rate = 0
+
+	// Первый платеж по графику и досрочное погашение 5000 через пять дней
+	early := func(strategy string) []LoanPayment {
+		return []LoanPayment{
+			{Date: "2026-01-15", Interest: 120, Principal: 946.19},
+			{Date: "2026-01-20", Principal: 5000, Early: true, Strategy: strategy},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		loan     Loan
+		payments []LoanPayment
+		// installments — очередных платежей в графике, включая внесенные
+		installments int
+		first        AmortizationRow // первая строка прогноза
+		lastPayment  float64
+	}{
+		{
+			name: "аннуитет", loan: loan, installments: 12,
+			first:       AmortizationRow{Number: 1, Date: "2026-01-15", Payment: 1066.19, Interest: 120, Principal: 946.19, Balance: 11053.81},
+			lastPayment: 1066.14,
+		},
+		{
+			name: "дифференцированный", loan: differentiated, installments: 12,
+			first:       AmortizationRow{Number: 1, Date: "2026-01-15", Payment: 1120, Interest: 120, Principal: 1000, Balance: 11000},
+			lastPayment: 1010,
+		},
+		{
+			name: "без процентов", loan: interestFree, installments: 12,
+			first:       AmortizationRow{Number: 1, Date: "2026-01-15", Payment: 1000, Principal: 1000, Balance: 11000},
+			lastPayment: 1000,
+		},
+		{
+			name: "досрочно, сократить срок", loan: loan, payments: early(earlyReduceTerm), installments: 7,
+			first:       AmortizationRow{Number: 2, Date: "2026-02-15", Payment: 1066.19, Interest: 60.54, Principal: 1005.65, Balance: 5048.16},
+			lastPayment: 933.21,
+		},
+		{
+			name: "досрочно, уменьшить платеж", loan: loan, payments: early(earlyReducePayment), installments: 12,
+			first:       AmortizationRow{Number: 2, Date: "2026-02-15", Payment: 583.91, Interest: 60.54, Principal: 523.37, Balance: 5530.44},
+			lastPayment: 583.96,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := amortizationSchedule(tt.loan, tt.payments)
+			if len(rows) == 0 {
+				t.Fatal("пустой график")
+			}
+
+			installments := 0
+			var principal int64
+			var first *AmortizationRow
+			for i, row := range rows {
+				if row.Number > 0 {
+					installments++
+				}
+				principal += toCents(row.Principal)
+				if !row.Paid && first == nil {
+					first = &rows[i]
+				}
+			}
+			if installments != tt.installments {
+				t.Errorf("платежей %d, ожидалось %d", installments, tt.installments)
+			}
+			if principal != toCents(tt.loan.Principal) {
+				t.Errorf("погашено %.2f, ожидалось %.2f", float64(principal)/100, tt.loan.Principal)
+			}
+			if first == nil {
+				t.Fatal("в графике нет прогноза")
+			}
+			if *first != tt.first {
+				t.Errorf("первый платеж прогноза %+v, ожидалось %+v", *first, tt.first)
+			}
+			last := rows[len(rows)-1]
+			if last.Balance != 0 || last.Payment != tt.lastPayment {
+				t.Errorf("последний платеж %.2f с остатком %.2f, ожидалось %.2f с нулевым остатком",
+					last.Payment, last.Balance, tt.lastPayment)
+			}
+		})
+	}
+}
+
+func TestAmortizationScheduleInvalidStart(t *testing.T) {
+	loan := Loan{Principal: 1000, Rate: 10, TermMonths: 10, StartDate: "15.01.2026"}
+	if rows := amortizationSchedule(loan, nil); rows != nil {
+		t.Errorf("график по неверной дате: %v", rows)
+	}
+}
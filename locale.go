@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// locale — правила записи чисел и дат для языка интерфейса
+type locale struct {
+	Decimal      string // десятичный разделитель
+	Group        string // разделитель разрядов
+	DateLayout   string // формат даты для time.Format
+	DateHint     string // тот же формат для подсказки в поле ввода
+	SymbolBefore bool   // символ валюты ставится перед суммой
+	FirstWeekday time.Weekday
+}
+
+var locales = map[string]locale{
+	langRussian: {Decimal: ",", Group: " ", DateLayout: "02.01.2006", DateHint: "ДД.ММ.ГГГГ", FirstWeekday: time.Monday},
+	langEnglish: {Decimal: ".", Group: ",", DateLayout: "01/02/2006", DateHint: "MM/DD/YYYY", SymbolBefore: true, FirstWeekday: time.Sunday},
+}
+
+// Символы валют; для остальных кодов показывается сам код
+var currencySymbols = map[string]string{
+	"RUB": "₽",
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"CNY": "¥",
+	"JPY": "¥",
+	"KZT": "₸",
+	"UAH": "₴",
+	"BYN": "Br",
+	"TRY": "₺",
+	"INR": "₹",
+}
+
+func currentLocale() locale {
+	return locales[currentLanguage]
+}
+
+// formatNumber записывает число с разделителями разрядов и двумя знаками после запятой
+func formatNumber(v float64) string {
+	loc := currentLocale()
+	text := strconv.FormatFloat(math.Abs(v), 'f', 2, 64)
+	whole, fraction := text[:len(text)-3], text[len(text)-2:]
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(loc.Group)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	sign := ""
+	if v < 0 && text != "0.00" {
+		sign = "-"
+	}
+	return sign + grouped.String() + loc.Decimal + fraction
+}
+
+func currencySymbol(code string) string {
+	if symbol, ok := currencySymbols[strings.ToUpper(code)]; ok {
+		return symbol
+	}
+	return code
+}
+
+// formatMoney записывает сумму с символом валюты: "1 234,56 ₽" или "$1,234.56"
+func formatMoney(v float64, currency string) string {
+	symbol := currencySymbol(currency)
+	if currentLocale().SymbolBefore {
+		number := formatNumber(v)
+		if strings.HasPrefix(number, "-") {
+			return "-" + symbol + number[1:]
+		}
+		return symbol + number
+	}
+	return formatNumber(v) + " " + symbol
+}
+
+// money записывает сумму в основной валюте открытой базы. Ею показываются
+// итоги, собранные по счетам этой валюты.
+func money(v float64) string {
+	return formatMoney(v, baseCurrency())
+}
+
+// currentBaseCurrency — основная валюта открытой базы; её читает useBaseCurrency
+var currentBaseCurrency = defaultCurrency
+
+func baseCurrency() string {
+	return currentBaseCurrency
+}
+
+// parseAmount читает сумму, набранную пользователем; символ валюты пропускается.
+// В русской записи десятичным разделителем может быть и запятая, и точка
+// ("1 234,56", "1234.56"), в английской запятая разделяет разряды ("1,234.56").
+// Разряды можно разделять и пробелами или апострофом, но только группами по три
+// цифры: иначе "12,5" в английской записи молча превратилось бы в 125.
+func parseAmount(text string) (float64, error) {
+	loc := currentLocale()
+	invalid := errors.New(T("неверная сумма"))
+	cleaned := strings.TrimSpace(text)
+	for _, symbol := range currencySymbols {
+		cleaned = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(cleaned, symbol), symbol))
+	}
+	sign := ""
+	if strings.HasPrefix(cleaned, "-") || strings.HasPrefix(cleaned, "+") {
+		sign, cleaned = cleaned[:1], cleaned[1:]
+	}
+
+	decimals := loc.Decimal
+	if loc.Decimal == "," {
+		decimals = ",."
+	}
+	whole, fraction := cleaned, ""
+	if i := strings.LastIndexAny(cleaned, decimals); i >= 0 {
+		whole, fraction = cleaned[:i], cleaned[i+1:]
+	}
+	if strings.ContainsAny(whole, decimals) || !isDigits(fraction) {
+		return 0, invalid
+	}
+
+	// Целая часть: первая группа от одной до трех цифр, остальные ровно по три
+	separators := " \u00a0\u202f'" + loc.Group
+	var groups []string
+	start := 0
+	for i, r := range whole {
+		if strings.ContainsRune(separators, r) {
+			groups = append(groups, whole[start:i])
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	groups = append(groups, whole[start:])
+	for i, g := range groups {
+		if !isDigits(g) || len(groups) > 1 && (g == "" || i == 0 && len(g) > 3 || i > 0 && len(g) != 3) {
+			return 0, invalid
+		}
+	}
+	digits := strings.Join(groups, "")
+	if digits == "" && fraction == "" {
+		return 0, invalid
+	}
+	if digits == "" {
+		digits = "0"
+	}
+	if fraction != "" {
+		digits += "." + fraction
+	}
+	value, err := strconv.ParseFloat(sign+digits, 64)
+	if err != nil {
+		return 0, invalid
+	}
+	return value, nil
+}
+
+// isDigits — состоит ли строка только из цифр 0-9; пустая строка подходит
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatDate показывает дату из базы (YYYY-MM-DD) в формате языка интерфейса
+func formatDate(iso string) string {
+	t, err := time.Parse("2006-01-02", iso)
+	if err != nil {
+		return iso
+	}
+	return t.Format(currentLocale().DateLayout)
+}
+
+// parseDate читает дату в формате языка интерфейса или в виде YYYY-MM-DD
+// и возвращает ее в том виде, в котором даты хранятся в базе
+func parseDate(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range []string{currentLocale().DateLayout, "2006-01-02"} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf(T("неверная дата %q: ожидается %s"), text, currentLocale().DateHint)
+}
+
+// entryDate переводит дату из поля ввода в YYYY-MM-DD; нераспознанный текст
+// возвращается как есть, чтобы ошибку показала проверка, которая его получит
+func entryDate(text string) string {
+	if date, err := parseDate(text); err == nil {
+		return date
+	}
+	return text
+}
+
+// datePlaceholder — подсказка для поля ввода даты, например "Дата (ДД.ММ.ГГГГ)"
+func datePlaceholder(label string) string {
+	return T(label) + " (" + currentLocale().DateHint + ")"
+}
+
+// signedMoney — сумма со знаком и в плюсе, и в минусе, для изменений и результатов
+func signedMoney(v float64) string {
+	if v > 0 {
+		return "+" + money(v)
+	}
+	return money(v)
+}
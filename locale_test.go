@@ -0,0 +1,100 @@
+package main
+
+import "testing"
+
+// useLanguage переключает язык интерфейса на время теста
+func useLanguage(t *testing.T, code string) {
+	t.Helper()
+	previous := currentLanguage
+	setLanguage(code)
+	t.Cleanup(func() { currentLanguage = previous })
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		lang    string
+		text    string
+		want    float64
+		wantErr bool
+	}{
+		{lang: langRussian, text: "250", want: 250},
+		{lang: langRussian, text: "1 234,56", want: 1234.56},
+		{lang: langRussian, text: "1 234,56", want: 1234.56},
+		{lang: langRussian, text: "1234.56", want: 1234.56},
+		{lang: langRussian, text: " 99,9 ₽ ", want: 99.9},
+		{lang: langRussian, text: "-15,5", want: -15.5},
+		{lang: langRussian, text: "1,2,3", wantErr: true},
+		{lang: langRussian, text: "", wantErr: true},
+		{lang: langRussian, text: "сто", wantErr: true},
+		{lang: langRussian, text: "NaN", wantErr: true},
+		{lang: langRussian, text: "Inf", wantErr: true},
+		{lang: langEnglish, text: "1,234.56", want: 1234.56},
+		{lang: langEnglish, text: "$12.50", want: 12.5},
+		{lang: langEnglish, text: "1 000", want: 1000},
+		{lang: langEnglish, text: "1,234,567.5", want: 1234567.5},
+		{lang: langEnglish, text: "12,5", wantErr: true},
+		{lang: langEnglish, text: "1 234,56", wantErr: true},
+		{lang: langEnglish, text: "1,2345", wantErr: true},
+		{lang: langEnglish, text: "12.5.1", wantErr: true},
+		{lang: langRussian, text: "12 34", wantErr: true},
+		{lang: langRussian, text: "1 234 567", want: 1234567},
+		{lang: langRussian, text: ",5", want: 0.5},
+		{lang: langRussian, text: "1e5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang+" "+tt.text, func(t *testing.T) {
+			useLanguage(t, tt.lang)
+			got, err := parseAmount(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка, получено %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if toCents(got) != toCents(tt.want) {
+				t.Errorf("получено %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		lang    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{lang: langRussian, text: "05.10.2026", want: "2026-10-05"},
+		{lang: langRussian, text: " 2026-10-05 ", want: "2026-10-05"},
+		{lang: langRussian, text: "29.02.2028", want: "2028-02-29"},
+		{lang: langRussian, text: "29.02.2026", wantErr: true},
+		{lang: langRussian, text: "10/05/2026", wantErr: true},
+		{lang: langRussian, text: "", wantErr: true},
+		{lang: langEnglish, text: "10/05/2026", want: "2026-10-05"},
+		{lang: langEnglish, text: "2026-10-05", want: "2026-10-05"},
+		{lang: langEnglish, text: "05.10.2026", wantErr: true},
+		{lang: langEnglish, text: "13/01/2026", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang+" "+tt.text, func(t *testing.T) {
+			useLanguage(t, tt.lang)
+			got, err := parseDate(tt.text)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка, получено %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("получено %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultAccountID — счет, созданный миграцией для транзакций, введенных до появления счетов
+const defaultAccountID = 1
+
+// Классы статей капитала (net_worth_items.class)
+const (
+	classAsset     = "asset"
+	classLiability = "liability"
+)
+
+var assetKinds = []string{"Недвижимость", "Транспорт", "Инвестиции", "Денежный счет", "Прочее"}
+var liabilityKinds = []string{"Ипотека", "Кредит", "Кредитная карта", "Прочее"}
+
+type Account struct {
+	ID             int
+	Name           string
+	Currency       string
+	OpeningBalance float64
+}
+
+// NetWorthItem — актив или обязательство с датированными оценками стоимости
+type NetWorthItem struct {
+	ID    int
+	Name  string
+	Class string
+	Kind  string
+}
+
+// NetWorthSnapshot — состав капитала на дату. Accounts и NetWorth считаются
+// в основной валюте; курсов в программе нет, поэтому остатки счетов в других
+// валютах собраны в Foreign по кодам валют и в капитал не входят.
+type NetWorthSnapshot struct {
+	Date        string
+	Accounts    float64
+	Investments float64
+	Assets      float64
+	Liabilities float64
+	NetWorth    float64
+	Foreign     map[string]float64
+}
+
+func loadAccounts(db *sql.DB) ([]Account, error) {
+	rows, err := db.Query("SELECT id, name, currency, opening_balance FROM accounts ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []Account
+	for rows.Next() {
+		var a Account
+		if err := rows.Scan(&a.ID, &a.Name, &a.Currency, &a.OpeningBalance); err != nil {
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func saveAccount(db *sql.DB, a Account) error {
+	if a.Name == "" {
+		return errors.New(T("не указано название счета"))
+	}
+	a.Currency = strings.ToUpper(strings.TrimSpace(a.Currency))
+	if a.Currency == "" {
+		a.Currency = baseCurrency()
+	}
+	_, err := db.Exec("INSERT INTO accounts (name, currency, opening_balance) VALUES (?, ?, ?)",
+		a.Name, a.Currency, a.OpeningBalance)
+	return err
+}
+
+// settingBaseCurrency — ключ основной валюты в app_settings. Она хранится в самой
+// базе, а не в настройках приложения: от неё зависит, какие счета складываются
+// в итоги, и смена валюты новых счетов не должна её менять.
+const settingBaseCurrency = "base_currency"
+
+// useBaseCurrency делает основной валюту, записанную в открытой базе
+func useBaseCurrency(db *sql.DB) {
+	currentBaseCurrency = strings.ToUpper(getSetting(db, settingBaseCurrency, defaultCurrency))
+}
+
+// setBaseCurrency меняет основную валюту базы. Суммы не пересчитываются: итоги
+// просто начинают складывать счета другой валюты.
+func setBaseCurrency(db *sql.DB, code string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return errors.New(T("код валюты должен состоять из трех букв, например RUB"))
+	}
+	if err := setSetting(db, settingBaseCurrency, code); err != nil {
+		return err
+	}
+	currentBaseCurrency = code
+	return nil
+}
+
+// inBaseCurrency — ведется ли счет в основной валюте; счет без валюты считается в ней
+func (a Account) inBaseCurrency() bool {
+	return a.Currency == "" || strings.EqualFold(a.Currency, baseCurrency())
+}
+
+// baseCurrencyCondition — SQL-условие того же, что inBaseCurrency, для счета в столбце
+// column; номер 0 у старых транзакций означает основной счет
+func baseCurrencyCondition(column string) (string, []interface{}) {
+	return fmt.Sprintf(`COALESCE(NULLIF(%s, 0), %d) IN (
+		SELECT id FROM accounts WHERE COALESCE(currency, '') = '' OR UPPER(currency) = ?
+	)`, column, defaultAccountID), []interface{}{baseCurrency()}
+}
+
+func accountNames(accounts []Account) []string {
+	names := make([]string, 0, len(accounts))
+	for _, a := range accounts {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func accountByName(accounts []Account, name string) (Account, bool) {
+	for _, a := range accounts {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Account{}, false
+}
+
+// accountBalance — остаток счета на дату включительно с учетом начального остатка.
+// Перевод уменьшает счет списания и увеличивает счет зачисления.
+func accountBalance(db *sql.DB, accountID int, date string) (float64, error) {
+	var balance float64
+	err := db.QueryRow(`
+		SELECT a.opening_balance + COALESCE((
+			SELECT SUM(CASE
+				WHEN t.type = ? AND t.transfer_account_id = a.id THEN t.amount
+				WHEN t.type = ? THEN -t.amount
+				WHEN t.type = ? THEN t.amount
+				WHEN t.type = ? THEN -t.amount
+				ELSE 0 END)
+			FROM transactions t
+			WHERE (t.account_id = a.id OR t.transfer_account_id = a.id) AND t.date <= ?
+		), 0)
+		FROM accounts a
+		WHERE a.id = ?
+	`, typeTransfer, typeTransfer, typeIncome, typeExpense, date, accountID).Scan(&balance)
+	return balance, err
+}
+
+func loadNetWorthItems(db *sql.DB) ([]NetWorthItem, error) {
+	rows, err := db.Query("SELECT id, name, class, kind FROM net_worth_items ORDER BY class, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []NetWorthItem
+	for rows.Next() {
+		var item NetWorthItem
+		if err := rows.Scan(&item.ID, &item.Name, &item.Class, &item.Kind); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// dbExecutor — общее у *sql.DB и *sql.Tx: такие записи можно делать как отдельно,
+// так и частью большей SQL-транзакции
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func saveNetWorthItem(db dbExecutor, item NetWorthItem) (int64, error) {
+	if item.Name == "" {
+		return 0, errors.New(T("не указано название"))
+	}
+	if item.Class != classAsset && item.Class != classLiability {
+		return 0, fmt.Errorf(T("неизвестный класс %q"), item.Class)
+	}
+	res, err := db.Exec("INSERT INTO net_worth_items (name, class, kind) VALUES (?, ?, ?)", item.Name, item.Class, item.Kind)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// addValuation записывает оценку стоимости статьи на дату; повторная оценка на ту же дату заменяет прежнюю
+func addValuation(db dbExecutor, itemID int, date string, value float64) error {
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return errors.New(T("неверная дата: ожидается YYYY-MM-DD"))
+	}
+	if value < 0 {
+		return errors.New(T("стоимость не может быть отрицательной"))
+	}
+	_, err := db.Exec(`
+		INSERT INTO net_worth_valuations (item_id, date, value) VALUES (?, ?, ?)
+		ON CONFLICT(item_id, date) DO UPDATE SET value = excluded.value
+	`, itemID, date, value)
+	return err
+}
+
+// itemValue — последняя известная стоимость статьи на дату; до первой оценки - ноль
+func itemValue(db *sql.DB, itemID int, date string) (float64, error) {
+	var value float64
+	err := db.QueryRow(`
+		SELECT COALESCE((
+			SELECT value FROM net_worth_valuations
+			WHERE item_id = ? AND date <= ?
+			ORDER BY date DESC LIMIT 1
+		), 0)
+	`, itemID, date).Scan(&value)
+	return value, err
+}
+
+func netWorthAt(db *sql.DB, date string) (NetWorthSnapshot, error) {
+	s := NetWorthSnapshot{Date: date}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		return s, err
+	}
+	for _, a := range accounts {
+		balance, err := accountBalance(db, a.ID, date)
+		if err != nil {
+			return s, err
+		}
+		if a.inBaseCurrency() {
+			s.Accounts += balance
+			continue
+		}
+		if s.Foreign == nil {
+			s.Foreign = make(map[string]float64)
+		}
+		s.Foreign[strings.ToUpper(a.Currency)] += balance
+	}
+	s.Investments, err = portfolioValue(db, date)
+	if err != nil {
+		return s, err
+	}
+
+	items, err := loadNetWorthItems(db)
+	if err != nil {
+		return s, err
+	}
+	for _, item := range items {
+		value, err := itemValue(db, item.ID, date)
+		if err != nil {
+			return s, err
+		}
+		if item.Class == classLiability {
+			s.Liabilities += value
+		} else {
+			s.Assets += value
+		}
+	}
+	s.NetWorth = s.Accounts + s.Investments + s.Assets - s.Liabilities
+	return s, nil
+}
+
+// foreignBalances перечисляет остатки в других валютах по порядку кодов
+func foreignBalances(balances map[string]float64) string {
+	codes := make([]string, 0, len(balances))
+	for code := range balances {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		parts = append(parts, formatMoney(balances[code], code))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// netWorthHistory возвращает капитал на конец каждого из последних months месяцев;
+// последняя точка - сегодняшний день
+func netWorthHistory(db *sql.DB, today time.Time, months int) ([]NetWorthSnapshot, error) {
+	var history []NetWorthSnapshot
+	firstOfMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.Local)
+	for i := months - 1; i >= 1; i-- {
+		end := firstOfMonth.AddDate(0, -i+1, -1)
+		s, err := netWorthAt(db, end.Format("2006-01-02"))
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, s)
+	}
+	s, err := netWorthAt(db, today.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return append(history, s), nil
+}
+
+func netWorthWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Капитал"))
+	restoreWindowSize(window, "net_worth", fyne.NewSize(1000, 800))
+
+	summaryContainer := container.NewVBox()
+	itemsContainer := container.NewVBox()
+	historyContainer := container.NewVBox()
+
+	itemSelect := widget.NewSelect(nil, nil)
+	var items []NetWorthItem
+	baseSelect := widget.NewSelect(nil, nil)
+
+	update := func() {
+		today := time.Now()
+		summaryContainer.Objects = nil
+		itemsContainer.Objects = nil
+		historyContainer.Objects = nil
+
+		current, err := netWorthAt(db, today.Format("2006-01-02"))
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		summaryContainer.Add(widget.NewLabelWithStyle(Tf("Чистый капитал: %s", money(current.NetWorth)),
+			fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		summaryContainer.Add(widget.NewLabel(Tf("Счета: %s   Инвестиции: %s   Активы: %s   Обязательства: %s",
+			money(current.Accounts), money(current.Investments), money(current.Assets), money(current.Liabilities))))
+		if len(current.Foreign) > 0 {
+			summaryContainer.Add(widget.NewLabel(Tf("Счета в других валютах (в капитал не входят): %s",
+				foreignBalances(current.Foreign))))
+		}
+
+		// Счета, остатки которых считаются по транзакциям
+		accounts, err := loadAccounts(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		// В основную валюту можно перевести итоги, выбрав валюту одного из счетов
+		seen := map[string]bool{baseCurrency(): true}
+		currencies := []string{baseCurrency()}
+		for _, acc := range accounts {
+			if code := strings.ToUpper(acc.Currency); code != "" && !seen[code] {
+				seen[code] = true
+				currencies = append(currencies, code)
+			}
+		}
+		sort.Strings(currencies)
+		baseSelect.Options = currencies
+		baseSelect.SetSelected(baseCurrency())
+
+		itemsContainer.Add(widget.NewLabelWithStyle(T("Счета"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		for _, acc := range accounts {
+			balance, err := accountBalance(db, acc.ID, today.Format("2006-01-02"))
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			itemsContainer.Add(widget.NewLabel(fmt.Sprintf("%s: %s", acc.Name, formatMoney(balance, acc.Currency))))
+		}
+
+		items, err = loadNetWorthItems(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		var names []string
+		for _, class := range []string{classAsset, classLiability} {
+			title := T("Активы")
+			if class == classLiability {
+				title = T("Обязательства")
+			}
+			itemsContainer.Add(widget.NewLabelWithStyle(title, fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+			for _, item := range items {
+				if item.Class != class {
+					continue
+				}
+				value, err := itemValue(db, item.ID, today.Format("2006-01-02"))
+				if err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				itemsContainer.Add(widget.NewLabel(fmt.Sprintf("%s | %s | %s", item.Name, T(item.Kind), money(value))))
+				names = append(names, item.Name)
+			}
+		}
+		itemSelect.Options = names
+		itemSelect.Refresh()
+
+		// История капитала по месяцам за последний год
+		history, err := netWorthHistory(db, today, 12)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		points := make([]balancePoint, 0, len(history))
+		for _, s := range history {
+			points = append(points, balancePoint{Date: s.Date, Balance: s.NetWorth})
+		}
+		historyContainer.Add(newBalanceChart(points))
+		historyContainer.Add(container.NewGridWithColumns(6,
+			widget.NewLabelWithStyle(T("Дата"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Счета"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Инвестиции"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Активы"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Обязательства"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Капитал"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		))
+		for i := len(history) - 1; i >= 0; i-- {
+			s := history[i]
+			historyContainer.Add(container.NewGridWithColumns(6,
+				widget.NewLabel(formatDate(s.Date)),
+				widget.NewLabel(money(s.Accounts)),
+				widget.NewLabel(money(s.Investments)),
+				widget.NewLabel(money(s.Assets)),
+				widget.NewLabel(money(s.Liabilities)),
+				widget.NewLabel(money(s.NetWorth)),
+			))
+		}
+
+		summaryContainer.Refresh()
+		itemsContainer.Refresh()
+		historyContainer.Refresh()
+	}
+
+	baseSelect.OnChanged = func(code string) {
+		if code == baseCurrency() {
+			return
+		}
+		if err := setBaseCurrency(db, code); err != nil {
+			dialog.ShowError(err, window)
+		}
+		update()
+	}
+
+	// Новый счет
+	accountNameEntry := widget.NewEntry()
+	accountNameEntry.SetPlaceHolder(T("Название счета"))
+	accountCurrencyEntry := widget.NewEntry()
+	accountCurrencyEntry.SetText(preferredDefaultCurrency())
+	openingEntry := widget.NewEntry()
+	openingEntry.SetPlaceHolder(T("Начальный остаток"))
+	addAccountButton := widget.NewButtonWithIcon(T("Добавить счет"), theme.ContentAddIcon(), func() {
+		opening := 0.0
+		if openingEntry.Text != "" {
+			var err error
+			opening, err = parseAmount(openingEntry.Text)
+			if err != nil {
+				dialog.ShowError(errors.New(T("неверная сумма")), window)
+				return
+			}
+		}
+		err := saveAccount(db, Account{Name: accountNameEntry.Text, Currency: accountCurrencyEntry.Text, OpeningBalance: opening})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		accountNameEntry.SetText("")
+		openingEntry.SetText("")
+		update()
+	})
+
+	// Новый актив или обязательство
+	itemNameEntry := widget.NewEntry()
+	itemNameEntry.SetPlaceHolder(T("Название"))
+	kindSelect := widget.NewSelect(translateAll(assetKinds), nil)
+	classSelect := widget.NewSelect([]string{T("Актив"), T("Обязательство")}, func(selected string) {
+		if selected == T("Обязательство") {
+			kindSelect.Options = translateAll(liabilityKinds)
+		} else {
+			kindSelect.Options = translateAll(assetKinds)
+		}
+		kindSelect.ClearSelected()
+		kindSelect.Refresh()
+	})
+	classSelect.SetSelected(T("Актив"))
+	itemValueEntry := widget.NewEntry()
+	itemValueEntry.SetPlaceHolder(T("Текущая стоимость"))
+	addItemButton := widget.NewButtonWithIcon(T("Добавить"), theme.ContentAddIcon(), func() {
+		value, err := parseAmount(itemValueEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверная сумма")), window)
+			return
+		}
+		class := classAsset
+		if classSelect.Selected == T("Обязательство") {
+			class = classLiability
+		}
+		id, err := saveNetWorthItem(db, NetWorthItem{Name: itemNameEntry.Text, Class: class, Kind: untranslate(kindSelect.Selected, append(assetKinds, liabilityKinds...))})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if err := addValuation(db, int(id), time.Now().Format("2006-01-02"), value); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		itemNameEntry.SetText("")
+		itemValueEntry.SetText("")
+		update()
+	})
+
+	// Новая оценка существующей статьи
+	valuationDateEntry := widget.NewEntry()
+	valuationDateEntry.SetText(formatDate(time.Now().Format("2006-01-02")))
+	valuationEntry := widget.NewEntry()
+	valuationEntry.SetPlaceHolder(T("Стоимость"))
+	addValuationButton := widget.NewButton(T("Записать оценку"), func() {
+		value, err := parseAmount(valuationEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверная сумма")), window)
+			return
+		}
+		for _, item := range items {
+			if item.Name == itemSelect.Selected {
+				if err := addValuation(db, item.ID, entryDate(valuationDateEntry.Text), value); err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				valuationEntry.SetText("")
+				update()
+				return
+			}
+		}
+		dialog.ShowError(errors.New(T("выберите актив или обязательство")), window)
+	})
+
+	update()
+
+	content := container.NewVBox(
+		widget.NewLabelWithStyle(T("Капитал"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		summaryContainer,
+		container.NewHBox(widget.NewLabel(T("Основная валюта")), baseSelect),
+		widget.NewSeparator(),
+		itemsContainer,
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(T("Новый счет"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(4, accountNameEntry, accountCurrencyEntry, openingEntry, addAccountButton),
+		widget.NewLabelWithStyle(T("Новый актив или обязательство"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(5, classSelect, itemNameEntry, kindSelect, itemValueEntry, addItemButton),
+		widget.NewLabelWithStyle(T("Переоценка"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		container.NewGridWithColumns(4, itemSelect, valuationDateEntry, valuationEntry, addValuationButton),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(T("История капитала"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		historyContainer,
+	)
+
+	window.SetContent(container.NewScroll(content))
+	return window
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+// useTestBaseCurrency читает основную валюту базы и возвращает прежнюю после теста
+func useTestBaseCurrency(t *testing.T, db *sql.DB) {
+	t.Helper()
+	previous := currentBaseCurrency
+	t.Cleanup(func() { currentBaseCurrency = previous })
+	useBaseCurrency(db)
+}
+
+func TestNetWorthByBaseCurrency(t *testing.T) {
+	db := openTestDB(t)
+	useTestBaseCurrency(t, db)
+	if baseCurrency() != "RUB" {
+		t.Fatalf("основная валюта новой базы %q", baseCurrency())
+	}
+	if err := saveAccount(db, Account{Name: "Доллары", Currency: "usd", OpeningBalance: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := insertTransaction(db, Transaction{Date: "2026-01-10", Type: typeIncome, Category: "Зарплата", Amount: 5000}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		base     string
+		accounts float64
+		foreign  map[string]float64
+	}{
+		{base: "RUB", accounts: 5000, foreign: map[string]float64{"USD": 100}},
+		{base: "usd", accounts: 100, foreign: map[string]float64{"RUB": 5000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.base, func(t *testing.T) {
+			if err := setBaseCurrency(db, tt.base); err != nil {
+				t.Fatal(err)
+			}
+			s, err := netWorthAt(db, "2026-12-31")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Accounts != tt.accounts || s.NetWorth != tt.accounts || !reflect.DeepEqual(s.Foreign, tt.foreign) {
+				t.Errorf("счета %v, капитал %v, другие валюты %v; ожидалось %v и %v",
+					s.Accounts, s.NetWorth, s.Foreign, tt.accounts, tt.foreign)
+			}
+			balance, err := currentBalance(db, "2026-12-31")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != tt.accounts {
+				t.Errorf("остаток для прогноза %v, ожидалось %v", balance, tt.accounts)
+			}
+		})
+	}
+}
+
+// В базе, заведенной до появления настройки, основной становится валюта большинства счетов
+func TestBaseCurrencyMigration(t *testing.T) {
+	db := openTestDB(t)
+	for _, a := range []Account{{Name: "Карта", Currency: "EUR"}, {Name: "Наличные", Currency: "eur"}} {
+		if err := saveAccount(db, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("DELETE FROM app_settings WHERE key = ?", settingBaseCurrency); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTable(db); err != nil {
+		t.Fatal(err)
+	}
+	useTestBaseCurrency(t, db)
+	if baseCurrency() != "EUR" {
+		t.Errorf("основная валюта %q, ожидалась EUR", baseCurrency())
+	}
+}
@@ -0,0 +1,581 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Payee — получатель платежа. Банки пишут одного и того же получателя по-разному
+// ("PYATEROCHKA 1234 MOSCOW", "Пятерочка"), поэтому описание транзакции сводится
+// к получателю по шаблонам.
+type Payee struct {
+	ID              int
+	Name            string
+	DefaultCategory string
+	// Patterns — по одному на строку: текст ищется в описании без учета регистра,
+	// цифр и знаков препинания, /выражение/ проверяется как регулярное выражение
+	Patterns []string
+
+	matchers []payeeMatcher
+}
+
+type payeeMatcher struct {
+	text   string // нормализованный текст; пусто для регулярного выражения
+	re     *regexp.Regexp
+	weight int // чем длиннее шаблон, тем он точнее
+}
+
+// PayeeTotal — расходы на получателя за период
+type PayeeTotal struct {
+	PayeeID     int
+	Name        string
+	Count       int
+	Total       float64
+	TopCategory string
+}
+
+// UnmatchedDescription — описание, для которого получатель не нашелся
+type UnmatchedDescription struct {
+	Normalized string
+	Example    string
+	Count      int
+}
+
+// normalizePayeeText приводит строку банка к виду для сравнения: нижний регистр,
+// без знаков препинания и без слов с цифрами (номера терминалов, карт, чеков)
+func normalizePayeeText(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	kept := words[:0]
+	for _, word := range words {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		kept = append(kept, word)
+	}
+	return strings.Join(kept, " ")
+}
+
+// compile готовит шаблоны получателя; его название тоже служит шаблоном
+func (p *Payee) compile() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New(T("не указано название получателя"))
+	}
+	p.matchers = nil
+	for _, pattern := range append([]string{p.Name}, p.Patterns...) {
+		if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			re, err := regexp.Compile("(?i)" + pattern[1:len(pattern)-1])
+			if err != nil {
+				return fmt.Errorf(T("неверное регулярное выражение: %v"), err)
+			}
+			p.matchers = append(p.matchers, payeeMatcher{re: re, weight: len(pattern)})
+			continue
+		}
+		if text := normalizePayeeText(pattern); text != "" {
+			p.matchers = append(p.matchers, payeeMatcher{text: text, weight: len(text)})
+		}
+	}
+	return nil
+}
+
+// match возвращает вес самого точного совпавшего шаблона или 0
+func (p Payee) match(description, normalized string) int {
+	best := 0
+	for _, m := range p.matchers {
+		var ok bool
+		if m.re != nil {
+			ok = m.re.MatchString(description)
+		} else {
+			ok = normalized == m.text || strings.HasPrefix(normalized, m.text+" ") ||
+				strings.Contains(" "+normalized+" ", " "+m.text+" ")
+		}
+		if ok && m.weight > best {
+			best = m.weight
+		}
+	}
+	return best
+}
+
+// matchPayee находит получателя по описанию; при нескольких совпадениях
+// побеждает самый длинный шаблон
+func matchPayee(payees []Payee, description string) (Payee, bool) {
+	normalized := normalizePayeeText(description)
+	var found Payee
+	best := 0
+	for _, p := range payees {
+		if weight := p.match(description, normalized); weight > best {
+			found, best = p, weight
+		}
+	}
+	return found, best > 0
+}
+
+func loadPayees(db *sql.DB) ([]Payee, error) {
+	rows, err := db.Query("SELECT id, name, default_category FROM payees ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payees []Payee
+	for rows.Next() {
+		var p Payee
+		if err := rows.Scan(&p.ID, &p.Name, &p.DefaultCategory); err != nil {
+			continue
+		}
+		payees = append(payees, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	patterns, err := db.Query("SELECT payee_id, pattern FROM payee_patterns ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer patterns.Close()
+	byID := make(map[int]*Payee, len(payees))
+	for i := range payees {
+		byID[payees[i].ID] = &payees[i]
+	}
+	for patterns.Next() {
+		var id int
+		var pattern string
+		if err := patterns.Scan(&id, &pattern); err != nil {
+			continue
+		}
+		if p, ok := byID[id]; ok {
+			p.Patterns = append(p.Patterns, pattern)
+		}
+	}
+	if err := patterns.Err(); err != nil {
+		return nil, err
+	}
+
+	// Получатель с ошибкой в шаблоне узнается только по названию
+	for i := range payees {
+		if payees[i].compile() != nil {
+			payees[i].Patterns = nil
+			payees[i].compile()
+		}
+	}
+	return payees, nil
+}
+
+// savePayee добавляет или обновляет получателя вместе с шаблонами и возвращает его ID
+func savePayee(db *sql.DB, p Payee) (int, error) {
+	if err := p.compile(); err != nil {
+		return 0, err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if p.ID == 0 {
+		res, err := tx.Exec("INSERT INTO payees (name, default_category) VALUES (?, ?)", p.Name, p.DefaultCategory)
+		if err != nil {
+			return 0, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		p.ID = int(id)
+	} else if _, err := tx.Exec("UPDATE payees SET name = ?, default_category = ? WHERE id = ?",
+		p.Name, p.DefaultCategory, p.ID); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM payee_patterns WHERE payee_id = ?", p.ID); err != nil {
+		return 0, err
+	}
+	for _, pattern := range p.Patterns {
+		if _, err := tx.Exec("INSERT INTO payee_patterns (payee_id, pattern) VALUES (?, ?)", p.ID, pattern); err != nil {
+			return 0, err
+		}
+	}
+	return p.ID, tx.Commit()
+}
+
+func deletePayee(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, query := range []string{
+		"DELETE FROM payee_patterns WHERE payee_id = ?",
+		"DELETE FROM payees WHERE id = ?",
+		"UPDATE transactions SET payee_id = 0 WHERE payee_id = ?",
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// assignPayees сопоставляет получателей транзакциям, у которых их еще нет, после
+// изменения шаблонов. Уже назначенный получатель не меняется. Транзакциям без
+// категории достается категория получателя по умолчанию.
+func assignPayees(db *sql.DB) (int, error) {
+	payees, err := loadPayees(db)
+	if err != nil {
+		return 0, err
+	}
+	transactions, err := loadTransactions(db, periodFilter{Kind: periodAll})
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	changed := 0
+	var recategorized [][2]Transaction
+	for _, t := range transactions {
+		if t.PayeeID != 0 {
+			continue
+		}
+		payee, ok := matchPayee(payees, t.Description)
+		if !ok {
+			continue
+		}
+		after := t
+		after.PayeeID = payee.ID
+		if t.Category == "" && len(t.Splits) == 0 {
+			after.Category = payee.DefaultCategory
+		}
+		if _, err := tx.Exec("UPDATE transactions SET payee_id = ?, category = ? WHERE id = ? AND payee_id = 0",
+			after.PayeeID, after.Category, t.ID); err != nil {
+			return 0, err
+		}
+		if after.Category != t.Category {
+			recategorized = append(recategorized, [2]Transaction{t, after})
+		}
+		changed++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	for _, pair := range recategorized {
+		relearnTransaction(db, pair[0], pair[1])
+	}
+	return changed, nil
+}
+
+// payeeTotals — расходы по получателям за период, крупные первыми.
+// Транзакции без получателя собираются в строку с PayeeID = 0.
+func payeeTotals(db *sql.DB, period periodFilter) ([]PayeeTotal, error) {
+	cond, args, err := period.condition("t.date")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`
+		SELECT t.payee_id, COALESCE(p.name, ''), COUNT(*), SUM(t.amount)
+		FROM transactions t LEFT JOIN payees p ON p.id = t.payee_id
+		WHERE t.type = ? AND `+cond+`
+		GROUP BY t.payee_id
+		ORDER BY SUM(t.amount) DESC
+	`, append([]interface{}{typeExpense}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []PayeeTotal
+	for rows.Next() {
+		var p PayeeTotal
+		if err := rows.Scan(&p.PayeeID, &p.Name, &p.Count, &p.Total); err != nil {
+			continue
+		}
+		if p.PayeeID == 0 {
+			p.Name = T("Без получателя")
+		}
+		totals = append(totals, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	// Основная категория получателя — та, на которую пришлось больше всего денег
+	cond, args, err = period.condition("date")
+	if err != nil {
+		return nil, err
+	}
+	rows, err = db.Query(`
+		SELECT payee_id, category, SUM(amount) AS total
+		FROM transaction_lines
+		WHERE type = ? AND `+cond+`
+		GROUP BY payee_id, category
+		ORDER BY total DESC
+	`, append([]interface{}{typeExpense}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	top := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var category string
+		var total float64
+		if err := rows.Scan(&id, &category, &total); err != nil {
+			continue
+		}
+		if _, ok := top[id]; !ok {
+			top[id] = category
+		}
+	}
+	for i := range totals {
+		totals[i].TopCategory = top[totals[i].PayeeID]
+	}
+	return totals, rows.Err()
+}
+
+// unmatchedDescriptions — частые описания без получателя: из них удобно заводить новых
+func unmatchedDescriptions(db *sql.DB, limit int) ([]UnmatchedDescription, error) {
+	rows, err := db.Query("SELECT description FROM transactions WHERE payee_id = 0 AND description != '' AND type != ?", typeTransfer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make(map[string]*UnmatchedDescription)
+	for rows.Next() {
+		var description string
+		if err := rows.Scan(&description); err != nil {
+			continue
+		}
+		key := normalizePayeeText(description)
+		if key == "" {
+			continue
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &UnmatchedDescription{Normalized: key, Example: description}
+			groups[key] = g
+		}
+		g.Count++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]UnmatchedDescription, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Normalized < result[j].Normalized
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// showPayeeReport выводит расходы по получателям за период в окно статистики
+func showPayeeReport(db *sql.DB, period periodFilter, target *fyne.Container) error {
+	totals, err := payeeTotals(db, period)
+	if err != nil {
+		return err
+	}
+	target.Objects = nil
+	target.Add(widget.NewLabelWithStyle(T("Расходы по получателям"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+	target.Add(widget.NewLabel(Tf("Период: %s", period.description())))
+	target.Add(widget.NewSeparator())
+	if len(totals) == 0 {
+		target.Add(widget.NewLabel(T("Нет данных для отображения")))
+		return nil
+	}
+
+	var sum float64
+	var slices []chartSlice
+	for _, p := range totals {
+		sum += p.Total
+		slices = append(slices, chartSlice{Label: p.Name, Value: p.Total})
+	}
+	target.Add(newDonutChart(slices))
+
+	target.Add(container.NewGridWithColumns(6,
+		widget.NewLabelWithStyle(T("Получатель"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Операций"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Сумма"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Средний чек"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Доля"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Категория"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	))
+	for _, p := range totals {
+		target.Add(container.NewGridWithColumns(6,
+			widget.NewLabel(p.Name),
+			widget.NewLabel(fmt.Sprint(p.Count)),
+			widget.NewLabel(money(p.Total)),
+			widget.NewLabel(money(p.Total/float64(p.Count))),
+			widget.NewLabel(fmt.Sprintf("%.1f%%", p.Total/sum*100)),
+			widget.NewLabel(p.TopCategory),
+		))
+	}
+	return nil
+}
+
+func payeesWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Получатели"))
+	restoreWindowSize(window, "payees", fyne.NewSize(1000, 700))
+
+	var payees []Payee
+	var editing Payee
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(T("Название"))
+	categoryEntry := widget.NewEntry()
+	categoryEntry.SetPlaceHolder(T("Категория по умолчанию"))
+	patternsEntry := widget.NewMultiLineEntry()
+	patternsEntry.SetPlaceHolder(T("Шаблоны, по одному на строку; /выражение/ - регулярное выражение"))
+	patternsEntry.SetMinRowsVisible(5)
+
+	fillForm := func(p Payee) {
+		editing = p
+		nameEntry.SetText(p.Name)
+		categoryEntry.SetText(p.DefaultCategory)
+		patternsEntry.SetText(strings.Join(p.Patterns, "\n"))
+	}
+
+	payeesList := widget.NewList(
+		func() int { return len(payees) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			text := payees[i].Name
+			if payees[i].DefaultCategory != "" {
+				text += " → " + payees[i].DefaultCategory
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	payeesList.OnSelected = func(i widget.ListItemID) { fillForm(payees[i]) }
+
+	unmatchedContainer := container.NewVBox()
+	update := func() {
+		var err error
+		payees, err = loadPayees(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		payeesList.UnselectAll()
+		payeesList.Refresh()
+
+		unmatched, err := unmatchedDescriptions(db, 30)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		unmatchedContainer.Objects = nil
+		for _, u := range unmatched {
+			u := u
+			unmatchedContainer.Add(container.NewBorder(nil, nil, nil,
+				widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+					payeesList.UnselectAll()
+					fillForm(Payee{Name: capitalize(u.Normalized), Patterns: []string{u.Normalized}})
+				}),
+				widget.NewLabel(fmt.Sprintf("%s (%d)", u.Example, u.Count)),
+			))
+		}
+		unmatchedContainer.Refresh()
+	}
+
+	// save сохраняет получателя и пересчитывает получателей в истории
+	save := func(p Payee) {
+		id, err := savePayee(db, p)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		p.ID = id
+		changed, err := assignPayees(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		update()
+		fillForm(p)
+		dialog.ShowInformation(T("Успех"), Tf("Обновлено транзакций: %d", changed), window)
+	}
+
+	newButton := widget.NewButtonWithIcon(T("Новый получатель"), theme.ContentAddIcon(), func() {
+		payeesList.UnselectAll()
+		fillForm(Payee{})
+	})
+	saveButton := widget.NewButtonWithIcon(T("Сохранить"), theme.DocumentSaveIcon(), func() {
+		var patterns []string
+		for _, line := range strings.Split(patternsEntry.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				patterns = append(patterns, line)
+			}
+		}
+		save(Payee{
+			ID:              editing.ID,
+			Name:            strings.TrimSpace(nameEntry.Text),
+			DefaultCategory: strings.TrimSpace(categoryEntry.Text),
+			Patterns:        patterns,
+		})
+	})
+	deleteButton := widget.NewButtonWithIcon(T("Удалить"), theme.DeleteIcon(), func() {
+		if editing.ID == 0 {
+			return
+		}
+		dialog.ShowConfirm(T("Удаление"), Tf("Удалить получателя «%s»?", editing.Name), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := deletePayee(db, editing.ID); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if _, err := assignPayees(db); err != nil {
+				dialog.ShowError(err, window)
+			}
+			update()
+			fillForm(Payee{})
+		}, window)
+	})
+
+	form := container.NewVBox(
+		widget.NewLabelWithStyle(T("Получатель"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		nameEntry,
+		categoryEntry,
+		patternsEntry,
+		container.NewHBox(newButton, saveButton, deleteButton),
+		widget.NewSeparator(),
+		widget.NewLabelWithStyle(T("Описания без получателя"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+
+	update()
+
+	right := container.NewBorder(form, nil, nil, nil, container.NewScroll(unmatchedContainer))
+	split := container.NewHSplit(payeesList, right)
+	split.SetOffset(0.3)
+	window.SetContent(split)
+	return window
+}
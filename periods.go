@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Варианты периода в окнах статистики и экспорта
+const (
+	periodAll    = "Все время"
+	periodYear   = "По годам"
+	periodMonth  = "По месяцам"
+	periodCustom = "Выбрать период"
+)
+
+var periodOptions = []string{periodAll, periodYear, periodMonth, periodCustom}
+
+var monthNames = []string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь",
+	"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"}
+
+// periodFilter — период, выбранный пользователем в periodSelect и связанных полях
+type periodFilter struct {
+	Kind  string
+	Year  string
+	Month int // 1-12, 0 - не выбран
+	Start string
+	End   string
+}
+
+// condition возвращает SQL-условие по столбцу даты и его аргументы
+func (p periodFilter) condition(column string) (string, []interface{}, error) {
+	switch p.Kind {
+	case periodYear:
+		if p.Year == "" {
+			return "", nil, errors.New(T("выберите год"))
+		}
+		return fmt.Sprintf("strftime('%%Y', %s) = ?", column), []interface{}{p.Year}, nil
+	case periodMonth:
+		if p.Year == "" || p.Month < 1 || p.Month > 12 {
+			return "", nil, errors.New(T("выберите год и месяц"))
+		}
+		return fmt.Sprintf("strftime('%%Y', %s) = ? AND strftime('%%m', %s) = ?", column, column),
+			[]interface{}{p.Year, fmt.Sprintf("%02d", p.Month)}, nil
+	case periodCustom:
+		if p.Start == "" || p.End == "" {
+			return "", nil, errors.New(T("введите начальную и конечную даты"))
+		}
+		return fmt.Sprintf("%s BETWEEN ? AND ?", column), []interface{}{p.Start, p.End}, nil
+	default:
+		return "1 = 1", nil, nil
+	}
+}
+
+// bounds возвращает первый и последний день периода; для всего времени - пустые строки
+func (p periodFilter) bounds() (string, string) {
+	switch p.Kind {
+	case periodYear:
+		return p.Year + "-01-01", p.Year + "-12-31"
+	case periodMonth:
+		year, err := strconv.Atoi(p.Year)
+		if err != nil || p.Month < 1 || p.Month > 12 {
+			return "", ""
+		}
+		start := time.Date(year, time.Month(p.Month), 1, 0, 0, 0, 0, time.Local)
+		return start.Format("2006-01-02"), start.AddDate(0, 1, -1).Format("2006-01-02")
+	case periodCustom:
+		return p.Start, p.End
+	default:
+		return "", ""
+	}
+}
+
+func (p periodFilter) description() string {
+	switch p.Kind {
+	case periodAll:
+		return T("Все время")
+	case periodYear:
+		if p.Year == "" {
+			return T("Год не выбран")
+		}
+		return Tf("Год: %s", p.Year)
+	case periodMonth:
+		if p.Year == "" || p.Month < 1 || p.Month > 12 {
+			return T("Период не выбран")
+		}
+		return fmt.Sprintf("%s %s", T(monthNames[p.Month-1]), p.Year)
+	case periodCustom:
+		if p.Start == "" || p.End == "" {
+			return T("Период не выбран")
+		}
+		return Tf("С %s по %s", p.Start, p.End)
+	default:
+		return ""
+	}
+}
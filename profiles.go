@@ -0,0 +1,313 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// databaseEnv задает файл базы, как и флаг --db
+	databaseEnv = "FINANCE_DB"
+	// appDataDirName — каталог программы внутри каталога данных XDG
+	appDataDirName = "finance-tracker"
+	defaultProfile = "default"
+	// legacyDatabasePath — где база лежала раньше: в рабочем каталоге
+	legacyDatabasePath = "./finance.db"
+)
+
+var profileNamePattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// dataDir возвращает каталог данных программы: $XDG_DATA_HOME/finance-tracker
+// или ~/.local/share/finance-tracker
+func dataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appDataDirName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf(T("не удалось определить домашний каталог: %w"), err)
+	}
+	return filepath.Join(home, ".local", "share", appDataDirName), nil
+}
+
+// profilePath — файл базы профиля; у каждого профиля своя база
+func profilePath(name string) (string, error) {
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf(T("недопустимое имя профиля %q: разрешены буквы, цифры, _ и -"), name)
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	if name == defaultProfile {
+		return filepath.Join(dir, "finance.db"), nil
+	}
+	return filepath.Join(dir, "profiles", name+".db"), nil
+}
+
+// listProfiles возвращает профиль по умолчанию и все созданные профили
+func listProfiles() ([]string, error) {
+	profiles := []string{defaultProfile}
+	dir, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "profiles"))
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".db")
+		if !e.IsDir() && name != e.Name() && profileNamePattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append(profiles, names...), nil
+}
+
+// lastProfile — профиль, открытый в прошлый раз
+func lastProfile() string {
+	dir, err := dataDir()
+	if err != nil {
+		return defaultProfile
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "profile"))
+	name := strings.TrimSpace(string(data))
+	if err != nil || !profileNamePattern.MatchString(name) {
+		return defaultProfile
+	}
+	return name
+}
+
+func saveLastProfile(name string) error {
+	dir, err := dataDir()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "profile"), []byte(name+"\n"), 0600)
+}
+
+// migrateLegacyDatabase копирует базу из рабочего каталога на новое место,
+// если там еще ничего нет. Старый файл остается нетронутым.
+func migrateLegacyDatabase(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(legacyDatabasePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// globalOptions — флаги, которые идут перед подкомандой и действуют и на окно
+type globalOptions struct {
+	dbPath  string
+	profile string
+	args    []string
+}
+
+func parseGlobalFlags(args []string, stderr io.Writer) (globalOptions, error) {
+	var o globalOptions
+	fs := newFlagSet("finance_tracker", stderr)
+	fs.StringVar(&o.dbPath, "db", "", T("файл базы данных (или переменная ")+databaseEnv+")")
+	fs.StringVar(&o.profile, "profile", "", T("профиль: отдельная база в каталоге данных"))
+	fs.Usage = func() {
+		fmt.Fprint(stderr, T(cliUsage))
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	o.args = fs.Args()
+	return o, nil
+}
+
+// fixedDatabase сообщает, что база задана явно и профили не используются
+func (o globalOptions) fixedDatabase() bool {
+	return o.dbPath != "" || os.Getenv(databaseEnv) != ""
+}
+
+// openDatabase открывает базу по флагу --db, переменной FINANCE_DB или профилю
+// (явному или открытому в прошлый раз) и приводит схему к текущей
+func (o globalOptions) openDatabase() (db *sql.DB, path, profile string, err error) {
+	path = o.dbPath
+	if path == "" {
+		path = os.Getenv(databaseEnv)
+	}
+	if path == "" {
+		profile = o.profile
+		if profile == "" {
+			profile = lastProfile()
+		}
+		if path, err = prepareProfile(profile); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	db, err = openSQLite(path)
+	if err != nil {
+		return nil, "", "", fmt.Errorf(T("не удалось подключиться к базе данных: %w"), err)
+	}
+	if err := createTable(db); err != nil {
+		db.Close()
+		return nil, "", "", err
+	}
+	useBaseCurrency(db)
+	return db, path, profile, nil
+}
+
+// openSQLite открывает базу с проверкой внешних ключей: без неё REFERENCES и
+// ON DELETE CASCADE в схеме ничего не делают. Параметр в строке подключения
+// действует на каждое соединение пула, а не только на первое.
+func openSQLite(path string) (*sql.DB, error) {
+	return sql.Open("sqlite3", path+"?_foreign_keys=on")
+}
+
+// prepareProfile создает каталог профиля и переносит старую базу в профиль по умолчанию
+func prepareProfile(profile string) (string, error) {
+	path, err := profilePath(profile)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	if profile == defaultProfile {
+		if err := migrateLegacyDatabase(path); err != nil {
+			return "", fmt.Errorf(T("не удалось перенести базу из %s: %w"), legacyDatabasePath, err)
+		}
+	}
+	return path, nil
+}
+
+// session — главное окно и открытая в нем база текущего профиля
+type session struct {
+	app     fyne.App
+	window  fyne.Window
+	options globalOptions
+	db      *sql.DB
+	path    string
+	profile string
+}
+
+// show строит главное окно для открытой базы и проверяет бюджеты и цели
+func (s *session) show() {
+	title := "Finance Tracker"
+	if s.profile != "" && s.profile != defaultProfile {
+		title += " — " + s.profile
+	}
+	s.window.SetTitle(title)
+	s.window.SetContent(mainContent(s))
+
+	sendBudgetAlerts(s.app, s.db)
+	sendGoalAlerts(s.app, s.db)
+}
+
+func (s *session) closeOtherWindows() {
+	for _, w := range s.app.Driver().AllWindows() {
+		if w != s.window {
+			w.Close()
+		}
+	}
+}
+
+// switchLanguage запоминает язык и перестраивает главное окно; остальные окна
+// закрываются, потому что их подписи уже созданы на прежнем языке
+func (s *session) switchLanguage(code string) {
+	if code == currentLanguage {
+		return
+	}
+	setLanguage(code)
+	s.app.Preferences().SetString(prefLanguage, currentLanguage)
+	s.closeOtherWindows()
+	s.show()
+}
+
+// switchProfile закрывает все окна, кроме главного, и открывает базу другого профиля
+func (s *session) switchProfile(name string) error {
+	if name == s.profile {
+		return nil
+	}
+	options := s.options
+	options.profile = name
+	db, path, profile, err := options.openDatabase()
+	if err != nil {
+		return err
+	}
+	if err := saveLastProfile(profile); err != nil {
+		db.Close()
+		return err
+	}
+
+	// Открытые окна держат старую базу
+	s.closeOtherWindows()
+	forgetClassifier(s.db)
+	forgetHistory(s.db)
+	s.db.Close()
+	s.db, s.path, s.profile = db, path, profile
+	s.show()
+	return nil
+}
+
+// profileSwitcher — выбор профиля на главном окне. Если база задана флагом
+// или переменной окружения, показывается только путь к ней.
+func profileSwitcher(s *session) fyne.CanvasObject {
+	if s.options.fixedDatabase() {
+		return widget.NewLabel(T("База: ") + s.path)
+	}
+	profiles, err := listProfiles()
+	if err != nil {
+		profiles = []string{s.profile}
+	}
+	profileSelect := widget.NewSelect(profiles, nil)
+	profileSelect.SetSelected(s.profile)
+	profileSelect.OnChanged = func(name string) {
+		if err := s.switchProfile(name); err != nil {
+			dialog.ShowError(err, s.window)
+			profileSelect.SetSelected(s.profile)
+		}
+	}
+
+	newButton := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetPlaceHolder(T("Имя профиля"))
+		dialog.ShowForm(T("Новый профиль"), T("Создать"), T("Отмена"),
+			[]*widget.FormItem{widget.NewFormItem(T("Имя"), nameEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				for _, p := range profiles {
+					if p == nameEntry.Text {
+						dialog.ShowError(fmt.Errorf(T("профиль %q уже есть"), p), s.window)
+						return
+					}
+				}
+				if err := s.switchProfile(nameEntry.Text); err != nil {
+					dialog.ShowError(err, s.window)
+				}
+			}, s.window)
+	})
+	return container.NewHBox(widget.NewLabel(T("Профиль:")), profileSelect, newButton)
+}
@@ -2,9 +2,13 @@ package main
 
 import (
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"image/color"
+	"math"
 	"os"
 	"strconv"
 	"strings"
@@ -28,63 +32,134 @@ type Transaction struct {
 	Amount      float64
 	Description string
 	Type        string
+	AccountID   int
+	// Счет зачисления для переводов между своими счетами
+	TransferAccountID int         `json:",omitempty"`
+	Splits            []SplitLine `json:",omitempty"`
+	// Теги через запятую, в основном их назначают правила
+	Tags    string `json:",omitempty"`
+	PayeeID int    `json:",omitempty"`
+	// Status — отметка сверки с банком: пусто, statusCleared или statusReconciled
+	Status string `json:",omitempty"`
+}
+
+// Типы транзакций в том виде, в котором они хранятся в базе. Коды не зависят
+// от языка интерфейса, подписи берутся из transactionTypes.
+const (
+	typeIncome   = "income"
+	typeExpense  = "expense"
+	typeTransfer = "transfer"
+)
+
+var transactionTypes = []struct {
+	Code  string
+	Label string
+}{
+	{typeIncome, "Доход"},
+	{typeExpense, "Расход"},
+	{typeTransfer, "Перевод"},
+}
+
+func typeLabel(code string) string {
+	for _, t := range transactionTypes {
+		if t.Code == code {
+			return T(t.Label)
+		}
+	}
+	return code
+}
+
+// typeCode возвращает код типа по коду или подписи на любом языке
+// (так тип приходит из формы, CSV-файла или старых версий базы)
+func typeCode(value string) string {
+	for _, t := range transactionTypes {
+		if t.Code == value || untranslate(value, []string{t.Label}) == t.Label {
+			return t.Code
+		}
+	}
+	return value
 }
 
 func main() {
-	// Инициализация базы данных
-	db, err := sql.Open("sqlite3", "./finance.db")
-	if err != nil {
-		fyne.CurrentApp().SendNotification(&fyne.Notification{
-			Title:   "Ошибка",
-			Content: "Не удалось подключиться к базе данных",
-		})
+	options, err := parseGlobalFlags(os.Args[1:], os.Stderr)
+	if err == flag.ErrHelp {
 		return
 	}
-	defer db.Close()
-	createTable(db)
+	if err != nil {
+		os.Exit(2)
+	}
+
+	// С подкомандой программа работает без окна
+	if len(options.args) > 0 {
+		os.Exit(runCLI(options, os.Stdin, os.Stdout, os.Stderr))
+	}
 
 	// Инициализация приложения
-	myApp := app.New()
-	customTheme := newCustomTheme(true) // Начинаем с темной темы
+	myApp := app.NewWithID(appID)
+	setLanguage(myApp.Preferences().StringWithFallback(prefLanguage, currentLanguage))
+	customTheme := newCustomTheme(myApp.Preferences().BoolWithFallback(prefDarkTheme, true))
 	myApp.Settings().SetTheme(customTheme)
 	myWindow := myApp.NewWindow("Finance Tracker")
-	myWindow.Resize(fyne.NewSize(800, 600))
+	restoreWindowSize(myWindow, "main", fyne.NewSize(800, 600))
+
+	// Инициализация базы данных
+	db, path, profile, err := options.openDatabase()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	s := &session{app: myApp, window: myWindow, options: options, db: db, path: path, profile: profile}
+	defer func() { s.db.Close() }()
+
+	s.addUndoShortcuts()
+	s.show()
+	myWindow.ShowAndRun()
+}
+
+// mainContent строит содержимое главного окна для базы текущего профиля
+func mainContent(s *session) fyne.CanvasObject {
+	myApp, myWindow, db := s.app, s.window, s.db
 
 	// Заголовок
-	title := widget.NewLabel("Учет доходов и расходов")
+	title := widget.NewLabel(T("Учет доходов и расходов"))
 	title.TextStyle = fyne.TextStyle{Bold: true}
 	title.Alignment = fyne.TextAlignCenter
 
 	// Переключатель темы
-	themeSwitch := widget.NewCheck("Темная тема", func(checked bool) {
+	themeSwitch := widget.NewCheck(T("Темная тема"), func(checked bool) {
 		customTheme := newCustomTheme(checked)
 		myApp.Settings().SetTheme(customTheme)
+		myApp.Preferences().SetBool(prefDarkTheme, checked)
+	})
+	themeSwitch.Checked = myApp.Preferences().BoolWithFallback(prefDarkTheme, true)
+
+	settingsButton := widget.NewButtonWithIcon(T("Настройки"), theme.SettingsIcon(), func() {
+		settingsWindow(myApp, themeSwitch.SetChecked, s.switchLanguage).Show()
 	})
-	themeSwitch.Checked = true // Начинаем с темной темы
 
 	// Кнопки для главного окна
-	addButton := widget.NewButtonWithIcon("Добавить транзакцию", theme.ContentAddIcon(), func() {
+	addButton := widget.NewButtonWithIcon(T("Добавить транзакцию"), theme.ContentAddIcon(), func() {
 		addTransactionWindow(myApp, db).Show()
 	})
 	addButtonContainer := container.NewMax(addButton)
 	addButtonContainer.Resize(fyne.NewSize(200, 60))
 	addButtonAligned := container.NewHBox(addButtonContainer, widget.NewLabel("")) // Выравнивание влево
 
-	viewButton := widget.NewButtonWithIcon("Просмотреть транзакции", theme.ViewFullScreenIcon(), func() {
+	viewButton := widget.NewButtonWithIcon(T("Просмотреть транзакции"), theme.ViewFullScreenIcon(), func() {
 		viewTransactionsWindow(myApp, db).Show()
 	})
 	viewButtonContainer := container.NewMax(viewButton)
 	viewButtonContainer.Resize(fyne.NewSize(200, 60))
 	viewButtonAligned := container.NewHBox(viewButtonContainer, widget.NewLabel(""))
 
-	fullScreenButton := widget.NewButtonWithIcon("Полноэкранный режим", theme.ViewFullScreenIcon(), func() {
+	fullScreenButton := widget.NewButtonWithIcon(T("Полноэкранный режим"), theme.ViewFullScreenIcon(), func() {
 		myWindow.SetFullScreen(!myWindow.FullScreen())
 	})
 	fullScreenButtonContainer := container.NewMax(fullScreenButton)
 	fullScreenButtonContainer.Resize(fyne.NewSize(200, 60))
 	fullScreenButtonAligned := container.NewHBox(fullScreenButtonContainer, widget.NewLabel(""))
 
-	exitButton := widget.NewButtonWithIcon("Выход", theme.LogoutIcon(), func() {
+	exitButton := widget.NewButtonWithIcon(T("Выход"), theme.LogoutIcon(), func() {
 		myApp.Quit()
 	})
 	exitButtonContainer := container.NewMax(exitButton)
@@ -92,21 +167,93 @@ func main() {
 	exitButtonAligned := container.NewHBox(exitButtonContainer, widget.NewLabel(""))
 
 	// Добавляем новые кнопки
-	statisticsButton := widget.NewButtonWithIcon("Статистика", theme.DocumentIcon(), func() {
+	statisticsButton := widget.NewButtonWithIcon(T("Статистика"), theme.DocumentIcon(), func() {
 		statisticsWindow(myApp, db).Show()
 	})
 	statisticsButtonContainer := container.NewMax(statisticsButton)
 	statisticsButtonContainer.Resize(fyne.NewSize(200, 60))
 	statisticsButtonAligned := container.NewHBox(statisticsButtonContainer, widget.NewLabel(""))
 
-	budgetButton := widget.NewButtonWithIcon("Бюджет", theme.SettingsIcon(), func() {
+	budgetButton := widget.NewButtonWithIcon(T("Бюджет"), theme.SettingsIcon(), func() {
 		budgetWindow(myApp, db).Show()
 	})
 	budgetButtonContainer := container.NewMax(budgetButton)
 	budgetButtonContainer.Resize(fyne.NewSize(200, 60))
 	budgetButtonAligned := container.NewHBox(budgetButtonContainer, widget.NewLabel(""))
 
-	exportButton := widget.NewButtonWithIcon("Экспорт данных", theme.DocumentSaveIcon(), func() {
+	forecastButton := widget.NewButtonWithIcon(T("Прогноз"), theme.HistoryIcon(), func() {
+		forecastWindow(myApp, db).Show()
+	})
+	forecastButtonContainer := container.NewMax(forecastButton)
+	forecastButtonContainer.Resize(fyne.NewSize(200, 60))
+	forecastButtonAligned := container.NewHBox(forecastButtonContainer, widget.NewLabel(""))
+
+	netWorthButton := widget.NewButtonWithIcon(T("Капитал"), theme.AccountIcon(), func() {
+		netWorthWindow(myApp, db).Show()
+	})
+	netWorthButtonContainer := container.NewMax(netWorthButton)
+	netWorthButtonContainer.Resize(fyne.NewSize(200, 60))
+	netWorthButtonAligned := container.NewHBox(netWorthButtonContainer, widget.NewLabel(""))
+
+	loansButton := widget.NewButtonWithIcon(T("Кредиты"), theme.ListIcon(), func() {
+		loansWindow(myApp, db).Show()
+	})
+	loansButtonContainer := container.NewMax(loansButton)
+	loansButtonContainer.Resize(fyne.NewSize(200, 60))
+	loansButtonAligned := container.NewHBox(loansButtonContainer, widget.NewLabel(""))
+
+	investmentsButton := widget.NewButtonWithIcon(T("Инвестиции"), theme.GridIcon(), func() {
+		portfolioWindow(myApp, db).Show()
+	})
+	investmentsButtonContainer := container.NewMax(investmentsButton)
+	investmentsButtonContainer.Resize(fyne.NewSize(200, 60))
+	investmentsButtonAligned := container.NewHBox(investmentsButtonContainer, widget.NewLabel(""))
+
+	// Прогресс целей накоплений под изображением на главном окне
+	goalsProgressContainer := container.NewVBox()
+	goalsButton := widget.NewButtonWithIcon(T("Цели"), theme.ConfirmIcon(), func() {
+		goalsWindow(myApp, db, func() { fillGoalsProgress(db, goalsProgressContainer) }).Show()
+	})
+	goalsButtonContainer := container.NewMax(goalsButton)
+	goalsButtonContainer.Resize(fyne.NewSize(200, 60))
+	goalsButtonAligned := container.NewHBox(goalsButtonContainer, widget.NewLabel(""))
+
+	reconcileButton := widget.NewButtonWithIcon(T("Сверка с банком"), theme.ConfirmIcon(), func() {
+		reconcileWindow(myApp, db).Show()
+	})
+	reconcileButtonContainer := container.NewMax(reconcileButton)
+	reconcileButtonContainer.Resize(fyne.NewSize(200, 60))
+	reconcileButtonAligned := container.NewHBox(reconcileButtonContainer, widget.NewLabel(""))
+
+	rulesButton := widget.NewButtonWithIcon(T("Правила"), theme.ListIcon(), func() {
+		rulesWindow(myApp, db).Show()
+	})
+	rulesButtonContainer := container.NewMax(rulesButton)
+	rulesButtonContainer.Resize(fyne.NewSize(200, 60))
+	rulesButtonAligned := container.NewHBox(rulesButtonContainer, widget.NewLabel(""))
+
+	payeesButton := widget.NewButtonWithIcon(T("Получатели"), theme.AccountIcon(), func() {
+		payeesWindow(myApp, db).Show()
+	})
+	payeesButtonContainer := container.NewMax(payeesButton)
+	payeesButtonContainer.Resize(fyne.NewSize(200, 60))
+	payeesButtonAligned := container.NewHBox(payeesButtonContainer, widget.NewLabel(""))
+
+	activityButton := widget.NewButtonWithIcon(T("Журнал изменений"), theme.HistoryIcon(), func() {
+		activityWindow(myApp, db).Show()
+	})
+	activityButtonContainer := container.NewMax(activityButton)
+	activityButtonContainer.Resize(fyne.NewSize(200, 60))
+	activityButtonAligned := container.NewHBox(activityButtonContainer, widget.NewLabel(""))
+
+	importButton := widget.NewButtonWithIcon(T("Импорт данных"), theme.DownloadIcon(), func() {
+		importWindow(myApp, db).Show()
+	})
+	importButtonContainer := container.NewMax(importButton)
+	importButtonContainer.Resize(fyne.NewSize(200, 60))
+	importButtonAligned := container.NewHBox(importButtonContainer, widget.NewLabel(""))
+
+	exportButton := widget.NewButtonWithIcon(T("Экспорт данных"), theme.DocumentSaveIcon(), func() {
 		exportDataWindow(myApp, db).Show()
 	})
 	exportButtonContainer := container.NewMax(exportButton)
@@ -115,11 +262,22 @@ func main() {
 
 	// Обновляем вертикальное расположение кнопок
 	buttons := container.NewVBox(
-		container.NewHBox(widget.NewLabel(""), themeSwitch), // Добавляем переключатель темы
+		container.NewHBox(widget.NewLabel(""), themeSwitch, settingsButton), // Добавляем переключатель темы
+		profileSwitcher(s),
 		addButtonAligned,
 		viewButtonAligned,
 		statisticsButtonAligned,
 		budgetButtonAligned,
+		forecastButtonAligned,
+		netWorthButtonAligned,
+		loansButtonAligned,
+		investmentsButtonAligned,
+		goalsButtonAligned,
+		reconcileButtonAligned,
+		rulesButtonAligned,
+		payeesButtonAligned,
+		activityButtonAligned,
+		importButtonAligned,
 		exportButtonAligned,
 		fullScreenButtonAligned,
 		exitButtonAligned,
@@ -131,13 +289,13 @@ func main() {
 	if err != nil {
 		// Если изображение не удалось загрузить, используем заглушку
 		fyne.CurrentApp().SendNotification(&fyne.Notification{
-			Title:   "Ошибка",
-			Content: "Не удалось загрузить изображение: " + err.Error(),
+			Title:   T("Ошибка"),
+			Content: T("Не удалось загрузить изображение: ") + err.Error(),
 		})
 		placeholderImage := canvas.NewRectangle(&color.NRGBA{R: 150, G: 150, B: 150, A: 255})
 		placeholderImage.CornerRadius = 20
 		placeholderImage.SetMinSize(fyne.NewSize(300, 300))
-		imageLabel := widget.NewLabel("Не удалось загрузить изображение")
+		imageLabel := widget.NewLabel(T("Не удалось загрузить изображение"))
 		imageContainer = container.NewCenter(
 			container.NewVBox(
 				placeholderImage,
@@ -156,12 +314,15 @@ func main() {
 	}
 
 	// Разделение окна: кнопки слева, изображение справа
-	split := container.NewHSplit(buttons, imageContainer)
+	fillGoalsProgress(db, goalsProgressContainer)
+	split := container.NewHSplit(buttons, container.NewVBox(imageContainer, goalsProgressContainer))
 	split.SetOffset(0.5) // Делим окно пополам
 
 	// Основной контейнер с заголовком и разделением
 	content := container.NewVBox(
 		container.NewCenter(title),
+		quickAddBox(myApp, myWindow, db),
+		undoControls(s),
 		split,
 	)
 
@@ -173,11 +334,11 @@ func main() {
 		content,
 	)
 
-	myWindow.SetContent(customPaddedContent)
-	myWindow.ShowAndRun()
+	return customPaddedContent
 }
 
-func createTable(db *sql.DB) {
+// createTable создает недостающие таблицы и столбцы и пересоздает представления
+func createTable(db *sql.DB) error {
 	queries := []string{
 		`CREATE TABLE IF NOT EXISTS transactions (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -191,67 +352,496 @@ func createTable(db *sql.DB) {
 			category TEXT PRIMARY KEY,
 			limit_amount REAL
 		)`,
+		`CREATE TABLE IF NOT EXISTS transaction_splits (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			transaction_id INTEGER REFERENCES transactions(id) ON DELETE CASCADE,
+			category TEXT,
+			amount REAL,
+			note TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS envelope_assignments (
+			category TEXT,
+			month TEXT,
+			amount REAL,
+			PRIMARY KEY (category, month)
+		)`,
+		`CREATE TABLE IF NOT EXISTS app_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT
+		)`,
+		// Журнал отправленных уведомлений о бюджете: один порог - одно уведомление за период
+		`CREATE TABLE IF NOT EXISTS budget_alerts (
+			category TEXT,
+			period_start TEXT,
+			threshold REAL,
+			sent_at TEXT,
+			PRIMARY KEY (category, period_start, threshold)
+		)`,
+		`CREATE TABLE IF NOT EXISTS recurring_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			description TEXT,
+			category TEXT,
+			type TEXT,
+			amount REAL,
+			frequency TEXT,
+			next_date TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS accounts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE,
+			currency TEXT,
+			opening_balance REAL
+		)`,
+		// Счет по умолчанию, к которому относятся транзакции, введенные до появления счетов
+		`INSERT OR IGNORE INTO accounts (id, name, currency, opening_balance) VALUES (1, 'Основной счет', 'RUB', 0)`,
+		`CREATE TABLE IF NOT EXISTS net_worth_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			class TEXT,
+			kind TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS net_worth_valuations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_id INTEGER REFERENCES net_worth_items(id),
+			date TEXT,
+			value REAL,
+			UNIQUE (item_id, date)
+		)`,
+		`CREATE TABLE IF NOT EXISTS loans (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			principal REAL,
+			rate REAL,
+			term_months INTEGER,
+			start_date TEXT,
+			schedule_type TEXT,
+			account_id INTEGER REFERENCES accounts(id),
+			liability_id INTEGER REFERENCES net_worth_items(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS loan_payments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			loan_id INTEGER REFERENCES loans(id),
+			date TEXT,
+			interest REAL,
+			principal REAL,
+			early BOOLEAN NOT NULL DEFAULT 0,
+			strategy TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS savings_goals (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT UNIQUE,
+			target REAL,
+			deadline TEXT,
+			account_id INTEGER REFERENCES accounts(id),
+			created TEXT
+		)`,
+		`CREATE TABLE IF NOT EXISTS goal_contributions (
+			transaction_id INTEGER PRIMARY KEY REFERENCES transactions(id) ON DELETE CASCADE,
+			goal_id INTEGER REFERENCES savings_goals(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS securities (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			ticker TEXT UNIQUE,
+			name TEXT,
+			account_id INTEGER REFERENCES accounts(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS security_trades (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			security_id INTEGER REFERENCES securities(id),
+			date TEXT,
+			kind TEXT,
+			quantity REAL,
+			price REAL,
+			fee REAL NOT NULL DEFAULT 0,
+			transaction_id INTEGER REFERENCES transactions(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS security_prices (
+			security_id INTEGER REFERENCES securities(id),
+			date TEXT,
+			price REAL,
+			PRIMARY KEY (security_id, date)
+		)`,
+		`CREATE TABLE IF NOT EXISTS goal_alerts (
+			goal_id INTEGER,
+			month TEXT,
+			sent_at TEXT,
+			PRIMARY KEY (goal_id, month)
+		)`,
+		`CREATE TABLE IF NOT EXISTS rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			priority INTEGER NOT NULL DEFAULT 0,
+			enabled INTEGER NOT NULL DEFAULT 1,
+			description_contains TEXT NOT NULL DEFAULT '',
+			description_regex TEXT NOT NULL DEFAULT '',
+			min_amount REAL NOT NULL DEFAULT 0,
+			max_amount REAL NOT NULL DEFAULT 0,
+			account_id INTEGER NOT NULL DEFAULT 0,
+			type TEXT NOT NULL DEFAULT '',
+			set_category TEXT NOT NULL DEFAULT '',
+			set_tags TEXT NOT NULL DEFAULT '',
+			set_description TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS payees (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			default_category TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS payee_patterns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			payee_id INTEGER NOT NULL,
+			pattern TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS reconciliations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			account_id INTEGER NOT NULL,
+			statement_date TEXT NOT NULL,
+			statement_balance REAL NOT NULL,
+			reconciled_at TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS category_aliases (
+			alias TEXT PRIMARY KEY,
+			category TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			table_name TEXT NOT NULL,
+			row_key TEXT NOT NULL,
+			action TEXT NOT NULL,
+			before_json TEXT,
+			after_json TEXT,
+			changed_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+		)`,
+		`CREATE INDEX IF NOT EXISTS audit_log_row ON audit_log (table_name, row_key)`,
+	}
+
+	// Новые столбцы существующих таблиц: SQLite не поддерживает ADD COLUMN IF NOT EXISTS
+	columns := []struct {
+		table, column, definition string
+	}{
+		{"budget_limits", "period", "TEXT NOT NULL DEFAULT 'monthly'"},
+		{"budget_limits", "start_date", "TEXT NOT NULL DEFAULT ''"},
+		{"budget_limits", "end_date", "TEXT NOT NULL DEFAULT ''"},
+		{"budget_limits", "rollover", "TEXT NOT NULL DEFAULT 'none'"},
+		{"transactions", "account_id", "INTEGER NOT NULL DEFAULT 1"},
+		{"transactions", "transfer_account_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"transactions", "tags", "TEXT NOT NULL DEFAULT ''"},
+		{"transactions", "payee_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"transactions", "status", "TEXT NOT NULL DEFAULT ''"},
+		{"loan_payments", "interest_transaction_id", "INTEGER NOT NULL DEFAULT 0"},
+		{"loan_payments", "principal_transaction_id", "INTEGER NOT NULL DEFAULT 0"},
+	}
+
+	// Раньше тип хранился русской подписью; переводим такие записи в коды
+	migrations := []string{
+		`UPDATE transactions SET type = CASE type
+			WHEN 'Доход' THEN 'income' WHEN 'Расход' THEN 'expense' WHEN 'Перевод' THEN 'transfer' ELSE type END`,
+		`UPDATE recurring_items SET type = CASE type
+			WHEN 'Доход' THEN 'income' WHEN 'Расход' THEN 'expense' ELSE type END`,
+		// Основная валюта базы - та, в которой ведется большинство счетов
+		`INSERT OR IGNORE INTO app_settings (key, value)
+			SELECT 'base_currency', COALESCE((
+				SELECT UPPER(currency) FROM accounts WHERE COALESCE(currency, '') <> ''
+				GROUP BY UPPER(currency) ORDER BY COUNT(*) DESC, MIN(id) LIMIT 1
+			), 'RUB')`,
+	}
+
+	// Представления пересоздаются при каждом запуске, чтобы следовать схеме
+	views := []string{
+		// Разделенная транзакция дает по строке на каждую часть, обычная - одну строку
+		`DROP VIEW IF EXISTS transaction_lines`,
+		`CREATE VIEW transaction_lines AS
+			SELECT t.id AS transaction_id, t.date, t.type, s.category, s.amount, s.note, t.description, t.account_id, t.payee_id
+			FROM transactions t
+			JOIN transaction_splits s ON s.transaction_id = t.id
+			UNION ALL
+			SELECT t.id, t.date, t.type, t.category, t.amount, '', t.description, t.account_id, t.payee_id
+			FROM transactions t
+			WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)`,
 	}
 
 	for _, query := range queries {
-		_, err := db.Exec(query)
-		if err != nil {
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   "Ошибка",
-				Content: "Не удалось создать таблицу: " + err.Error(),
-			})
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf(T("не удалось создать таблицу: %w"), err)
 		}
 	}
+
+	for _, c := range columns {
+		if err := addColumnIfMissing(db, c.table, c.column, c.definition); err != nil {
+			return fmt.Errorf(T("не удалось обновить таблицу: %w"), err)
+		}
+	}
+
+	for _, query := range migrations {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf(T("не удалось обновить таблицу: %w"), err)
+		}
+	}
+
+	for _, query := range views {
+		if _, err := db.Exec(query); err != nil {
+			return fmt.Errorf(T("не удалось создать представление: %w"), err)
+		}
+	}
+
+	// Триггеры журнала создаются последними, когда все столбцы уже на месте
+	if err := createAuditTriggers(db); err != nil {
+		return fmt.Errorf(T("не удалось создать журнал изменений: %w"), err)
+	}
+	return nil
+}
+
+func addColumnIfMissing(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
 }
 
 func addTransactionWindow(a fyne.App, db *sql.DB) fyne.Window {
-	window := a.NewWindow("Добавить транзакцию")
-	window.Resize(fyne.NewSize(600, 400))
+	return transactionWindow(a, db, Transaction{}, nil)
+}
+
+// editTransactionWindow открывает форму с сохраненной транзакцией; onSaved
+// вызывается после сохранения изменений
+func editTransactionWindow(a fyne.App, db *sql.DB, t Transaction, onSaved func()) fyne.Window {
+	return transactionWindow(a, db, t, onSaved)
+}
 
-	typeSelect := widget.NewSelect([]string{"Доход", "Расход"}, nil)
+// transactionWindow — форма новой транзакции или, если у existing есть ID, изменения сохраненной
+func transactionWindow(a fyne.App, db *sql.DB, existing Transaction, onSaved func()) fyne.Window {
+	editing := existing.ID != 0
+	title := T("Добавить транзакцию")
+	if editing {
+		title = T("Изменить транзакцию")
+	}
+	window := a.NewWindow(title)
+	restoreWindowSize(window, "add_transaction", fyne.NewSize(600, 450))
+
+	// Тип обязателен; заранее выбирается, только если он задан в настройках
+	typeSelect := widget.NewSelect([]string{typeLabel(typeIncome), typeLabel(typeExpense)}, nil)
+	typeSelect.PlaceHolder = T("Выберите тип")
+	if preferences().String(prefDefaultType) != "" {
+		typeSelect.SetSelected(typeLabel(prefChoice(prefDefaultType, []string{typeIncome, typeExpense}, typeExpense)))
+	}
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+	accountSelect := widget.NewSelect(accountNames(accounts), nil)
+	if len(accounts) > 0 {
+		accountSelect.SetSelected(accounts[0].Name)
+	}
 	categoryEntry := widget.NewEntry()
-	categoryEntry.SetPlaceHolder("Категория")
+	categoryEntry.SetPlaceHolder(T("Категория"))
 	amountEntry := widget.NewEntry()
-	amountEntry.SetPlaceHolder("Сумма")
+	amountEntry.SetPlaceHolder(T("Сумма"))
 	descriptionEntry := widget.NewEntry()
-	descriptionEntry.SetPlaceHolder("Описание")
-	dateEntry := widget.NewEntry()
-	dateEntry.SetPlaceHolder("Дата (YYYY-MM-DD)")
+	descriptionEntry.SetPlaceHolder(T("Описание"))
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder(T("Теги через запятую"))
+	dateField := newDateField(window, "Дата")
+	dateField.SetDate(time.Now().Format("2006-01-02"))
+	markup, err := loadAutoMarkup(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
 
-	saveButton := widget.NewButton("Сохранить", func() {
-		amount, err := strconv.ParseFloat(amountEntry.Text, 64)
-		if err != nil || amount <= 0 {
-			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   "Ошибка",
-				Content: "Неверная сумма",
-			})
+	// Пока пользователь не ввел категорию сам, её подставляют правила, а под полем
+	// показываются категории, на которые транзакция похожа по истории
+	suggested := ""
+	suggestionsBox := container.NewHBox()
+	suggest := func() {
+		amount, _ := parseAmount(amountEntry.Text)
+		account, _ := accountByName(accounts, accountSelect.Selected)
+		t := Transaction{
+			Amount: amount, Description: descriptionEntry.Text, Type: typeCode(typeSelect.Selected), AccountID: account.ID,
+		}
+
+		suggestionsBox.Objects = nil
+		if descriptionEntry.Text != "" {
+			for _, s := range suggestCategories(db, t, 3) {
+				category := s.Category
+				suggestionsBox.Add(widget.NewButton(describeSuggestion(s), func() { categoryEntry.SetText(category) }))
+			}
+		}
+		if len(suggestionsBox.Objects) > 0 {
+			suggestionsBox.Objects = append([]fyne.CanvasObject{widget.NewLabel(T("Похоже на:"))}, suggestionsBox.Objects...)
+		}
+		suggestionsBox.Refresh()
+
+		// У сохраненной транзакции категорию уже выбрали, подсказки только показываем
+		if editing || (categoryEntry.Text != "" && categoryEntry.Text != suggested) {
 			return
 		}
-		if dateEntry.Text == "" {
-			dateEntry.Text = time.Now().Format("2006-01-02")
+		suggested = markup.suggestCategory(t)
+		if suggested != "" || categoryEntry.Text != "" {
+			categoryEntry.SetText(suggested)
 		}
-		query := `INSERT INTO transactions (date, category, amount, description, type) VALUES (?, ?, ?, ?, ?)`
-		_, err = db.Exec(query, dateEntry.Text, categoryEntry.Text, amount, descriptionEntry.Text, typeSelect.Selected)
+	}
+
+	saveButton := widget.NewButton(T("Сохранить"), nil)
+	validator := newFormValidator(saveButton)
+
+	// Поле суммы подключаем к проверке до редактора частей: он дополняет его OnChanged
+	typeField, typeRow := validator.add(typeSelect, func() error {
+		if typeSelect.Selected == "" {
+			return errors.New(T("выберите тип"))
+		}
+		return nil
+	})
+	typeSelect.OnChanged = func(string) {
+		validator.changed(typeField)
+		suggest()
+	}
+	accountField, accountRow := validator.add(accountSelect, func() error {
+		if _, ok := accountByName(accounts, accountSelect.Selected); !ok {
+			return errors.New(T("выберите счет"))
+		}
+		return nil
+	})
+	accountSelect.OnChanged = func(string) {
+		validator.changed(accountField)
+		suggest()
+	}
+	amountField, amountRow := validator.add(amountEntry, positiveAmount(amountEntry))
+	amountEntry.OnChanged = func(string) {
+		validator.changed(amountField)
+		suggest()
+	}
+
+	splits := newSplitEditor(amountEntry, categoryEntry)
+	categoryField, categoryRow := validator.add(categoryEntry, func() error {
+		if len(splits.rows) == 0 && categoryEntry.Text == "" {
+			return errors.New(T("не указана категория"))
+		}
+		return nil
+	})
+	categoryEntry.OnChanged = func(string) { validator.changed(categoryField) }
+	splitsField, splitsRow := validator.add(splits.content, func() error {
+		lines, err := splits.Lines()
+		if err != nil || len(lines) == 0 {
+			return err
+		}
+		amount, _ := parseAmount(amountEntry.Text)
+		return validateSplits(amount, lines)
+	})
+	splits.OnChanged = func() {
+		if len(splits.rows) > 0 {
+			validator.changed(splitsField)
+		} else {
+			validator.validate()
+		}
+	}
+	dateFieldCheck, dateRow := validator.add(dateField.content, func() error {
+		_, err := dateField.Date()
+		return err
+	})
+	dateField.entry.OnChanged = func(string) { validator.changed(dateFieldCheck) }
+
+	descriptionEntry.OnChanged = func(string) { suggest() }
+
+	saveButton.OnTapped = func() {
+		if !validator.validate() {
+			return
+		}
+		amount, _ := parseAmount(amountEntry.Text)
+		lines, _ := splits.Lines()
+		date, _ := dateField.Date()
+		account, _ := accountByName(accounts, accountSelect.Selected)
+		t := Transaction{
+			Date:        date,
+			Category:    categoryEntry.Text,
+			Amount:      amount,
+			Description: descriptionEntry.Text,
+			Type:        typeCode(typeSelect.Selected),
+			AccountID:   account.ID,
+			Splits:      lines,
+			Tags:        mergeTags("", tagsEntry.Text),
+		}
+		if editing {
+			// Правила уже отработали при вводе; заново ищем только получателя по описанию,
+			// а если описание ни с кем не совпало, остается прежний, в том числе выбранный вручную
+			t.ID, t.Status, t.PayeeID = existing.ID, existing.Status, existing.PayeeID
+			if payee, ok := matchPayee(markup.payees, t.Description); ok {
+				t.PayeeID = payee.ID
+			}
+			if err := runCommand(db, &editTransactionCommand{After: t}); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if onSaved != nil {
+				onSaved()
+			}
+			sendBudgetAlerts(a, db, transactionCategories(existing, t)...)
+			window.Close()
+			return
+		}
+		t = markup.apply(t)
+		err := runCommand(db, &addTransactionCommand{Transaction: t})
 		if err != nil {
 			fyne.CurrentApp().SendNotification(&fyne.Notification{
-				Title:   "Ошибка",
-				Content: "Не удалось сохранить транзакцию",
+				Title:   T("Ошибка"),
+				Content: T("Не удалось сохранить транзакцию"),
 			})
 			return
 		}
 		fyne.CurrentApp().SendNotification(&fyne.Notification{
-			Title:   "Успех",
-			Content: "Транзакция сохранена",
+			Title:   T("Успех"),
+			Content: T("Транзакция сохранена"),
 		})
+
+		// Проверяем бюджеты всех категорий, затронутых транзакцией
+		sendBudgetAlerts(a, db, transactionCategories(t)...)
 		window.Close()
-	})
+	}
+
+	if editing {
+		typeSelect.SetSelected(typeLabel(existing.Type))
+		for _, acc := range accounts {
+			if acc.ID == existing.AccountID {
+				accountSelect.SetSelected(acc.Name)
+			}
+		}
+		categoryEntry.SetText(existing.Category)
+		amountEntry.SetText(formatNumber(existing.Amount))
+		descriptionEntry.SetText(existing.Description)
+		tagsEntry.SetText(existing.Tags)
+		dateField.SetDate(existing.Date)
+		splits.SetLines(existing.Splits)
+	}
+	validator.validate()
 
 	content := container.NewVBox(
-		typeSelect,
-		categoryEntry,
-		amountEntry,
+		typeRow,
+		accountRow,
+		categoryRow,
+		suggestionsBox,
+		amountRow,
+		splitsRow,
 		descriptionEntry,
-		dateEntry,
+		tagsEntry,
+		dateRow,
 		saveButton,
 	)
 	window.SetContent(content)
@@ -259,49 +849,150 @@ func addTransactionWindow(a fyne.App, db *sql.DB) fyne.Window {
 }
 
 func viewTransactionsWindow(a fyne.App, db *sql.DB) fyne.Window {
-	window := a.NewWindow("Просмотр транзакций")
-	window.Resize(fyne.NewSize(1000, 600))
+	window := a.NewWindow(T("Просмотр транзакций"))
+	restoreWindowSize(window, "transactions", fyne.NewSize(1000, 600))
 
-	rows, err := db.Query("SELECT id, date, type, category, amount, description FROM transactions")
+	accounts, err := loadAccounts(db)
 	if err != nil {
-		fyne.CurrentApp().SendNotification(&fyne.Notification{
-			Title:   "Ошибка",
-			Content: "Не удалось загрузить транзакции",
-		})
-		return window
+		dialog.ShowError(err, window)
 	}
-	defer rows.Close()
-
-	var transactions []Transaction
-	for rows.Next() {
-		var t Transaction
-		if err := rows.Scan(&t.ID, &t.Date, &t.Type, &t.Category, &t.Amount, &t.Description); err != nil {
-			continue
-		}
-		transactions = append(transactions, t)
+	accountNamesByID := map[int]string{0: T(portfolioAccountName)}
+	currencyByID := map[int]string{0: baseCurrency()}
+	for _, acc := range accounts {
+		accountNamesByID[acc.ID] = acc.Name
+		currencyByID[acc.ID] = acc.Currency
 	}
 
+	var transactions []Transaction
 	list := widget.NewList(
 		func() int { return len(transactions) },
 		func() fyne.CanvasObject { return widget.NewLabel("template") },
 		func(i widget.ListItemID, o fyne.CanvasObject) {
 			t := transactions[i]
-			o.(*widget.Label).SetText(fmt.Sprintf("%s | %s | %s | %.2f | %s", t.Date, t.Type, t.Category, t.Amount, t.Description))
+			currency := currencyByID[t.AccountID]
+			if t.AccountID == 0 {
+				currency = currencyByID[t.TransferAccountID]
+			}
+			category := t.Category
+			if len(t.Splits) > 0 {
+				// Для разделенной транзакции перечисляем части вместо одной категории
+				parts := make([]string, 0, len(t.Splits))
+				for _, line := range t.Splits {
+					parts = append(parts, fmt.Sprintf("%s %s", line.Category, formatMoney(line.Amount, currency)))
+				}
+				category = strings.Join(parts, ", ")
+			}
+			account := accountNamesByID[t.AccountID]
+			if t.Type == typeTransfer {
+				account += " → " + accountNamesByID[t.TransferAccountID]
+			}
+			text := fmt.Sprintf("%s | %s | %s | %s | %s | %s",
+				formatDate(t.Date), account, typeLabel(t.Type), category, formatMoney(t.Amount, currency), t.Description)
+			if t.Tags != "" {
+				text += " | #" + strings.Join(splitTags(t.Tags), " #")
+			}
+			if t.Status != statusUncleared {
+				text += " | " + statusLabel(t.Status)
+			}
+			o.(*widget.Label).SetText(text)
 		},
 	)
 
+	reload := func() {
+		var err error
+		transactions, err = loadTransactions(db, periodFilter{Kind: periodAll})
+		if err != nil {
+			fyne.CurrentApp().SendNotification(&fyne.Notification{
+				Title:   T("Ошибка"),
+				Content: T("Не удалось загрузить транзакции"),
+			})
+		}
+		list.UnselectAll()
+		list.Refresh()
+	}
+
+	// По выбору транзакции показываем, что с ней можно сделать
+	list.OnSelected = func(i widget.ListItemID) {
+		t := transactions[i]
+		var actions *dialog.CustomDialog
+		run := func(action func() error) func() {
+			return func() {
+				actions.Hide()
+				if err := action(); err != nil {
+					dialog.ShowError(err, window)
+				}
+				reload()
+			}
+		}
+
+		buttons := container.NewVBox()
+		if t.Status == statusReconciled {
+			buttons.Add(widget.NewButtonWithIcon(T("Снять сверку"), theme.WarningIcon(), func() {
+				actions.Hide()
+				dialog.ShowConfirm(T("Снять сверку"), T("Транзакция уже сверена с выпиской. Снять отметку и разрешить изменения?"), func(ok bool) {
+					if ok {
+						if err := unlockTransaction(db, t.ID); err != nil {
+							dialog.ShowError(err, window)
+						}
+					}
+					reload()
+				}, window)
+			}))
+		} else {
+			buttons.Add(widget.NewButtonWithIcon(T("Изменить"), theme.DocumentCreateIcon(), func() {
+				actions.Hide()
+				list.UnselectAll()
+				if t.Type == typeTransfer {
+					dialog.ShowError(errors.New(T("переводы не изменяются в этой форме: удалите перевод и создайте заново")), window)
+					return
+				}
+				editTransactionWindow(a, db, t, reload).Show()
+			}))
+			buttons.Add(widget.NewButtonWithIcon(T("Удалить"), theme.DeleteIcon(), func() {
+				actions.Hide()
+				dialog.ShowConfirm(T("Удаление"), T("Удалить транзакцию?"), func(ok bool) {
+					if ok {
+						if err := runCommand(db, &deleteTransactionCommand{ID: t.ID}); err != nil {
+							dialog.ShowError(err, window)
+						}
+					}
+					reload()
+				}, window)
+			}))
+			if t.Status == statusCleared {
+				buttons.Add(widget.NewButton(T("Снять отметку проведения"), run(func() error {
+					return setTransactionStatus(db, []int{t.ID}, statusUncleared)
+				})))
+			} else {
+				buttons.Add(widget.NewButton(T("Отметить проведенной"), run(func() error {
+					return setTransactionStatus(db, []int{t.ID}, statusCleared)
+				})))
+			}
+		}
+		buttons.Add(widget.NewButtonWithIcon(T("История"), theme.HistoryIcon(), func() {
+			actions.Hide()
+			transactionHistoryWindow(a, db, t.ID).Show()
+		}))
+
+		actions = dialog.NewCustom(fmt.Sprintf("%s, %s", formatDate(t.Date), money(t.Amount)), T("Закрыть"), buttons, window)
+		actions.SetOnClosed(func() { list.UnselectAll() })
+		actions.Show()
+	}
+
+	reload()
 	scroll := container.NewScroll(list)
 	window.SetContent(scroll)
 	return window
 }
 
 func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
-	window := a.NewWindow("Статистика")
-	window.Resize(fyne.NewSize(1000, 800))
+	window := a.NewWindow(T("Статистика"))
+	restoreWindowSize(window, "statistics", fyne.NewSize(1000, 800))
 
 	// Элементы управления
-	periodSelect := widget.NewSelect([]string{"Все время", "По годам", "По месяцам", "Выбрать период"}, nil)
-	periodSelect.SetSelected("Все время")
+	periodSelect := widget.NewSelect(translateAll(periodOptions), nil)
+	periodSelect.SetSelected(T(prefChoice(prefStatsPeriod, periodOptions, periodAll)))
+	periodKind := func() string { return untranslate(periodSelect.Selected, periodOptions) }
 
 	// Получаем список годов из базы данных
 	var years []string
@@ -323,169 +1014,133 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 	if len(years) > 0 {
 		yearSelect.SetSelected(years[0])
 	}
-	
-	monthSelect := widget.NewSelect([]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", 
-		"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"}, nil)
-	
+
+	monthSelect := widget.NewSelect(translateAll(monthNames), nil)
+
 	startDateEntry := widget.NewEntry()
-	startDateEntry.SetPlaceHolder("Начальная дата (YYYY-MM-DD)")
+	startDateEntry.SetPlaceHolder(datePlaceholder("Начальная дата"))
 	endDateEntry := widget.NewEntry()
-	endDateEntry.SetPlaceHolder("Конечная дата (YYYY-MM-DD)")
-	
-	refreshButton := widget.NewButton("Обновить", nil)
-	
+	endDateEntry.SetPlaceHolder(datePlaceholder("Конечная дата"))
+
+	refreshButton := widget.NewButton(T("Обновить"), nil)
+
+	// Режим сравнения: выбранный период сопоставляется с другим
+	modeSelect := widget.NewSelect(translateAll([]string{statsModeOverview, statsModeCompare, statsModePayees}), nil)
+	modeSelect.SetSelected(T(statsModeOverview))
+	compareSelect := widget.NewSelect(translateAll(compareOptions), nil)
+	compareSelect.SetSelected(T(compareWithPrevious))
+	compareStartEntry := widget.NewEntry()
+	compareStartEntry.SetPlaceHolder(datePlaceholder("Начальная дата"))
+	compareEndEntry := widget.NewEntry()
+	compareEndEntry.SetPlaceHolder(datePlaceholder("Конечная дата"))
+	compareCustomContainer := container.NewGridWithColumns(2, compareStartEntry, compareEndEntry)
+	compareCustomContainer.Hide()
+	compareContainer := container.NewHBox(widget.NewLabel(T("Сравнить с:")), compareSelect, compareCustomContainer)
+	compareContainer.Hide()
+
 	// Контейнеры
 	filterContainer := container.NewVBox()
 	statsContainer := container.NewVBox()
 	scrollContainer := container.NewScroll(statsContainer)
 	scrollContainer.SetMinSize(fyne.NewSize(950, 600))
 
-	// Функция для получения описания периода
-	getPeriodDescription := func() string {
-		switch periodSelect.Selected {
-		case "Все время":
-			return "Все время"
-		case "По годам":
-			if yearSelect.Selected == "" {
-				return "Год не выбран"
-			}
-			return fmt.Sprintf("Год: %s", yearSelect.Selected)
-		case "По месяцам":
-			if yearSelect.Selected == "" || monthSelect.Selected == "" {
-				return "Период не выбран"
-			}
-			return fmt.Sprintf("%s %s", monthSelect.Selected, yearSelect.Selected)
-		case "Выбрать период":
-			if startDateEntry.Text == "" || endDateEntry.Text == "" {
-				return "Период не выбран"
-			}
-			return fmt.Sprintf("С %s по %s", startDateEntry.Text, endDateEntry.Text)
-		default:
-			return ""
+	// Период, выбранный в элементах управления
+	selectedPeriod := func() periodFilter {
+		return periodFilter{
+			Kind:  periodKind(),
+			Year:  yearSelect.Selected,
+			Month: monthSelect.SelectedIndex() + 1,
+			Start: entryDate(startDateEntry.Text),
+			End:   entryDate(endDateEntry.Text),
 		}
 	}
 
 	// Функция обновления статистики
 	updateStats := func() {
-		var query string
-		var args []interface{}
-		
-		switch periodSelect.Selected {
-		case "Все время":
-			query = `
-				SELECT 
-					type, 
-					category, 
-					SUM(amount) as total
-				FROM transactions
-				GROUP BY type, category
-				ORDER BY type, total DESC
-			`
-			
-		case "По годам":
-			if yearSelect.Selected == "" {
+		period := selectedPeriod()
+		if _, _, err := period.condition("date"); err != nil {
+			statsContainer.Objects = nil
+			statsContainer.Add(widget.NewLabel(err.Error()))
+			statsContainer.Refresh()
+			return
+		}
+
+		if modeSelect.Selected == T(statsModePayees) {
+			if err := showPayeeReport(db, period, statsContainer); err != nil {
 				statsContainer.Objects = nil
-				statsContainer.Add(widget.NewLabel("Выберите год"))
-				statsContainer.Refresh()
-				return
+				statsContainer.Add(widget.NewLabel(err.Error()))
 			}
-			query = `
-				SELECT 
-					type, 
-					category, 
-					SUM(amount) as total
-				FROM transactions
-				WHERE strftime('%Y', date) = ?
-				GROUP BY type, category
-				ORDER BY type, total DESC
-			`
-			args = append(args, yearSelect.Selected)
-			
-		case "По месяцам":
-			if yearSelect.Selected == "" || monthSelect.Selected == "" {
-				statsContainer.Objects = nil
-				statsContainer.Add(widget.NewLabel("Выберите год и месяц"))
-				statsContainer.Refresh()
-				return
+			statsContainer.Refresh()
+			return
+		}
+
+		if modeSelect.Selected == T(statsModeCompare) {
+			statsContainer.Objects = nil
+			var other periodFilter
+			var err error
+			switch untranslate(compareSelect.Selected, compareOptions) {
+			case compareWithYearAgo:
+				other, err = period.yearAgo()
+			case compareWithCustom:
+				other = periodFilter{Kind: periodCustom, Start: entryDate(compareStartEntry.Text), End: entryDate(compareEndEntry.Text)}
+			default:
+				other, err = period.previous()
 			}
-			monthNum := fmt.Sprintf("%02d", monthSelect.SelectedIndex()+1)
-			query = `
-				SELECT 
-					type, 
-					category, 
-					SUM(amount) as total
-				FROM transactions
-				WHERE strftime('%Y', date) = ? 
-				AND strftime('%m', date) = ?
-				GROUP BY type, category
-				ORDER BY type, total DESC
-			`
-			args = append(args, yearSelect.Selected, monthNum)
-			
-		case "Выбрать период":
-			if startDateEntry.Text == "" || endDateEntry.Text == "" {
+			if err == nil {
+				err = showComparison(db, other, period, statsContainer)
+			}
+			if err != nil {
 				statsContainer.Objects = nil
-				statsContainer.Add(widget.NewLabel("Введите начальную и конечную даты"))
-				statsContainer.Refresh()
-				return
+				statsContainer.Add(widget.NewLabel(err.Error()))
 			}
-			query = `
-				SELECT 
-					type, 
-					category, 
-					SUM(amount) as total
-				FROM transactions
-				WHERE date BETWEEN ? AND ?
-				GROUP BY type, category
-				ORDER BY type, total DESC
-			`
-			args = append(args, startDateEntry.Text, endDateEntry.Text)
+			statsContainer.Refresh()
+			return
 		}
-		
-		// Выполняем запрос
-		rows, err := db.Query(query, args...)
+
+		// Суммы те же, что у команды stats: только по счетам в основной валюте
+		report, err := buildStatsReport(db, period)
 		if err != nil {
 			dialog.ShowError(err, window)
 			return
 		}
-		defer rows.Close()
-		
-		var stats []struct {
-			Type     string
-			Category string
-			Total    float64
-		}
-		
-		var totalIncome, totalExpense float64
-		
-		for rows.Next() {
-			var stat struct {
-				Type     string
-				Category string
-				Total    float64
-			}
-			if err := rows.Scan(&stat.Type, &stat.Category, &stat.Total); err != nil {
-				continue
-			}
-			stats = append(stats, stat)
-			
-			if stat.Type == "Доход" {
-				totalIncome += stat.Total
-			} else {
-				totalExpense += stat.Total
+		stats := report.Categories
+		totalIncome, totalExpense := report.Income, report.Expense
+		var expenseSlices []chartSlice
+		for _, stat := range stats {
+			if stat.Type == typeExpense {
+				expenseSlices = append(expenseSlices, chartSlice{Label: stat.Category, Value: stat.Total})
 			}
 		}
-		
+
 		// Очищаем контейнер
 		statsContainer.Objects = nil
 		
 		// Добавляем общую информацию
-		statsContainer.Add(widget.NewLabelWithStyle("Статистика", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
-		statsContainer.Add(widget.NewLabel(fmt.Sprintf("Период: %s", getPeriodDescription())))
+		statsContainer.Add(widget.NewLabelWithStyle(T("Статистика"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		statsContainer.Add(widget.NewLabel(Tf("Период: %s", period.description())))
 		statsContainer.Add(widget.NewSeparator())
-		statsContainer.Add(widget.NewLabel(fmt.Sprintf("Общий доход: %.2f ₽", totalIncome)))
-		statsContainer.Add(widget.NewLabel(fmt.Sprintf("Общий расход: %.2f ₽", totalExpense)))
-		statsContainer.Add(widget.NewLabel(fmt.Sprintf("Баланс: %.2f ₽", totalIncome-totalExpense)))
+		statsContainer.Add(widget.NewLabel(Tf("Общий доход: %s", money(totalIncome))))
+		statsContainer.Add(widget.NewLabel(Tf("Общий расход: %s", money(totalExpense))))
+		statsContainer.Add(widget.NewLabel(Tf("Баланс: %s", money(totalIncome-totalExpense))))
+		statsContainer.Add(widget.NewSeparator())
+
+		// Графики строятся по тому же периоду, что и таблица
+		months, err := loadMonthTotals(db, period)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		points, err := loadBalancePoints(db, period)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		statsContainer.Add(widget.NewLabelWithStyle(T("Расходы по категориям"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		statsContainer.Add(newDonutChart(expenseSlices))
+		statsContainer.Add(widget.NewLabelWithStyle(T("Доходы и расходы по месяцам"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		statsContainer.Add(newIncomeExpenseChart(months))
+		statsContainer.Add(widget.NewLabelWithStyle(T("Накопленный баланс"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}))
+		statsContainer.Add(newBalanceChart(points))
 		statsContainer.Add(widget.NewSeparator())
 		
 		// Создаем таблицу статистики
@@ -501,13 +1156,13 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 						// Заголовки
 						switch i.Col {
 						case 0:
-							label.SetText("Тип")
+							label.SetText(T("Тип"))
 							label.TextStyle = fyne.TextStyle{Bold: true}
 						case 1:
-							label.SetText("Категория")
+							label.SetText(T("Категория"))
 							label.TextStyle = fyne.TextStyle{Bold: true}
 						case 2:
-							label.SetText("Сумма")
+							label.SetText(T("Сумма"))
 							label.TextStyle = fyne.TextStyle{Bold: true}
 						}
 					} else {
@@ -515,11 +1170,11 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 						stat := stats[i.Row-1]
 						switch i.Col {
 						case 0:
-							label.SetText(stat.Type)
+							label.SetText(typeLabel(stat.Type))
 						case 1:
 							label.SetText(stat.Category)
 						case 2:
-							label.SetText(fmt.Sprintf("%.2f ₽", stat.Total))
+							label.SetText(money(stat.Total))
 						}
 					}
 				},
@@ -535,7 +1190,7 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 			tableScroll.SetMinSize(fyne.NewSize(900, 400))
 			statsContainer.Add(tableScroll)
 		} else {
-			statsContainer.Add(widget.NewLabel("Нет данных для отображения"))
+			statsContainer.Add(widget.NewLabel(T("Нет данных для отображения")))
 		}
 		
 		statsContainer.Refresh()
@@ -543,13 +1198,13 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 
 	// Устанавливаем обработчики событий
 	yearSelect.OnChanged = func(selected string) {
-		if periodSelect.Selected == "По годам" || periodSelect.Selected == "По месяцам" {
+		if periodKind() == periodYear || periodKind() == periodMonth {
 			updateStats()
 		}
 	}
 	
 	monthSelect.OnChanged = func(selected string) {
-		if periodSelect.Selected == "По месяцам" {
+		if periodKind() == periodMonth {
 			updateStats()
 		}
 	}
@@ -558,30 +1213,30 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 	periodSelect.OnChanged = func(selected string) {
 		filterContainer.Objects = nil
 		
-		switch selected {
-		case "По годам":
+		switch untranslate(selected, periodOptions) {
+		case periodYear:
 			filterContainer.Add(container.NewHBox(
-				widget.NewLabel("Год:"),
+				widget.NewLabel(T("Год:")),
 				yearSelect,
 			))
 			updateStats()
 			
-		case "По месяцам":
+		case periodMonth:
 			currentMonth := time.Now().Month() - 1 // Индексация с 0
 			monthSelect.SetSelectedIndex(int(currentMonth))
 			filterContainer.Add(container.NewHBox(
-				widget.NewLabel("Год:"),
+				widget.NewLabel(T("Год:")),
 				yearSelect,
-				widget.NewLabel("Месяц:"),
+				widget.NewLabel(T("Месяц:")),
 				monthSelect,
 			))
 			updateStats()
 			
-		case "Выбрать период":
+		case periodCustom:
 			filterContainer.Add(container.NewVBox(
-				widget.NewLabel("Начальная дата:"),
+				widget.NewLabel(T("Начальная дата:")),
 				startDateEntry,
-				widget.NewLabel("Конечная дата:"),
+				widget.NewLabel(T("Конечная дата:")),
 				endDateEntry,
 			))
 		}
@@ -590,15 +1245,34 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 	}
 	
 	refreshButton.OnTapped = updateStats
+
+	modeSelect.OnChanged = func(selected string) {
+		if selected == T(statsModeCompare) {
+			compareContainer.Show()
+		} else {
+			compareContainer.Hide()
+		}
+		updateStats()
+	}
+	compareSelect.OnChanged = func(selected string) {
+		if selected == T(compareWithCustom) {
+			compareCustomContainer.Show()
+		} else {
+			compareCustomContainer.Hide()
+		}
+		updateStats()
+	}
+	compareStartEntry.OnChanged = func(string) { updateStats() }
+	compareEndEntry.OnChanged = func(string) { updateStats() }
 	
 	// Автоматическое обновление при изменении фильтров
 	startDateEntry.OnChanged = func(string) { 
-		if periodSelect.Selected == "Выбрать период" {
+		if periodKind() == periodCustom {
 			updateStats() 
 		}
 	}
 	endDateEntry.OnChanged = func(string) { 
-		if periodSelect.Selected == "Выбрать период" {
+		if periodKind() == periodCustom {
 			updateStats() 
 		}
 	}
@@ -610,11 +1284,14 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 	// Основной макет
 	content := container.NewVBox(
 		container.NewHBox(
-			widget.NewLabel("Период:"),
+			widget.NewLabel(T("Период:")),
 			periodSelect,
+			widget.NewLabel(T("Режим:")),
+			modeSelect,
 			refreshButton,
 		),
 		filterContainer,
+		compareContainer,
 		widget.NewSeparator(),
 		scrollContainer,
 	)
@@ -624,130 +1301,156 @@ func statisticsWindow(a fyne.App, db *sql.DB) fyne.Window {
 }
 
 func budgetWindow(a fyne.App, db *sql.DB) fyne.Window {
-	window := a.NewWindow("Управление бюджетом")
-	window.Resize(fyne.NewSize(1000, 800)) // Увеличиваем размер окна
+	window := a.NewWindow(T("Управление бюджетом"))
+	restoreWindowSize(window, "budget", fyne.NewSize(1000, 800))
 
 	categoryEntry := widget.NewEntry()
-	categoryEntry.SetPlaceHolder("Категория")
+	categoryEntry.SetPlaceHolder(T("Категория"))
 	limitEntry := widget.NewEntry()
-	limitEntry.SetPlaceHolder("Лимит бюджета")
+	limitEntry.SetPlaceHolder(T("Лимит бюджета"))
+	startDateEntry := widget.NewEntry()
+	startDateEntry.SetPlaceHolder(datePlaceholder("Начальная дата"))
+	endDateEntry := widget.NewEntry()
+	endDateEntry.SetPlaceHolder(datePlaceholder("Конечная дата"))
+
+	// Даты нужны только для своего периода
+	customPeriodContainer := container.NewGridWithColumns(2, startDateEntry, endDateEntry)
+	customPeriodContainer.Hide()
+	periodSelect := widget.NewSelect(budgetPeriodLabels(), func(selected string) {
+		if budgetPeriodCode(selected) == budgetPeriodCustom {
+			customPeriodContainer.Show()
+		} else {
+			customPeriodContainer.Hide()
+		}
+	})
+	periodSelect.SetSelected(budgetPeriodLabel(budgetPeriodMonthly))
+	rolloverSelect := widget.NewSelect(rolloverLabels(), nil)
+	rolloverSelect.SetSelected(rolloverLabel(rolloverNone))
+
+	// Список бюджетов с прогрессом за текущий период
+	progressContainer := container.NewVBox()
+	updateProgress := func() {
+		progressContainer.Objects = nil
 
-	// Создаем таблицу для существующих лимитов
-	createBudgetTable := func() *widget.Table {
-		rows, err := db.Query("SELECT category, limit_amount FROM budget_limits")
+		progress, err := budgetProgress(db, time.Now())
 		if err != nil {
-			return nil
+			dialog.ShowError(err, window)
+			return
 		}
-		defer rows.Close()
-
-		var limits []struct {
-			Category string
-			Limit    float64
+		if len(progress) == 0 {
+			progressContainer.Add(widget.NewLabel(T("Бюджеты не заданы")))
+			progressContainer.Refresh()
+			return
 		}
 
-		for rows.Next() {
-			var limit struct {
-				Category string
-				Limit    float64
-			}
-			if err := rows.Scan(&limit.Category, &limit.Limit); err != nil {
-				continue
-			}
-			limits = append(limits, limit)
-		}
-
-		table := widget.NewTable(
-			func() (int, int) { return len(limits) + 1, 2 },
-			func() fyne.CanvasObject {
-				return widget.NewLabel("Шаблон")
-			},
-			func(i widget.TableCellID, o fyne.CanvasObject) {
-				label := o.(*widget.Label)
-				if i.Row == 0 {
-					switch i.Col {
-					case 0:
-						label.SetText("Категория")
-						label.TextStyle = fyne.TextStyle{Bold: true}
-					case 1:
-						label.SetText("Лимит")
-						label.TextStyle = fyne.TextStyle{Bold: true}
-					}
-				} else {
-					limit := limits[i.Row-1]
-					switch i.Col {
-					case 0:
-						label.SetText(limit.Category)
-					case 1:
-						label.SetText(fmt.Sprintf("%.2f ₽", limit.Limit))
-					}
-				}
-			},
+		header := container.NewGridWithColumns(6,
+			widget.NewLabelWithStyle(T("Категория"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Период"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Лимит"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Потрачено"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Осталось"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+			widget.NewLabelWithStyle(T("Выполнение"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		)
+		progressContainer.Add(header)
+
+		for _, p := range progress {
+			p := p
+			bar := widget.NewProgressBar()
+			bar.Max = 100
+			// Перерасход показываем текстом, полоса при этом заполнена целиком
+			bar.SetValue(math.Min(p.Percent, 100))
+			bar.TextFormatter = func() string {
+				return fmt.Sprintf("%.0f%%", p.Percent)
+			}
 
-		// Устанавливаем размеры столбцов
-		table.SetColumnWidth(0, 300)
-		table.SetColumnWidth(1, 200)
+			remaining := widget.NewLabel(money(p.Remaining))
+			if p.Remaining < 0 {
+				remaining.TextStyle = fyne.TextStyle{Bold: true}
+			}
 
-		return table
+			progressContainer.Add(container.NewGridWithColumns(6,
+				widget.NewLabel(p.Category),
+				widget.NewLabel(fmt.Sprintf("%s (%s – %s)", budgetPeriodLabel(p.Period), formatDate(p.Start), formatDate(p.End))),
+				widget.NewLabel(money(p.Limit)),
+				widget.NewLabel(money(p.Spent)),
+				remaining,
+				bar,
+			))
+		}
+		progressContainer.Refresh()
 	}
 
 	// Создаем таблицу бюджетов
 	createTable(db)
-	table := createBudgetTable()
+	updateProgress()
 
-	// Создаем контейнер с прокруткой для таблицы
-	tableScroll := container.NewScroll(table)
-	tableScroll.SetMinSize(fyne.NewSize(900, 400))
+	// Создаем контейнер с прокруткой для списка
+	progressScroll := container.NewScroll(progressContainer)
+	progressScroll.SetMinSize(fyne.NewSize(900, 400))
 
-	var saveButton *widget.Button
-	saveButton = widget.NewButton("Сохранить лимит", func() {
-		limit, err := strconv.ParseFloat(limitEntry.Text, 64)
+	saveButton := widget.NewButton(T("Сохранить лимит"), func() {
+		limit, err := parseAmount(limitEntry.Text)
 		if err != nil || limit <= 0 {
-			dialog.ShowError(fmt.Errorf("неверная сумма"), window)
+			dialog.ShowError(errors.New(T("неверная сумма")), window)
 			return
 		}
 
-		_, err = db.Exec(`
-			INSERT OR REPLACE INTO budget_limits (category, limit_amount)
-			VALUES (?, ?)
-		`, categoryEntry.Text, limit)
-
+		err = runCommand(db, &budgetCommand{Budget: Budget{
+			Category:  categoryEntry.Text,
+			Limit:     limit,
+			Period:    budgetPeriodCode(periodSelect.Selected),
+			StartDate: entryDate(startDateEntry.Text),
+			EndDate:   entryDate(endDateEntry.Text),
+			Rollover:  rolloverCode(rolloverSelect.Selected),
+		}})
 		if err != nil {
 			dialog.ShowError(err, window)
 			return
 		}
 
-		// Обновляем таблицу
-		newTable := createBudgetTable()
-		newTableScroll := container.NewScroll(newTable)
-		newTableScroll.SetMinSize(fyne.NewSize(900, 400))
+		updateProgress()
+		sendBudgetAlerts(a, db, categoryEntry.Text)
+	})
 
-		window.SetContent(container.NewVBox(
-			widget.NewLabelWithStyle("Управление бюджетом", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
-			container.NewHBox(
-				container.NewVBox(
-					categoryEntry,
-					limitEntry,
-					saveButton,
-				),
-			),
-			widget.NewSeparator(),
-			newTableScroll,
-		))
+	// Пороги уведомлений о расходовании бюджета
+	thresholdsEntry := widget.NewEntry()
+	thresholdsEntry.SetText(getSetting(db, settingAlertThresholds, defaultAlertThresholds))
+	saveThresholdsButton := widget.NewButton(T("Сохранить пороги"), func() {
+		if _, err := parseThresholds(thresholdsEntry.Text); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if err := setSetting(db, settingAlertThresholds, thresholdsEntry.Text); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		sendBudgetAlerts(a, db)
 	})
 
 	// Основной контейнер с улучшенным макетом
 	content := container.NewVBox(
-		widget.NewLabelWithStyle("Управление бюджетом", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Управление бюджетом"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		container.NewHBox(
 			container.NewVBox(
 				categoryEntry,
 				limitEntry,
+				container.NewHBox(widget.NewLabel(T("Период:")), periodSelect),
+				customPeriodContainer,
+				container.NewHBox(widget.NewLabel(T("Перенос:")), rolloverSelect),
 				saveButton,
 			),
 		),
+		container.NewBorder(nil, nil, widget.NewLabel(T("Пороги уведомлений, %:")), saveThresholdsButton, thresholdsEntry),
+		container.NewHBox(
+			widget.NewButtonWithIcon(T("Конверты"), theme.FolderOpenIcon(), func() {
+				envelopeWindow(a, db).Show()
+			}),
+			widget.NewButtonWithIcon(T("Объединить категории"), theme.ContentCopyIcon(), func() {
+				showMergeCategories(window, db, updateProgress)
+			}),
+		),
 		widget.NewSeparator(),
-		tableScroll,
+		progressScroll,
 	)
 
 	window.SetContent(content)
@@ -755,8 +1458,8 @@ func budgetWindow(a fyne.App, db *sql.DB) fyne.Window {
 }
 
 func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
-	window := a.NewWindow("Экспорт данных")
-	window.Resize(fyne.NewSize(600, 400))
+	window := a.NewWindow(T("Экспорт данных"))
+	restoreWindowSize(window, "export", fyne.NewSize(600, 400))
 
 	// Получаем список годов из базы данных
 	var years []string
@@ -774,24 +1477,23 @@ func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
 	}
 
 	// Элементы управления
-	formatSelect := widget.NewSelect([]string{"CSV", "JSON"}, nil)
-	formatSelect.SetSelected("CSV")
+	formatSelect := widget.NewSelect(exportFormats, nil)
+	formatSelect.SetSelected(prefChoice(prefExportFormat, exportFormats, exportFormats[0]))
 
-	periodSelect := widget.NewSelect([]string{"Все время", "По годам", "По месяцам", "Выбрать период"}, nil)
-	periodSelect.SetSelected("Все время")
+	periodSelect := widget.NewSelect(translateAll(periodOptions), nil)
+	periodKind := func() string { return untranslate(periodSelect.Selected, periodOptions) }
 
 	yearSelect := widget.NewSelect(years, nil)
 	if len(years) > 0 {
 		yearSelect.SetSelected(years[0])
 	}
 
-	monthSelect := widget.NewSelect([]string{"Январь", "Февраль", "Март", "Апрель", "Май", "Июнь", 
-		"Июль", "Август", "Сентябрь", "Октябрь", "Ноябрь", "Декабрь"}, nil)
+	monthSelect := widget.NewSelect(translateAll(monthNames), nil)
 
 	startDateEntry := widget.NewEntry()
-	startDateEntry.SetPlaceHolder("Начальная дата (YYYY-MM-DD)")
+	startDateEntry.SetPlaceHolder(datePlaceholder("Начальная дата"))
 	endDateEntry := widget.NewEntry()
-	endDateEntry.SetPlaceHolder("Конечная дата (YYYY-MM-DD)")
+	endDateEntry.SetPlaceHolder(datePlaceholder("Конечная дата"))
 
 	// Контейнер для динамического изменения элементов управления
 	filterContainer := container.NewVBox()
@@ -800,101 +1502,46 @@ func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
 	periodSelect.OnChanged = func(selected string) {
 		filterContainer.Objects = nil
 		
-		switch selected {
-		case "По годам":
+		switch untranslate(selected, periodOptions) {
+		case periodYear:
 			filterContainer.Add(container.NewHBox(
-				widget.NewLabel("Год:"),
+				widget.NewLabel(T("Год:")),
 				yearSelect,
 			))
-		case "По месяцам":
+		case periodMonth:
 			currentMonth := time.Now().Month() - 1
 			monthSelect.SetSelectedIndex(int(currentMonth))
 			filterContainer.Add(container.NewHBox(
-				widget.NewLabel("Год:"),
+				widget.NewLabel(T("Год:")),
 				yearSelect,
-				widget.NewLabel("Месяц:"),
+				widget.NewLabel(T("Месяц:")),
 				monthSelect,
 			))
-		case "Выбрать период":
+		case periodCustom:
 			filterContainer.Add(container.NewVBox(
-				widget.NewLabel("Начальная дата:"),
+				widget.NewLabel(T("Начальная дата:")),
 				startDateEntry,
-				widget.NewLabel("Конечная дата:"),
+				widget.NewLabel(T("Конечная дата:")),
 				endDateEntry,
 			))
 		}
 		
 		filterContainer.Refresh()
 	}
+	periodSelect.SetSelected(T(prefChoice(prefExportPeriod, periodOptions, periodAll)))
 
 	// Функция для получения данных в зависимости от выбранного периода
 	getExportData := func() ([]Transaction, error) {
-		var query string
-		var args []interface{}
-
-		switch periodSelect.Selected {
-		case "Все время":
-			query = "SELECT id, date, type, category, amount, description FROM transactions ORDER BY date DESC"
-		case "По годам":
-			if yearSelect.Selected == "" {
-				return nil, fmt.Errorf("выберите год")
-			}
-			query = "SELECT id, date, type, category, amount, description FROM transactions WHERE strftime('%Y', date) = ? ORDER BY date DESC"
-			args = append(args, yearSelect.Selected)
-		case "По месяцам":
-			if yearSelect.Selected == "" || monthSelect.Selected == "" {
-				return nil, fmt.Errorf("выберите год и месяц")
-			}
-			monthNum := fmt.Sprintf("%02d", monthSelect.SelectedIndex()+1)
-			query = "SELECT id, date, type, category, amount, description FROM transactions WHERE strftime('%Y', date) = ? AND strftime('%m', date) = ? ORDER BY date DESC"
-			args = append(args, yearSelect.Selected, monthNum)
-		case "Выбрать период":
-			if startDateEntry.Text == "" || endDateEntry.Text == "" {
-				return nil, fmt.Errorf("введите начальную и конечную даты")
-			}
-			query = "SELECT id, date, type, category, amount, description FROM transactions WHERE date BETWEEN ? AND ? ORDER BY date DESC"
-			args = append(args, startDateEntry.Text, endDateEntry.Text)
-		}
-
-		rows, err := db.Query(query, args...)
-		if err != nil {
-			return nil, err
-		}
-		defer rows.Close()
-
-		var transactions []Transaction
-		for rows.Next() {
-			var t Transaction
-			if err := rows.Scan(&t.ID, &t.Date, &t.Type, &t.Category, &t.Amount, &t.Description); err != nil {
-				continue
-			}
-			transactions = append(transactions, t)
-		}
-		return transactions, nil
-	}
-
-	// Функция для экспорта в CSV
-	exportToCSV := func(transactions []Transaction) string {
-		var csv strings.Builder
-		csv.WriteString("ID,Дата,Тип,Категория,Сумма,Описание\n")
-		
-		for _, t := range transactions {
-			csv.WriteString(fmt.Sprintf("%d,%s,%s,%s,%.2f,%s\n",
-				t.ID, t.Date, t.Type, t.Category, t.Amount, t.Description))
-		}
-		return csv.String()
-	}
-
-	// Функция для экспорта в JSON
-	exportToJSON := func(transactions []Transaction) (string, error) {
-		jsonData, err := json.MarshalIndent(transactions, "", "  ")
-		if err != nil {
-			return "", err
-		}
-		return string(jsonData), nil
+		return loadTransactions(db, periodFilter{
+			Kind:  periodKind(),
+			Year:  yearSelect.Selected,
+			Month: monthSelect.SelectedIndex() + 1,
+			Start: entryDate(startDateEntry.Text),
+			End:   entryDate(endDateEntry.Text),
+		})
 	}
 
-	exportButton := widget.NewButton("Экспортировать", func() {
+	exportButton := widget.NewButton(T("Экспортировать"), func() {
 		transactions, err := getExportData()
 		if err != nil {
 			dialog.ShowError(err, window)
@@ -902,7 +1549,7 @@ func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
 		}
 
 		if len(transactions) == 0 {
-			dialog.ShowInformation("Информация", "Нет данных для экспорта", window)
+			dialog.ShowInformation(T("Информация"), T("Нет данных для экспорта"), window)
 			return
 		}
 
@@ -911,10 +1558,20 @@ func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
 
 		switch formatSelect.Selected {
 		case "CSV":
-			data = exportToCSV(transactions)
+			accounts, err := loadAccounts(db)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			csvData, err := transactionsToCSV(transactions, accounts)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			data = csvData
 			extension = ".csv"
 		case "JSON":
-			jsonData, err := exportToJSON(transactions)
+			jsonData, err := transactionsToJSON(transactions)
 			if err != nil {
 				dialog.ShowError(err, window)
 				return
@@ -947,18 +1604,25 @@ func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
 				return
 			}
 
-			dialog.ShowInformation("Успех", "Данные успешно экспортированы", window)
+			// Следующий экспорт откроется в том же каталоге и в том же формате
+			rememberExportLocation(uri)
+			a.Preferences().SetString(prefExportFormat, formatSelect.Selected)
+
+			dialog.ShowInformation(T("Успех"), T("Данные успешно экспортированы"), window)
 		}, window)
+		if location := exportLocation(); location != nil {
+			saveDialog.SetLocation(location)
+		}
 
 		// Устанавливаем начальное имя файла
 		period := "all"
-		switch periodSelect.Selected {
-		case "По годам":
+		switch periodKind() {
+		case periodYear:
 			period = yearSelect.Selected
-		case "По месяцам":
+		case periodMonth:
 			period = fmt.Sprintf("%s_%s", yearSelect.Selected, monthSelect.Selected)
-		case "Выбрать период":
-			period = fmt.Sprintf("%s_to_%s", startDateEntry.Text, endDateEntry.Text)
+		case periodCustom:
+			period = fmt.Sprintf("%s_to_%s", entryDate(startDateEntry.Text), entryDate(endDateEntry.Text))
 		}
 		saveDialog.SetFileName(fmt.Sprintf("transactions_%s%s", period, extension))
 		saveDialog.Show()
@@ -966,13 +1630,13 @@ func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
 
 	// Основной макет
 	content := container.NewVBox(
-		widget.NewLabelWithStyle("Экспорт данных", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle(T("Экспорт данных"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		container.NewHBox(
-			widget.NewLabel("Формат:"),
+			widget.NewLabel(T("Формат:")),
 			formatSelect,
 		),
 		container.NewHBox(
-			widget.NewLabel("Период:"),
+			widget.NewLabel(T("Период:")),
 			periodSelect,
 		),
 		filterContainer,
@@ -983,3 +1647,35 @@ func exportDataWindow(a fyne.App, db *sql.DB) fyne.Window {
 	window.SetContent(content)
 	return window
 }
+
+// transactionsToCSV выгружает транзакции в CSV: по строке на каждую часть разделенной
+// транзакции. Счета записываются названиями, чтобы файл можно было загрузить в другую базу.
+func transactionsToCSV(transactions []Transaction, accounts []Account) (string, error) {
+	accountNamesByID := make(map[int]string)
+	for _, acc := range accounts {
+		accountNamesByID[acc.ID] = acc.Name
+	}
+	var out strings.Builder
+	writer := csv.NewWriter(&out)
+	writer.Write([]string{"ID", T("Дата"), T("Счет"), T("Счет зачисления"), T("Тип"), T("Категория"),
+		T("Сумма"), T("Описание"), T("Заметка"), T("Теги")})
+
+	for _, t := range transactions {
+		for _, line := range transactionLines(t) {
+			writer.Write([]string{
+				strconv.Itoa(t.ID), t.Date, accountNamesByID[t.AccountID], accountNamesByID[t.TransferAccountID],
+				typeLabel(t.Type), line.Category, fmt.Sprintf("%.2f", line.Amount), t.Description, line.Note, t.Tags,
+			})
+		}
+	}
+	writer.Flush()
+	return out.String(), writer.Error()
+}
+
+func transactionsToJSON(transactions []Transaction) (string, error) {
+	jsonData, err := json.MarshalIndent(transactions, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(jsonData), nil
+}
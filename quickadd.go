@@ -0,0 +1,276 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Слова для относительных дат в строке быстрого ввода: сдвиг в днях от сегодня
+var relativeDates = map[string]int{
+	"сегодня":   0,
+	"вчера":     -1,
+	"позавчера": -2,
+	"today":     0,
+	"yesterday": -1,
+}
+
+// QuickEntry — транзакция, разобранная из строки быстрого ввода
+type QuickEntry struct {
+	Transaction
+	// Phrase — слова без суммы и даты; по ним ищется и запоминается алиас категории
+	Phrase string
+}
+
+// parseQuickAdd разбирает строку вида "кофе 250 вчера" или "+50000 зарплата 2026-10-01".
+// Сумма со знаком "+" означает доход, без знака - расход. Категория берется из
+// алиасов (сначала для всей фразы, потом для отдельных слов), затем из правил
+// и получателей, иначе из самой фразы.
+func parseQuickAdd(text string, aliases map[string]string, markup autoMarkup, today time.Time) (QuickEntry, error) {
+	entry := QuickEntry{Transaction: Transaction{
+		Type: typeExpense,
+		Date: today.Format("2006-01-02"),
+	}}
+	var words []string
+	for _, token := range strings.Fields(text) {
+		lower := strings.ToLower(token)
+		if shift, ok := relativeDates[lower]; ok {
+			entry.Date = today.AddDate(0, 0, shift).Format("2006-01-02")
+			continue
+		}
+		if date, err := parseDate(token); err == nil {
+			entry.Date = date
+			continue
+		}
+		if entry.Amount == 0 && startsWithDigit(token) {
+			amount, err := parseAmount(strings.TrimPrefix(strings.TrimPrefix(token, "+"), "-"))
+			if err == nil && amount > 0 {
+				entry.Amount = amount
+				if strings.HasPrefix(token, "+") {
+					entry.Type = typeIncome
+				}
+				continue
+			}
+		}
+		words = append(words, token)
+	}
+	if entry.Amount == 0 {
+		return entry, errors.New(T("не найдена сумма"))
+	}
+
+	entry.Description = strings.Join(words, " ")
+	entry.Phrase = strings.ToLower(entry.Description)
+	entry.Category = lookupAlias(aliases, entry.Phrase)
+	if entry.Category == "" {
+		entry.Category = markup.suggestCategory(entry.Transaction)
+	}
+	if entry.Category == "" {
+		entry.Category = capitalize(entry.Description)
+	}
+	if entry.Category == "" {
+		return entry, errors.New(T("не указана категория"))
+	}
+	return entry, nil
+}
+
+func startsWithDigit(token string) bool {
+	token = strings.TrimLeft(token, "+-")
+	return token != "" && unicode.IsDigit([]rune(token)[0])
+}
+
+func lookupAlias(aliases map[string]string, phrase string) string {
+	if category, ok := aliases[phrase]; ok {
+		return category
+	}
+	for _, word := range strings.Fields(phrase) {
+		if category, ok := aliases[word]; ok {
+			return category
+		}
+	}
+	return ""
+}
+
+func capitalize(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+func loadCategoryAliases(db *sql.DB) (map[string]string, error) {
+	rows, err := db.Query("SELECT alias, category FROM category_aliases")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var alias, category string
+		if err := rows.Scan(&alias, &category); err != nil {
+			return nil, err
+		}
+		aliases[alias] = category
+	}
+	return aliases, rows.Err()
+}
+
+// saveCategoryAlias запоминает, что фраза означает категорию
+func saveCategoryAlias(db *sql.DB, alias, category string) error {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	if alias == "" || category == "" {
+		return nil
+	}
+	_, err := db.Exec(`
+		INSERT INTO category_aliases (alias, category) VALUES (?, ?)
+		ON CONFLICT(alias) DO UPDATE SET category = excluded.category
+	`, alias, category)
+	return err
+}
+
+// describeQuickEntry — как разобранная строка показывается до сохранения
+func describeQuickEntry(e QuickEntry) string {
+	return strings.Join([]string{typeLabel(e.Type), money(e.Amount), e.Category, formatDate(e.Date)}, " · ")
+}
+
+// quickAddBox — строка быстрого ввода на главном окне. Под полем показывается,
+// как понята строка; перед сохранением разбор можно поправить, и исправленная
+// категория запоминается как алиас для этой фразы.
+func quickAddBox(a fyne.App, window fyne.Window, db *sql.DB) fyne.CanvasObject {
+	input := widget.NewEntry()
+	input.SetPlaceHolder(T("Быстрый ввод: кофе 250 вчера"))
+	preview := widget.NewLabel("")
+
+	// Алиасы, правила и получатели могут измениться в других окнах, поэтому они
+	// перечитываются в начале каждого ввода и перед сохранением
+	aliases := map[string]string{}
+	var markup autoMarkup
+	reload := func() error {
+		loadedAliases, err := loadCategoryAliases(db)
+		if err != nil {
+			return err
+		}
+		loadedMarkup, err := loadAutoMarkup(db)
+		if err != nil {
+			return err
+		}
+		aliases, markup = loadedAliases, loadedMarkup
+		return nil
+	}
+	reload()
+
+	typing := false
+	input.OnChanged = func(text string) {
+		if strings.TrimSpace(text) == "" {
+			typing = false
+			preview.SetText("")
+			return
+		}
+		if !typing {
+			typing = true
+			reload()
+		}
+		entry, err := parseQuickAdd(text, aliases, markup, time.Now())
+		if err != nil {
+			preview.SetText(err.Error())
+			return
+		}
+		preview.SetText(describeQuickEntry(entry))
+	}
+
+	submit := func() {
+		if err := reload(); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		entry, err := parseQuickAdd(input.Text, aliases, markup, time.Now())
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		confirmQuickEntry(a, window, db, markup, entry, func(category string) {
+			if category != entry.Category {
+				aliases[entry.Phrase] = category
+			}
+			input.SetText("")
+		})
+	}
+	input.OnSubmitted = func(string) { submit() }
+	addButton := widget.NewButtonWithIcon("", theme.ContentAddIcon(), submit)
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, nil, addButton, input),
+		preview,
+	)
+}
+
+// confirmQuickEntry показывает разбор в форме и сохраняет транзакцию после подтверждения
+func confirmQuickEntry(a fyne.App, window fyne.Window, db *sql.DB, markup autoMarkup, entry QuickEntry, onSaved func(category string)) {
+	typeSelect := widget.NewSelect([]string{typeLabel(typeIncome), typeLabel(typeExpense)}, nil)
+	typeSelect.SetSelected(typeLabel(entry.Type))
+	amountEntry := widget.NewEntry()
+	amountEntry.SetText(formatNumber(entry.Amount))
+	categoryEntry := widget.NewEntry()
+	categoryEntry.SetText(entry.Category)
+	descriptionEntry := widget.NewEntry()
+	descriptionEntry.SetText(entry.Description)
+	dateField := newDateField(window, "Дата")
+	dateField.SetDate(entry.Date)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem(T("Тип"), typeSelect),
+		widget.NewFormItem(T("Сумма"), amountEntry),
+		widget.NewFormItem(T("Категория"), categoryEntry),
+		widget.NewFormItem(T("Описание"), descriptionEntry),
+		widget.NewFormItem(T("Дата"), dateField.content),
+	}
+	dialog.ShowForm(T("Добавить транзакцию"), T("Сохранить"), T("Отмена"), items, func(ok bool) {
+		if !ok {
+			return
+		}
+		amount, err := parseAmount(amountEntry.Text)
+		if err != nil || amount <= 0 {
+			dialog.ShowError(errors.New(T("неверная сумма")), window)
+			return
+		}
+		date, err := dateField.Date()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		category := strings.TrimSpace(categoryEntry.Text)
+		if category == "" {
+			dialog.ShowError(errors.New(T("не указана категория")), window)
+			return
+		}
+		t := entry.Transaction
+		t.Type = typeCode(typeSelect.Selected)
+		t.Amount = amount
+		t.Category = category
+		t.Description = descriptionEntry.Text
+		t.Date = date
+		t = markup.apply(t)
+		if err := runCommand(db, &addTransactionCommand{Transaction: t}); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		// Исправленную категорию запоминаем для этой фразы
+		if category != entry.Category {
+			if err := saveCategoryAlias(db, entry.Phrase, category); err != nil {
+				dialog.ShowError(err, window)
+			}
+		}
+		sendBudgetAlerts(a, db, category)
+		onSaved(category)
+	}, window)
+}
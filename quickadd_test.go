@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+	useLanguage(t, langRussian)
+	today := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+
+	taxi := Rule{Name: "Такси", Enabled: true, DescriptionContains: "такси", Category: "Транспорт"}
+	if err := taxi.compile(); err != nil {
+		t.Fatal(err)
+	}
+	markup := autoMarkup{rules: []Rule{taxi}}
+	aliases := map[string]string{
+		"кофе":          "Кафе",
+		"кофе в зернах": "Продукты",
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    QuickEntry
+		wantErr bool
+	}{
+		{
+			name: "расход вчера",
+			text: "булочка 250 вчера",
+			want: QuickEntry{Phrase: "булочка", Transaction: Transaction{
+				Type: typeExpense, Amount: 250, Date: "2026-10-18", Category: "Булочка", Description: "булочка"}},
+		},
+		{
+			name: "доход с датой",
+			text: "+50000 Зарплата 2026-10-01",
+			want: QuickEntry{Phrase: "зарплата", Transaction: Transaction{
+				Type: typeIncome, Amount: 50000, Date: "2026-10-01", Category: "Зарплата", Description: "Зарплата"}},
+		},
+		{
+			name: "дата в формате языка и копейки",
+			text: "05.10.2026 обед 300,50",
+			want: QuickEntry{Phrase: "обед", Transaction: Transaction{
+				Type: typeExpense, Amount: 300.5, Date: "2026-10-05", Category: "Обед", Description: "обед"}},
+		},
+		{
+			name: "алиас по слову",
+			text: "кофе с собой 180 позавчера",
+			want: QuickEntry{Phrase: "кофе с собой", Transaction: Transaction{
+				Type: typeExpense, Amount: 180, Date: "2026-10-17", Category: "Кафе", Description: "кофе с собой"}},
+		},
+		{
+			name: "алиас всей фразы важнее алиаса слова",
+			text: "Кофе в зернах 900",
+			want: QuickEntry{Phrase: "кофе в зернах", Transaction: Transaction{
+				Type: typeExpense, Amount: 900, Date: "2026-10-19", Category: "Продукты", Description: "Кофе в зернах"}},
+		},
+		{
+			name: "категория по правилу",
+			text: "такси домой 400",
+			want: QuickEntry{Phrase: "такси домой", Transaction: Transaction{
+				Type: typeExpense, Amount: 400, Date: "2026-10-19", Category: "Транспорт", Description: "такси домой"}},
+		},
+		{
+			name: "вторая сумма остается в описании",
+			text: "билеты 2 1500",
+			want: QuickEntry{Phrase: "билеты 1500", Transaction: Transaction{
+				Type: typeExpense, Amount: 2, Date: "2026-10-19", Category: "Билеты 1500", Description: "билеты 1500"}},
+		},
+		{name: "нет суммы", text: "кофе вчера", wantErr: true},
+		{name: "нет категории", text: "250 сегодня", wantErr: true},
+		{name: "пустая строка", text: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseQuickAdd(tt.text, aliases, markup, today)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка, получено %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Phrase != tt.want.Phrase || got.Type != tt.want.Type || got.Amount != tt.want.Amount ||
+				got.Date != tt.want.Date || got.Category != tt.want.Category || got.Description != tt.want.Description {
+				t.Errorf("получено %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Отметки сверки транзакции с выпиской банка (transactions.status). Перевод
+// между своими счетами отмечается один раз для обоих счетов.
+const (
+	statusUncleared  = ""
+	statusCleared    = "cleared"
+	statusReconciled = "reconciled"
+)
+
+var transactionStatuses = []struct {
+	Code  string
+	Label string
+}{
+	{statusUncleared, "Не проведена"},
+	{statusCleared, "Проведена"},
+	{statusReconciled, "Сверена"},
+}
+
+// lockedTransactionError возвращается при попытке изменить или удалить сверенную
+// транзакцию; текст переводится при выводе, а не при запуске
+type lockedTransactionError struct{}
+
+func (lockedTransactionError) Error() string {
+	return T("транзакция сверена с банком; чтобы изменить её, снимите отметку сверки")
+}
+
+var errTransactionLocked error = lockedTransactionError{}
+
+func statusLabel(code string) string {
+	for _, s := range transactionStatuses {
+		if s.Code == code {
+			return T(s.Label)
+		}
+	}
+	return code
+}
+
+// setTransactionStatus отмечает транзакции проведенными или снимает отметку.
+// Сверенные транзакции так не меняются: для них есть unlockTransaction.
+func setTransactionStatus(db *sql.DB, ids []int, status string) error {
+	if status == statusReconciled {
+		return errors.New(T("сверка завершается в окне сверки"))
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		if _, err := tx.Exec("UPDATE transactions SET status = ? WHERE id = ? AND status != ?",
+			status, id, statusReconciled); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// unlockTransaction снимает отметку сверки, возвращая транзакцию в проведенные
+func unlockTransaction(db *sql.DB, id int) error {
+	_, err := db.Exec("UPDATE transactions SET status = ? WHERE id = ? AND status = ?",
+		statusCleared, id, statusReconciled)
+	return err
+}
+
+// clearedBalance — остаток счета на дату по проведенным и сверенным транзакциям
+func clearedBalance(db *sql.DB, accountID int, date string) (float64, error) {
+	var balance float64
+	err := db.QueryRow(`
+		SELECT a.opening_balance + COALESCE((
+			SELECT SUM(CASE
+				WHEN t.type = ? AND t.transfer_account_id = a.id THEN t.amount
+				WHEN t.type = ? THEN -t.amount
+				WHEN t.type = ? THEN t.amount
+				WHEN t.type = ? THEN -t.amount
+				ELSE 0 END)
+			FROM transactions t
+			WHERE (t.account_id = a.id OR t.transfer_account_id = a.id) AND t.date <= ? AND t.status != ?
+		), 0)
+		FROM accounts a
+		WHERE a.id = ?
+	`, typeTransfer, typeTransfer, typeIncome, typeExpense, date, statusUncleared, accountID).Scan(&balance)
+	return balance, err
+}
+
+// unreconciledTransactions — еще не сверенные транзакции счета до даты выписки
+func unreconciledTransactions(db *sql.DB, accountID int, date string) ([]Transaction, error) {
+	rows, err := db.Query(`
+		SELECT id, date, type, category, amount, description, account_id, transfer_account_id, status
+		FROM transactions
+		WHERE (account_id = ? OR transfer_account_id = ?) AND date <= ? AND status != ?
+		ORDER BY date, id
+	`, accountID, accountID, date, statusReconciled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.Date, &t.Type, &t.Category, &t.Amount, &t.Description,
+			&t.AccountID, &t.TransferAccountID, &t.Status); err != nil {
+			continue
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// accountEffect — как транзакция меняет остаток счета
+func accountEffect(t Transaction, accountID int) float64 {
+	switch {
+	case t.Type == typeTransfer && t.TransferAccountID == accountID:
+		return t.Amount
+	case t.Type == typeIncome:
+		return t.Amount
+	default:
+		return -t.Amount
+	}
+}
+
+// finishReconciliation закрывает сверку: проведенные транзакции счета до даты
+// выписки становятся сверенными, а сама сверка запоминается
+func finishReconciliation(db *sql.DB, accountID int, date string, statementBalance float64) error {
+	balance, err := clearedBalance(db, accountID, date)
+	if err != nil {
+		return err
+	}
+	if toCents(balance) != toCents(statementBalance) {
+		return fmt.Errorf(T("остаток по проведенным (%s) не совпадает с выпиской (%s)"), money(balance), money(statementBalance))
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(`
+		UPDATE transactions SET status = ?
+		WHERE (account_id = ? OR transfer_account_id = ?) AND date <= ? AND status = ?
+	`, statusReconciled, accountID, accountID, date, statusCleared)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO reconciliations (account_id, statement_date, statement_balance, reconciled_at)
+		VALUES (?, ?, ?, ?)
+	`, accountID, date, statementBalance, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lastReconciliation — дата и остаток последней сверки счета
+func lastReconciliation(db *sql.DB, accountID int) (string, float64, bool, error) {
+	var date string
+	var balance float64
+	err := db.QueryRow(`
+		SELECT statement_date, statement_balance FROM reconciliations
+		WHERE account_id = ? ORDER BY statement_date DESC, id DESC LIMIT 1
+	`, accountID).Scan(&date, &balance)
+	if err == sql.ErrNoRows {
+		return "", 0, false, nil
+	}
+	return date, balance, err == nil, err
+}
+
+func reconcileWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Сверка с банком"))
+	restoreWindowSize(window, "reconcile", fyne.NewSize(1000, 700))
+
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+	accountSelect := widget.NewSelect(accountNames(accounts), nil)
+	dateField := newDateField(window, "Дата выписки")
+	dateField.SetDate(time.Now().Format("2006-01-02"))
+	balanceEntry := widget.NewEntry()
+	balanceEntry.SetPlaceHolder(T("Остаток по выписке"))
+
+	lastLabel := widget.NewLabel("")
+	clearedLabel := widget.NewLabel("")
+	differenceLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	rowsContainer := container.NewVBox()
+	finishButton := widget.NewButtonWithIcon(T("Завершить сверку"), theme.ConfirmIcon(), nil)
+	finishButton.Disable()
+
+	var account Account
+	var transactions []Transaction
+	var cleared float64
+
+	// updateDifference пересчитывает разницу с выпиской без обращения к базе
+	updateDifference := func() {
+		clearedLabel.SetText(Tf("Остаток по проведенным: %s", formatMoney(cleared, account.Currency)))
+		statement, err := parseAmount(balanceEntry.Text)
+		if err != nil {
+			differenceLabel.SetText(T("Введите остаток по выписке"))
+			finishButton.Disable()
+			return
+		}
+		difference := statement - cleared
+		differenceLabel.SetText(Tf("Разница: %s", formatMoney(difference, account.Currency)))
+		if toCents(difference) == 0 {
+			finishButton.Enable()
+		} else {
+			finishButton.Disable()
+		}
+	}
+
+	update := func() {
+		rowsContainer.Objects = nil
+		var ok bool
+		account, ok = accountByName(accounts, accountSelect.Selected)
+		date, err := dateField.Date()
+		if !ok || err != nil {
+			rowsContainer.Refresh()
+			return
+		}
+		if lastDate, lastBalance, found, err := lastReconciliation(db, account.ID); err != nil {
+			dialog.ShowError(err, window)
+		} else if found {
+			lastLabel.SetText(Tf("Последняя сверка: %s, остаток %s", formatDate(lastDate), formatMoney(lastBalance, account.Currency)))
+		} else {
+			lastLabel.SetText(T("Счет еще не сверялся"))
+		}
+		cleared, err = clearedBalance(db, account.ID, date)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		transactions, err = unreconciledTransactions(db, account.ID, date)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+
+		for _, t := range transactions {
+			t := t
+			effect := accountEffect(t, account.ID)
+			check := widget.NewCheck("", nil)
+			check.SetChecked(t.Status == statusCleared)
+			check.OnChanged = func(checked bool) {
+				status := statusUncleared
+				if checked {
+					status = statusCleared
+				}
+				if err := setTransactionStatus(db, []int{t.ID}, status); err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				if checked {
+					cleared += effect
+				} else {
+					cleared -= effect
+				}
+				updateDifference()
+			}
+			rowsContainer.Add(container.NewBorder(nil, nil, check, nil, container.NewGridWithColumns(4,
+				widget.NewLabel(formatDate(t.Date)),
+				widget.NewLabel(formatMoney(effect, account.Currency)),
+				widget.NewLabel(t.Category),
+				widget.NewLabel(t.Description),
+			)))
+		}
+		if len(transactions) == 0 {
+			rowsContainer.Add(widget.NewLabel(T("Несверенных транзакций нет")))
+		}
+		rowsContainer.Refresh()
+		updateDifference()
+	}
+
+	accountSelect.OnChanged = func(string) { update() }
+	dateField.entry.OnChanged = func(string) { update() }
+	balanceEntry.OnChanged = func(string) { updateDifference() }
+
+	finishButton.OnTapped = func() {
+		date, err := dateField.Date()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		statement, err := parseAmount(balanceEntry.Text)
+		if err != nil {
+			dialog.ShowError(errors.New(T("неверная сумма")), window)
+			return
+		}
+		if err := finishReconciliation(db, account.ID, date, statement); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		dialog.ShowInformation(T("Успех"), T("Сверка завершена, проведенные транзакции заблокированы"), window)
+		update()
+	}
+
+	clearAllButton := widget.NewButton(T("Отметить все"), func() {
+		ids := make([]int, 0, len(transactions))
+		for _, t := range transactions {
+			ids = append(ids, t.ID)
+		}
+		if err := setTransactionStatus(db, ids, statusCleared); err != nil {
+			dialog.ShowError(err, window)
+		}
+		update()
+	})
+
+	if len(accounts) > 0 {
+		accountSelect.SetSelected(accounts[0].Name)
+	}
+
+	controls := container.NewVBox(
+		container.NewGridWithColumns(3, accountSelect, dateField.content, balanceEntry),
+		lastLabel,
+		container.NewHBox(clearedLabel, differenceLabel),
+		container.NewHBox(clearAllButton, finishButton),
+		widget.NewSeparator(),
+	)
+	window.SetContent(container.NewBorder(controls, nil, nil, nil, container.NewScroll(rowsContainer)))
+	return window
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Rule — правило автоматической разметки транзакций. Пустые условия не проверяются,
+// пустые действия ничего не меняют.
+type Rule struct {
+	ID       int
+	Name     string
+	Priority int // правила с большим приоритетом применяются первыми
+	Enabled  bool
+
+	// Условия
+	DescriptionContains string // подстрока описания без учета регистра
+	DescriptionRegex    string
+	MinAmount           float64 // 0 - без ограничения
+	MaxAmount           float64 // 0 - без ограничения
+	AccountID           int     // 0 - любой счет
+	Type                string  // пусто - любой тип
+
+	// Действия
+	Category    string
+	Tags        string // через запятую, добавляются к тегам транзакции
+	Description string // новое описание; при регулярном выражении можно ссылаться на группы: $1
+
+	re *regexp.Regexp
+}
+
+// RuleMatch — транзакция из истории до и после применения правила
+type RuleMatch struct {
+	Before Transaction
+	After  Transaction
+}
+
+// compile проверяет правило и готовит регулярное выражение
+func (r *Rule) compile() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New(T("не указано название правила"))
+	}
+	if r.MinAmount < 0 || r.MaxAmount < 0 || (r.MaxAmount > 0 && r.MinAmount > r.MaxAmount) {
+		return errors.New(T("неверный диапазон суммы"))
+	}
+	if r.DescriptionContains == "" && r.DescriptionRegex == "" && r.MinAmount == 0 && r.MaxAmount == 0 &&
+		r.AccountID == 0 && r.Type == "" {
+		return errors.New(T("задайте хотя бы одно условие"))
+	}
+	if r.Category == "" && r.Tags == "" && r.Description == "" {
+		return errors.New(T("задайте хотя бы одно действие"))
+	}
+	r.re = nil
+	if r.DescriptionRegex != "" {
+		re, err := regexp.Compile("(?i)" + r.DescriptionRegex)
+		if err != nil {
+			return fmt.Errorf(T("неверное регулярное выражение: %v"), err)
+		}
+		r.re = re
+	}
+	return nil
+}
+
+func (r Rule) matches(t Transaction) bool {
+	if r.DescriptionContains != "" &&
+		!strings.Contains(strings.ToLower(t.Description), strings.ToLower(r.DescriptionContains)) {
+		return false
+	}
+	if r.re != nil && !r.re.MatchString(t.Description) {
+		return false
+	}
+	if r.MinAmount > 0 && t.Amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && t.Amount > r.MaxAmount {
+		return false
+	}
+	if r.AccountID != 0 && t.AccountID != r.AccountID {
+		return false
+	}
+	if r.Type != "" && t.Type != r.Type {
+		return false
+	}
+	return true
+}
+
+// rewrite возвращает новое описание транзакции по шаблону правила. Описание
+// заменяется целиком и при регулярном выражении: группы первого совпадения
+// подставляются в шаблон, а текст вокруг совпадения отбрасывается.
+func (r Rule) rewrite(description string) string {
+	if r.re == nil {
+		return r.Description
+	}
+	match := r.re.FindStringSubmatchIndex(description)
+	if match == nil {
+		return description
+	}
+	return string(r.re.ExpandString(nil, r.Description, description, match))
+}
+
+// applyRules размечает транзакцию подходящими правилами в порядке приоритета.
+// Категорию и описание задает первое подходящее правило, в котором они указаны;
+// категория, выбранная пользователем, не перезаписывается. Теги всех правил складываются.
+func applyRules(rules []Rule, t Transaction) Transaction {
+	original := t
+	rewritten := false
+	for _, r := range rules {
+		if !r.Enabled || !r.matches(original) {
+			continue
+		}
+		if r.Category != "" && t.Category == "" && len(t.Splits) == 0 {
+			t.Category = r.Category
+		}
+		if r.Tags != "" {
+			t.Tags = mergeTags(t.Tags, r.Tags)
+		}
+		if r.Description != "" && !rewritten {
+			t.Description = r.rewrite(original.Description)
+			rewritten = true
+		}
+	}
+	return t
+}
+
+// autoMarkup — автоматическая разметка новой транзакции: получатель по исходному
+// описанию банка, затем правила, затем категория получателя по умолчанию
+type autoMarkup struct {
+	rules  []Rule
+	payees []Payee
+}
+
+func loadAutoMarkup(db *sql.DB) (autoMarkup, error) {
+	rules, err := loadRules(db)
+	if err != nil {
+		return autoMarkup{}, err
+	}
+	payees, err := loadPayees(db)
+	if err != nil {
+		return autoMarkup{}, err
+	}
+	return autoMarkup{rules: rules, payees: payees}, nil
+}
+
+func (m autoMarkup) apply(t Transaction) Transaction {
+	payee, ok := matchPayee(m.payees, t.Description)
+	if ok && t.PayeeID == 0 {
+		t.PayeeID = payee.ID
+	}
+	t = applyRules(m.rules, t)
+	if ok && t.Category == "" && len(t.Splits) == 0 {
+		t.Category = payee.DefaultCategory
+	}
+	return t
+}
+
+// suggestCategory — категория, которую разметка назначила бы транзакции без категории
+func (m autoMarkup) suggestCategory(t Transaction) string {
+	t.Category = ""
+	t.Splits = nil
+	return m.apply(t).Category
+}
+
+// applyStoredMarkup размечает транзакцию правилами и получателями из базы
+func applyStoredMarkup(db *sql.DB, t Transaction) (Transaction, error) {
+	markup, err := loadAutoMarkup(db)
+	if err != nil {
+		return t, err
+	}
+	return markup.apply(t), nil
+}
+
+// splitTags разбирает список тегов через запятую без повторов
+func splitTags(tags string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+func mergeTags(a, b string) string {
+	return strings.Join(splitTags(a+","+b), ", ")
+}
+
+func loadRules(db *sql.DB) ([]Rule, error) {
+	rows, err := db.Query(`
+		SELECT id, name, priority, enabled, description_contains, description_regex, min_amount, max_amount,
+			account_id, type, set_category, set_tags, set_description
+		FROM rules ORDER BY priority DESC, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var r Rule
+		if err := rows.Scan(&r.ID, &r.Name, &r.Priority, &r.Enabled, &r.DescriptionContains, &r.DescriptionRegex,
+			&r.MinAmount, &r.MaxAmount, &r.AccountID, &r.Type, &r.Category, &r.Tags, &r.Description); err != nil {
+			continue
+		}
+		// Правило с ошибкой в выражении не должно срабатывать на все подряд
+		if r.compile() != nil {
+			r.Enabled = false
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// saveRule добавляет новое правило или обновляет существующее и возвращает его ID
+func saveRule(db *sql.DB, r Rule) (int, error) {
+	if err := r.compile(); err != nil {
+		return 0, err
+	}
+	if r.ID == 0 {
+		res, err := db.Exec(`
+			INSERT INTO rules (name, priority, enabled, description_contains, description_regex, min_amount, max_amount,
+				account_id, type, set_category, set_tags, set_description)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.Name, r.Priority, r.Enabled, r.DescriptionContains, r.DescriptionRegex, r.MinAmount, r.MaxAmount,
+			r.AccountID, r.Type, r.Category, r.Tags, r.Description)
+		if err != nil {
+			return 0, err
+		}
+		id, err := res.LastInsertId()
+		return int(id), err
+	}
+	_, err := db.Exec(`
+		UPDATE rules SET name = ?, priority = ?, enabled = ?, description_contains = ?, description_regex = ?,
+			min_amount = ?, max_amount = ?, account_id = ?, type = ?, set_category = ?, set_tags = ?, set_description = ?
+		WHERE id = ?
+	`, r.Name, r.Priority, r.Enabled, r.DescriptionContains, r.DescriptionRegex, r.MinAmount, r.MaxAmount,
+		r.AccountID, r.Type, r.Category, r.Tags, r.Description, r.ID)
+	return r.ID, err
+}
+
+func deleteRule(db *sql.DB, id int) error {
+	_, err := db.Exec("DELETE FROM rules WHERE id = ?", id)
+	return err
+}
+
+// testRule показывает, что правило изменило бы в уже сохраненных транзакциях.
+// Категория проверяется как у новой транзакции, без учета уже назначенной.
+func testRule(db *sql.DB, r Rule) ([]RuleMatch, error) {
+	if err := r.compile(); err != nil {
+		return nil, err
+	}
+	r.Enabled = true
+	transactions, err := loadTransactions(db, periodFilter{Kind: periodAll})
+	if err != nil {
+		return nil, err
+	}
+	var matches []RuleMatch
+	for _, t := range transactions {
+		if !r.matches(t) {
+			continue
+		}
+		blank := t
+		if len(blank.Splits) == 0 {
+			blank.Category = ""
+		}
+		after := applyRules([]Rule{r}, blank)
+		if after.Category == "" {
+			after.Category = t.Category
+		}
+		matches = append(matches, RuleMatch{Before: t, After: after})
+	}
+	return matches, nil
+}
+
+func rulesWindow(a fyne.App, db *sql.DB) fyne.Window {
+	window := a.NewWindow(T("Правила"))
+	restoreWindowSize(window, "rules", fyne.NewSize(1100, 750))
+
+	accounts, err := loadAccounts(db)
+	if err != nil {
+		dialog.ShowError(err, window)
+	}
+	anyAccount := T("Любой счет")
+	anyType := T("Любой тип")
+
+	var rules []Rule
+	var editing Rule
+
+	// Форма правила
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder(T("Название"))
+	priorityEntry := widget.NewEntry()
+	priorityEntry.SetPlaceHolder(T("Приоритет"))
+	enabledCheck := widget.NewCheck(T("Включено"), nil)
+	containsEntry := widget.NewEntry()
+	containsEntry.SetPlaceHolder(T("Описание содержит"))
+	regexEntry := widget.NewEntry()
+	regexEntry.SetPlaceHolder(T("Регулярное выражение"))
+	minEntry := widget.NewEntry()
+	minEntry.SetPlaceHolder(T("Сумма от"))
+	maxEntry := widget.NewEntry()
+	maxEntry.SetPlaceHolder(T("Сумма до"))
+	accountSelect := widget.NewSelect(append([]string{anyAccount}, accountNames(accounts)...), nil)
+	typeSelect := widget.NewSelect([]string{anyType, typeLabel(typeIncome), typeLabel(typeExpense), typeLabel(typeTransfer)}, nil)
+	categoryEntry := widget.NewEntry()
+	categoryEntry.SetPlaceHolder(T("Категория"))
+	tagsEntry := widget.NewEntry()
+	tagsEntry.SetPlaceHolder(T("Теги через запятую"))
+	descriptionEntry := widget.NewEntry()
+	descriptionEntry.SetPlaceHolder(T("Новое описание"))
+
+	fillForm := func(r Rule) {
+		editing = r
+		nameEntry.SetText(r.Name)
+		priorityEntry.SetText(strconv.Itoa(r.Priority))
+		enabledCheck.SetChecked(r.Enabled)
+		containsEntry.SetText(r.DescriptionContains)
+		regexEntry.SetText(r.DescriptionRegex)
+		minEntry.SetText("")
+		if r.MinAmount > 0 {
+			minEntry.SetText(formatNumber(r.MinAmount))
+		}
+		maxEntry.SetText("")
+		if r.MaxAmount > 0 {
+			maxEntry.SetText(formatNumber(r.MaxAmount))
+		}
+		accountSelect.SetSelected(anyAccount)
+		for _, acc := range accounts {
+			if acc.ID == r.AccountID {
+				accountSelect.SetSelected(acc.Name)
+			}
+		}
+		typeSelect.SetSelected(anyType)
+		if r.Type != "" {
+			typeSelect.SetSelected(typeLabel(r.Type))
+		}
+		categoryEntry.SetText(r.Category)
+		tagsEntry.SetText(r.Tags)
+		descriptionEntry.SetText(r.Description)
+	}
+
+	// readForm собирает правило из формы
+	readForm := func() (Rule, error) {
+		r := Rule{
+			ID:                  editing.ID,
+			Name:                strings.TrimSpace(nameEntry.Text),
+			Enabled:             enabledCheck.Checked,
+			DescriptionContains: strings.TrimSpace(containsEntry.Text),
+			DescriptionRegex:    strings.TrimSpace(regexEntry.Text),
+			Category:            strings.TrimSpace(categoryEntry.Text),
+			Tags:                mergeTags("", tagsEntry.Text),
+			Description:         strings.TrimSpace(descriptionEntry.Text),
+		}
+		if text := strings.TrimSpace(priorityEntry.Text); text != "" {
+			priority, err := strconv.Atoi(text)
+			if err != nil {
+				return r, errors.New(T("неверный приоритет"))
+			}
+			r.Priority = priority
+		}
+		for _, bound := range []struct {
+			entry *widget.Entry
+			value *float64
+		}{{minEntry, &r.MinAmount}, {maxEntry, &r.MaxAmount}} {
+			if strings.TrimSpace(bound.entry.Text) == "" {
+				continue
+			}
+			amount, err := parseAmount(bound.entry.Text)
+			if err != nil {
+				return r, errors.New(T("неверный диапазон суммы"))
+			}
+			*bound.value = amount
+		}
+		if acc, ok := accountByName(accounts, accountSelect.Selected); ok {
+			r.AccountID = acc.ID
+		}
+		if typeSelect.Selected != anyType {
+			r.Type = typeCode(typeSelect.Selected)
+		}
+		return r, nil
+	}
+
+	rulesList := widget.NewList(
+		func() int { return len(rules) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			r := rules[i]
+			text := fmt.Sprintf("%d · %s", r.Priority, r.Name)
+			if !r.Enabled {
+				text += T(" (выключено)")
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	rulesList.OnSelected = func(i widget.ListItemID) { fillForm(rules[i]) }
+
+	update := func() {
+		var err error
+		rules, err = loadRules(db)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		rulesList.UnselectAll()
+		rulesList.Refresh()
+	}
+
+	previewLabel := widget.NewLabel("")
+	previewContainer := container.NewVBox()
+
+	newButton := widget.NewButtonWithIcon(T("Новое правило"), theme.ContentAddIcon(), func() {
+		rulesList.UnselectAll()
+		fillForm(Rule{Enabled: true})
+	})
+	saveButton := widget.NewButtonWithIcon(T("Сохранить"), theme.DocumentSaveIcon(), func() {
+		r, err := readForm()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		id, err := saveRule(db, r)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		r.ID = id
+		update()
+		fillForm(r)
+	})
+	deleteButton := widget.NewButtonWithIcon(T("Удалить"), theme.DeleteIcon(), func() {
+		if editing.ID == 0 {
+			return
+		}
+		dialog.ShowConfirm(T("Удаление"), Tf("Удалить правило «%s»?", editing.Name), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := deleteRule(db, editing.ID); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			update()
+			fillForm(Rule{Enabled: true})
+		}, window)
+	})
+	testButton := widget.NewButtonWithIcon(T("Проверить на истории"), theme.SearchIcon(), func() {
+		r, err := readForm()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		matches, err := testRule(db, r)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		previewLabel.SetText(Tf("Подходит транзакций: %d", len(matches)))
+		previewContainer.Objects = nil
+		for _, m := range matches {
+			line := fmt.Sprintf("%s | %s | %s | %s", formatDate(m.Before.Date), money(m.Before.Amount),
+				m.Before.Description, m.Before.Category)
+			line += " → " + m.After.Category
+			if m.After.Description != m.Before.Description {
+				line += " | " + m.After.Description
+			}
+			if m.After.Tags != m.Before.Tags {
+				line += " | " + m.After.Tags
+			}
+			previewContainer.Add(widget.NewLabel(line))
+		}
+		previewContainer.Refresh()
+	})
+
+	form := container.NewVBox(
+		widget.NewLabelWithStyle(T("Правило"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		nameEntry,
+		container.NewGridWithColumns(2, priorityEntry, enabledCheck),
+		widget.NewLabelWithStyle(T("Условия"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		containsEntry,
+		regexEntry,
+		container.NewGridWithColumns(2, minEntry, maxEntry),
+		container.NewGridWithColumns(2, accountSelect, typeSelect),
+		widget.NewLabelWithStyle(T("Действия"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		categoryEntry,
+		tagsEntry,
+		descriptionEntry,
+		container.NewHBox(newButton, saveButton, deleteButton, testButton),
+		previewLabel,
+	)
+
+	update()
+	fillForm(Rule{Enabled: true})
+
+	right := container.NewBorder(form, nil, nil, nil, container.NewScroll(previewContainer))
+	split := container.NewHSplit(rulesList, right)
+	split.SetOffset(0.3)
+	window.SetContent(split)
+	return window
+}
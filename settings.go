@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// appID нужен fyne, чтобы хранить настройки между запусками
+const appID = "io.github.lliill1.finance_tracker"
+
+// Ключи настроек (fyne Preferences)
+const (
+	prefDarkTheme       = "theme.dark"
+	prefDefaultType     = "transaction.default_type"
+	prefStatsPeriod     = "statistics.period"
+	prefExportPeriod    = "export.period"
+	prefExportFormat    = "export.format"
+	prefExportDir       = "export.dir"
+	prefDefaultCurrency = "currency.default"
+	prefWindowSizes     = "window.sizes" // имена окон, размеры которых запомнены
+)
+
+const defaultCurrency = "RUB"
+
+var exportFormats = []string{"CSV", "JSON"}
+
+func preferences() fyne.Preferences {
+	return fyne.CurrentApp().Preferences()
+}
+
+// prefChoice возвращает сохраненное значение, если оно есть среди options
+func prefChoice(key string, options []string, fallback string) string {
+	value := preferences().StringWithFallback(key, fallback)
+	for _, o := range options {
+		if o == value {
+			return value
+		}
+	}
+	return fallback
+}
+
+func preferredDefaultCurrency() string {
+	return preferences().StringWithFallback(prefDefaultCurrency, defaultCurrency)
+}
+
+// restoreWindowSize задает окну сохраненный размер (или size) и запоминает
+// размер при закрытии окна
+func restoreWindowSize(w fyne.Window, name string, size fyne.Size) {
+	prefs := preferences()
+	width := prefs.FloatWithFallback("window."+name+".width", float64(size.Width))
+	height := prefs.FloatWithFallback("window."+name+".height", float64(size.Height))
+	w.Resize(fyne.NewSize(float32(width), float32(height)))
+
+	w.SetOnClosed(func() {
+		current := w.Canvas().Size()
+		if current.Width <= 0 || current.Height <= 0 {
+			return
+		}
+		prefs.SetFloat("window."+name+".width", float64(current.Width))
+		prefs.SetFloat("window."+name+".height", float64(current.Height))
+		names := prefs.StringList(prefWindowSizes)
+		for _, n := range names {
+			if n == name {
+				return
+			}
+		}
+		prefs.SetStringList(prefWindowSizes, append(names, name))
+	})
+}
+
+// resetWindowSizes забывает размеры всех окон
+func resetWindowSizes() {
+	prefs := preferences()
+	for _, name := range prefs.StringList(prefWindowSizes) {
+		prefs.RemoveValue("window." + name + ".width")
+		prefs.RemoveValue("window." + name + ".height")
+	}
+	prefs.RemoveValue(prefWindowSizes)
+}
+
+// exportLocation — каталог последнего экспорта, если он еще существует
+func exportLocation() fyne.ListableURI {
+	dir := preferences().String(prefExportDir)
+	if dir == "" {
+		return nil
+	}
+	location, err := storage.ListerForURI(storage.NewFileURI(dir))
+	if err != nil {
+		return nil
+	}
+	return location
+}
+
+func rememberExportLocation(uri fyne.URI) {
+	parent, err := storage.Parent(uri)
+	if err != nil || parent == nil {
+		return
+	}
+	preferences().SetString(prefExportDir, parent.Path())
+}
+
+// settingsWindow — окно настроек; onThemeChange вызывается при смене темы,
+// чтобы главное окно обновило свой переключатель, onLanguageChange - при смене языка
+func settingsWindow(a fyne.App, onThemeChange func(dark bool), onLanguageChange func(code string)) fyne.Window {
+	window := a.NewWindow(T("Настройки"))
+	restoreWindowSize(window, "settings", fyne.NewSize(500, 450))
+	prefs := a.Preferences()
+
+	darkCheck := widget.NewCheck(T("Темная тема"), nil)
+	darkCheck.SetChecked(prefs.BoolWithFallback(prefDarkTheme, true))
+
+	languageSelect := widget.NewSelect(languageLabels(), nil)
+	languageSelect.SetSelected(languageLabel(currentLanguage))
+
+	typeSelect := widget.NewSelect([]string{typeLabel(typeIncome), typeLabel(typeExpense)}, nil)
+	typeSelect.SetSelected(typeLabel(prefChoice(prefDefaultType, []string{typeIncome, typeExpense}, typeExpense)))
+
+	statsPeriodSelect := widget.NewSelect(translateAll(periodOptions), nil)
+	statsPeriodSelect.SetSelected(T(prefChoice(prefStatsPeriod, periodOptions, periodAll)))
+
+	exportPeriodSelect := widget.NewSelect(translateAll(periodOptions), nil)
+	exportPeriodSelect.SetSelected(T(prefChoice(prefExportPeriod, periodOptions, periodAll)))
+
+	formatSelect := widget.NewSelect(exportFormats, nil)
+	formatSelect.SetSelected(prefChoice(prefExportFormat, exportFormats, exportFormats[0]))
+
+	currencyEntry := widget.NewEntry()
+	currencyEntry.SetText(preferredDefaultCurrency())
+
+	exportDirLabel := widget.NewLabel(prefs.StringWithFallback(prefExportDir, T("не выбран")))
+
+	saveButton := widget.NewButton(T("Сохранить"), func() {
+		currency := strings.ToUpper(strings.TrimSpace(currencyEntry.Text))
+		if len(currency) != 3 {
+			dialog.ShowError(errors.New(T("код валюты должен состоять из трех букв, например RUB")), window)
+			return
+		}
+		prefs.SetBool(prefDarkTheme, darkCheck.Checked)
+		prefs.SetString(prefDefaultType, typeCode(typeSelect.Selected))
+		prefs.SetString(prefStatsPeriod, untranslate(statsPeriodSelect.Selected, periodOptions))
+		prefs.SetString(prefExportPeriod, untranslate(exportPeriodSelect.Selected, periodOptions))
+		prefs.SetString(prefExportFormat, formatSelect.Selected)
+		prefs.SetString(prefDefaultCurrency, currency)
+
+		a.Settings().SetTheme(newCustomTheme(darkCheck.Checked))
+		if onThemeChange != nil {
+			onThemeChange(darkCheck.Checked)
+		}
+		window.Close()
+		if onLanguageChange != nil {
+			onLanguageChange(languageCode(languageSelect.Selected))
+		}
+	})
+
+	resetButton := widget.NewButton(T("Сбросить размеры окон"), func() {
+		resetWindowSizes()
+		dialog.ShowInformation(T("Настройки"), T("Размеры окон сброшены"), window)
+	})
+
+	form := widget.NewForm(
+		widget.NewFormItem(T("Язык"), languageSelect),
+		widget.NewFormItem(T("Оформление"), darkCheck),
+		widget.NewFormItem(T("Тип транзакции"), typeSelect),
+		widget.NewFormItem(T("Период статистики"), statsPeriodSelect),
+		widget.NewFormItem(T("Период экспорта"), exportPeriodSelect),
+		widget.NewFormItem(T("Формат экспорта"), formatSelect),
+		widget.NewFormItem(T("Каталог экспорта"), exportDirLabel),
+		widget.NewFormItem(T("Валюта новых счетов"), currencyEntry),
+	)
+
+	window.SetContent(container.NewVBox(
+		widget.NewLabelWithStyle(T("Настройки"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		form,
+		resetButton,
+		saveButton,
+	))
+	return window
+}
@@ -0,0 +1,547 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// SplitLine — часть транзакции со своей категорией, суммой и заметкой
+type SplitLine struct {
+	Category string
+	Amount   float64
+	Note     string
+}
+
+// toCents переводит сумму в копейки, чтобы сравнивать суммы без ошибок округления
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// validateSplits проверяет, что части заполнены и в сумме дают итог транзакции
+func validateSplits(total float64, lines []SplitLine) error {
+	if len(lines) < 2 {
+		return errors.New(T("транзакцию нужно разделить минимум на две части"))
+	}
+	var sum int64
+	for i, line := range lines {
+		if line.Category == "" {
+			return fmt.Errorf(T("не указана категория в части %d"), i+1)
+		}
+		if line.Amount <= 0 {
+			return fmt.Errorf(T("неверная сумма в части %d"), i+1)
+		}
+		sum += toCents(line.Amount)
+	}
+	if sum != toCents(total) {
+		return fmt.Errorf(T("сумма частей (%s) не совпадает с суммой транзакции (%s)"),
+			money(float64(sum)/100), money(total))
+	}
+	return nil
+}
+
+// insertTransaction сохраняет транзакцию вместе с её частями в одной SQL-транзакции
+func insertTransaction(db *sql.DB, t Transaction) (int64, error) {
+	t.ID = 0
+	return storeTransaction(db, t)
+}
+
+// restoreTransaction возвращает удаленную транзакцию под прежним номером, чтобы
+// её история в журнале изменений и ссылки на неё оставались прежними
+func restoreTransaction(db *sql.DB, t Transaction) error {
+	if t.ID == 0 {
+		return errors.New(T("не указан номер транзакции"))
+	}
+	_, err := storeTransaction(db, t)
+	return err
+}
+
+// restoreTransactions возвращает несколько транзакций под прежними номерами разом:
+// либо все, либо ни одной
+func restoreTransactions(db *sql.DB, transactions []Transaction) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, t := range transactions {
+		if t.ID == 0 {
+			return errors.New(T("не указан номер транзакции"))
+		}
+		if _, err := storeTransactionTx(tx, t); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, t := range transactions {
+		learnTransaction(db, t)
+	}
+	return nil
+}
+
+// storeTransaction добавляет строку транзакции; номер задается, только если он указан
+func storeTransaction(db *sql.DB, t Transaction) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := storeTransactionTx(tx, t)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	learnTransaction(db, t)
+	return id, nil
+}
+
+// insertTransactionTx сохраняет новую транзакцию в уже начатой SQL-транзакции, чтобы
+// она и связанные с ней записи (сделка, взнос, платеж) появлялись только вместе.
+// Классификатор дообучается вызывающим после фиксации.
+func insertTransactionTx(tx *sql.Tx, t Transaction) (int64, error) {
+	t.ID = 0
+	return storeTransactionTx(tx, t)
+}
+
+func storeTransactionTx(tx *sql.Tx, t Transaction) (int64, error) {
+	if len(t.Splits) > 0 {
+		if err := validateSplits(t.Amount, t.Splits); err != nil {
+			return 0, err
+		}
+		// У разделённой транзакции категории хранятся только в частях
+		t.Category = ""
+	}
+
+	// У перевода из портфеля нет счета списания
+	if t.AccountID == 0 && t.Type != typeTransfer {
+		t.AccountID = defaultAccountID
+	}
+
+	var id interface{}
+	if t.ID != 0 {
+		id = t.ID
+	}
+	res, err := tx.Exec(`INSERT INTO transactions (id, date, category, amount, description, type, account_id, transfer_account_id, tags, payee_id, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, t.Date, t.Category, t.Amount, t.Description, t.Type, t.AccountID, t.TransferAccountID, t.Tags, t.PayeeID, t.Status)
+	if err != nil {
+		return 0, err
+	}
+	newID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range t.Splits {
+		_, err := tx.Exec(`INSERT INTO transaction_splits (transaction_id, category, amount, note) VALUES (?, ?, ?, ?)`,
+			newID, line.Category, line.Amount, line.Note)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return newID, nil
+}
+
+// loadTransaction возвращает одну транзакцию вместе с частями
+// missingTransactionError — транзакции с таким номером нет; по нему API отвечает 404
+type missingTransactionError struct {
+	id int
+}
+
+func (e missingTransactionError) Error() string {
+	return Tf("транзакция %d не найдена", e.id)
+}
+
+func loadTransaction(db *sql.DB, id int) (Transaction, error) {
+	var t Transaction
+	err := db.QueryRow(`
+		SELECT id, date, type, category, amount, description, account_id, transfer_account_id, tags, payee_id, status
+		FROM transactions WHERE id = ?
+	`, id).Scan(&t.ID, &t.Date, &t.Type, &t.Category, &t.Amount, &t.Description, &t.AccountID,
+		&t.TransferAccountID, &t.Tags, &t.PayeeID, &t.Status)
+	if err == sql.ErrNoRows {
+		return t, missingTransactionError{id: id}
+	}
+	if err != nil {
+		return t, err
+	}
+	rows, err := db.Query("SELECT category, amount, note FROM transaction_splits WHERE transaction_id = ? ORDER BY id", id)
+	if err != nil {
+		return t, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line SplitLine
+		if err := rows.Scan(&line.Category, &line.Amount, &line.Note); err != nil {
+			continue
+		}
+		t.Splits = append(t.Splits, line)
+	}
+	return t, rows.Err()
+}
+
+// transactionOwners — записи, которые создают свои транзакции и на них ссылаются
+var transactionOwners = []struct {
+	Table, Column, Label string
+}{
+	{"security_trades", "transaction_id", "сделка с ценной бумагой"},
+	{"goal_contributions", "transaction_id", "взнос в цель"},
+	{"loan_payments", "interest_transaction_id", "платеж по кредиту"},
+	{"loan_payments", "principal_transaction_id", "платеж по кредиту"},
+}
+
+// linkedTransactionError возвращается при попытке отдельно изменить или удалить
+// транзакцию сделки, взноса или платежа: иначе портфель, цель или график кредита
+// разошлись бы с учетом
+type linkedTransactionError struct {
+	owner string
+}
+
+func (e linkedTransactionError) Error() string {
+	return Tf("транзакция создана как %s; изменять и удалять её отдельно нельзя", T(e.owner))
+}
+
+// checkTransactionOwner проверяет, что на транзакцию не ссылается другая запись
+func checkTransactionOwner(db *sql.DB, id int) error {
+	for _, o := range transactionOwners {
+		var n int
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", o.Table, o.Column)
+		if err := db.QueryRow(query, id).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			return linkedTransactionError{owner: o.Label}
+		}
+	}
+	return nil
+}
+
+// updateTransaction сохраняет изменения транзакции и заменяет её части.
+// Сверенную с банком транзакцию изменить нельзя, пока не снята отметка сверки,
+// а транзакцию сделки, взноса или платежа - вообще.
+func updateTransaction(db *sql.DB, t Transaction) error {
+	before, err := loadTransaction(db, t.ID)
+	if err != nil {
+		return err
+	}
+	if before.Status == statusReconciled {
+		return errTransactionLocked
+	}
+	if err := checkTransactionOwner(db, t.ID); err != nil {
+		return err
+	}
+	if len(t.Splits) > 0 {
+		if err := validateSplits(t.Amount, t.Splits); err != nil {
+			return err
+		}
+		t.Category = ""
+	}
+	if t.AccountID == 0 && t.Type != typeTransfer {
+		t.AccountID = defaultAccountID
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE transactions SET date = ?, category = ?, amount = ?, description = ?, type = ?, account_id = ?,
+			transfer_account_id = ?, tags = ?, payee_id = ?, status = ?
+		WHERE id = ?
+	`, t.Date, t.Category, t.Amount, t.Description, t.Type, t.AccountID, t.TransferAccountID, t.Tags, t.PayeeID, t.Status, t.ID)
+	if err != nil {
+		return err
+	}
+	// Части переписываются, только если изменились, чтобы журнал не копил пустые правки
+	if !sameSplits(before.Splits, t.Splits) {
+		if _, err := tx.Exec("DELETE FROM transaction_splits WHERE transaction_id = ?", t.ID); err != nil {
+			return err
+		}
+		for _, line := range t.Splits {
+			_, err := tx.Exec(`INSERT INTO transaction_splits (transaction_id, category, amount, note) VALUES (?, ?, ?, ?)`,
+				t.ID, line.Category, line.Amount, line.Note)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	relearnTransaction(db, before, t)
+	return nil
+}
+
+func sameSplits(a, b []SplitLine) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Category != b[i].Category || toCents(a[i].Amount) != toCents(b[i].Amount) || a[i].Note != b[i].Note {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteTransaction удаляет транзакцию вместе с частями; сверенную или созданную
+// сделкой, взносом или платежом удалить нельзя
+func deleteTransaction(db *sql.DB, id int) error {
+	return deleteTransactions(db, []int{id})
+}
+
+// deleteTransactions удаляет транзакции одной SQL-транзакцией: если хоть одну
+// удалить нельзя, не удаляется ни одна
+func deleteTransactions(db *sql.DB, ids []int) error {
+	deleted := make([]Transaction, 0, len(ids))
+	for _, id := range ids {
+		before, err := loadTransaction(db, id)
+		if err != nil {
+			return err
+		}
+		if before.Status == statusReconciled {
+			return errTransactionLocked
+		}
+		if err := checkTransactionOwner(db, id); err != nil {
+			return err
+		}
+		deleted = append(deleted, before)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM transaction_splits WHERE transaction_id = ?", id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM transactions WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, t := range deleted {
+		unlearnTransaction(db, t)
+	}
+	return nil
+}
+
+// loadSplits возвращает части всех разделённых транзакций, сгруппированные по ID транзакции
+func loadSplits(db *sql.DB) (map[int][]SplitLine, error) {
+	rows, err := db.Query("SELECT transaction_id, category, amount, note FROM transaction_splits ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	splits := make(map[int][]SplitLine)
+	for rows.Next() {
+		var id int
+		var line SplitLine
+		if err := rows.Scan(&id, &line.Category, &line.Amount, &line.Note); err != nil {
+			continue
+		}
+		splits[id] = append(splits[id], line)
+	}
+	return splits, rows.Err()
+}
+
+// loadTransactions возвращает транзакции за период вместе с их частями, новые первыми
+func loadTransactions(db *sql.DB, period periodFilter) ([]Transaction, error) {
+	cond, args, err := period.condition("date")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT id, date, type, category, amount, description, account_id, transfer_account_id, tags, payee_id, status FROM transactions WHERE "+cond+" ORDER BY date DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.Date, &t.Type, &t.Category, &t.Amount, &t.Description, &t.AccountID, &t.TransferAccountID, &t.Tags, &t.PayeeID, &t.Status); err != nil {
+			continue
+		}
+		transactions = append(transactions, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	splits, err := loadSplits(db)
+	if err != nil {
+		return nil, err
+	}
+	for i := range transactions {
+		transactions[i].Splits = splits[transactions[i].ID]
+	}
+	return transactions, nil
+}
+
+// transactionCategories — категории всех переданных транзакций без повторов,
+// например до и после изменения, чтобы проверить бюджеты каждой из них
+func transactionCategories(transactions ...Transaction) []string {
+	var categories []string
+	seen := make(map[string]bool)
+	for _, t := range transactions {
+		for _, line := range transactionLines(t) {
+			if !seen[line.Category] {
+				seen[line.Category] = true
+				categories = append(categories, line.Category)
+			}
+		}
+	}
+	return categories
+}
+
+// transactionLines разворачивает транзакцию в строки: по одной на каждую часть
+// или одну строку, если транзакция не разделена
+func transactionLines(t Transaction) []SplitLine {
+	if len(t.Splits) > 0 {
+		return t.Splits
+	}
+	return []SplitLine{{Category: t.Category, Amount: t.Amount}}
+}
+
+type splitRow struct {
+	category *widget.Entry
+	amount   *widget.Entry
+	note     *widget.Entry
+}
+
+// splitEditor — блок формы для разделения транзакции по категориям
+type splitEditor struct {
+	rows          []*splitRow
+	rowsContainer *fyne.Container
+	remaining     *widget.Label
+	totalEntry    *widget.Entry
+	categoryEntry *widget.Entry
+	content       *fyne.Container
+	// OnChanged вызывается при любом изменении частей, чтобы форма перепроверила себя
+	OnChanged func()
+}
+
+func newSplitEditor(totalEntry, categoryEntry *widget.Entry) *splitEditor {
+	e := &splitEditor{
+		rowsContainer: container.NewVBox(),
+		remaining:     widget.NewLabel(""),
+		totalEntry:    totalEntry,
+		categoryEntry: categoryEntry,
+	}
+	e.remaining.Hide()
+
+	addButton := widget.NewButtonWithIcon(T("Разделить по категориям"), theme.ContentAddIcon(), func() {
+		if len(e.rows) == 0 {
+			// Первая часть наследует категорию, введённую для всей транзакции
+			e.addRow(categoryEntry.Text)
+		}
+		e.addRow("")
+	})
+
+	e.content = container.NewVBox(e.rowsContainer, e.remaining, addButton)
+	previous := totalEntry.OnChanged
+	totalEntry.OnChanged = func(text string) {
+		if previous != nil {
+			previous(text)
+		}
+		e.updateRemaining()
+	}
+	return e
+}
+
+func (e *splitEditor) addRow(category string) {
+	row := &splitRow{
+		category: widget.NewEntry(),
+		amount:   widget.NewEntry(),
+		note:     widget.NewEntry(),
+	}
+	row.category.SetPlaceHolder(T("Категория"))
+	row.category.SetText(category)
+	row.category.OnChanged = func(string) { e.updateRemaining() }
+	row.amount.SetPlaceHolder(T("Сумма"))
+	row.amount.OnChanged = func(string) { e.updateRemaining() }
+	row.note.SetPlaceHolder(T("Заметка"))
+
+	var line *fyne.Container
+	removeButton := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		for i, r := range e.rows {
+			if r == row {
+				e.rows = append(e.rows[:i], e.rows[i+1:]...)
+				break
+			}
+		}
+		e.rowsContainer.Remove(line)
+		e.updateRemaining()
+	})
+	line = container.NewBorder(nil, nil, nil, removeButton,
+		container.NewGridWithColumns(3, row.category, row.amount, row.note))
+
+	e.rows = append(e.rows, row)
+	e.rowsContainer.Add(line)
+	e.updateRemaining()
+}
+
+// SetLines заполняет редактор частями сохраненной транзакции
+func (e *splitEditor) SetLines(lines []SplitLine) {
+	for _, line := range lines {
+		e.addRow(line.Category)
+		row := e.rows[len(e.rows)-1]
+		row.amount.SetText(formatNumber(line.Amount))
+		row.note.SetText(line.Note)
+	}
+}
+
+// updateRemaining показывает, сколько ещё осталось распределить по частям
+func (e *splitEditor) updateRemaining() {
+	if e.OnChanged != nil {
+		defer e.OnChanged()
+	}
+	if len(e.rows) == 0 {
+		e.remaining.Hide()
+		e.categoryEntry.Enable()
+		return
+	}
+	e.categoryEntry.Disable()
+
+	total, _ := parseAmount(e.totalEntry.Text)
+	var assigned float64
+	for _, row := range e.rows {
+		amount, _ := parseAmount(row.amount.Text)
+		assigned += amount
+	}
+	e.remaining.SetText(Tf("Не распределено: %s", money(total-assigned)))
+	e.remaining.Show()
+}
+
+// Lines возвращает введённые части; пустой срез означает, что транзакция не разделена
+func (e *splitEditor) Lines() ([]SplitLine, error) {
+	var lines []SplitLine
+	for i, row := range e.rows {
+		amount, err := parseAmount(row.amount.Text)
+		if err != nil {
+			return nil, fmt.Errorf(T("неверная сумма в части %d"), i+1)
+		}
+		lines = append(lines, SplitLine{
+			Category: row.category.Text,
+			Amount:   amount,
+			Note:     row.note.Text,
+		})
+	}
+	return lines, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB создает пустую базу со всеми таблицами во временном каталоге теста
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := openSQLite(filepath.Join(t.TempDir(), "finance.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		forgetClassifier(db)
+		forgetHistory(db)
+		db.Close()
+	})
+	if err := createTable(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestValidateSplits(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   float64
+		lines   []SplitLine
+		wantErr bool
+	}{
+		{
+			name:  "две части",
+			total: 1000,
+			lines: []SplitLine{{Category: "Продукты", Amount: 700}, {Category: "Хозтовары", Amount: 300}},
+		},
+		{
+			name:  "копейки без ошибки округления",
+			total: 0.3,
+			lines: []SplitLine{{Category: "А", Amount: 0.1}, {Category: "Б", Amount: 0.2}},
+		},
+		{
+			name:  "три части с заметками",
+			total: 100.01,
+			lines: []SplitLine{
+				{Category: "А", Amount: 33.33, Note: "первая"},
+				{Category: "Б", Amount: 33.34},
+				{Category: "В", Amount: 33.34},
+			},
+		},
+		{
+			name:    "одна часть",
+			total:   100,
+			lines:   []SplitLine{{Category: "А", Amount: 100}},
+			wantErr: true,
+		},
+		{
+			name:    "нет частей",
+			total:   100,
+			wantErr: true,
+		},
+		{
+			name:    "часть без категории",
+			total:   100,
+			lines:   []SplitLine{{Category: "А", Amount: 50}, {Amount: 50}},
+			wantErr: true,
+		},
+		{
+			name:    "нулевая часть",
+			total:   100,
+			lines:   []SplitLine{{Category: "А", Amount: 100}, {Category: "Б"}},
+			wantErr: true,
+		},
+		{
+			name:    "отрицательная часть",
+			total:   100,
+			lines:   []SplitLine{{Category: "А", Amount: 150}, {Category: "Б", Amount: -50}},
+			wantErr: true,
+		},
+		{
+			name:    "сумма частей меньше итога",
+			total:   100,
+			lines:   []SplitLine{{Category: "А", Amount: 50}, {Category: "Б", Amount: 49.99}},
+			wantErr: true,
+		},
+		{
+			name:    "сумма частей больше итога",
+			total:   100,
+			lines:   []SplitLine{{Category: "А", Amount: 50}, {Category: "Б", Amount: 50.01}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSplits(tt.total, tt.lines)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ошибка %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSplitTransactionRoundTrip(t *testing.T) {
+	db := openTestDB(t)
+	saved := Transaction{
+		Date: "2026-10-01", Type: typeExpense, Amount: 1000, Category: "Игнорируется",
+		Splits: []SplitLine{{Category: "Продукты", Amount: 700, Note: "овощи"}, {Category: "Хозтовары", Amount: 300}},
+	}
+	id, err := insertTransaction(db, saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadTransaction(db, int(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Category != "" {
+		t.Errorf("у разделенной транзакции осталась категория %q", got.Category)
+	}
+	if len(got.Splits) != 2 || got.Splits[0] != saved.Splits[0] || got.Splits[1] != saved.Splits[1] {
+		t.Errorf("части %+v, ожидалось %+v", got.Splits, saved.Splits)
+	}
+
+	// Части, не сходящиеся с итогом, не сохраняются
+	got.Amount = 999
+	if err := updateTransaction(db, got); err == nil {
+		t.Error("сохранена транзакция с частями, не сходящимися с суммой")
+	}
+	if _, err := loadTransaction(db, int(id)+1); !errors.As(err, &missingTransactionError{}) {
+		t.Errorf("для несуществующей транзакции получено %v", err)
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// undoLimit — сколько последних действий можно отменить
+const undoLimit = 100
+
+// command — отменяемое действие с данными. Do выполняет его (и повторяет после
+// отмены), Undo возвращает данные в состояние до Do.
+type command interface {
+	Do(db *sql.DB) error
+	Undo(db *sql.DB) error
+	Label() string
+}
+
+// undoHistory — стеки отмены и повтора одной базы
+type undoHistory struct {
+	mu       sync.Mutex
+	undo     []command
+	redo     []command
+	onChange func()
+}
+
+// Истории открытых баз живут до конца сеанса, а не до закрытия окна, в котором
+// было сделано действие
+var (
+	historiesMu sync.Mutex
+	histories   = make(map[*sql.DB]*undoHistory)
+)
+
+func historyFor(db *sql.DB) *undoHistory {
+	historiesMu.Lock()
+	defer historiesMu.Unlock()
+	h, ok := histories[db]
+	if !ok {
+		h = &undoHistory{}
+		histories[db] = h
+	}
+	return h
+}
+
+// forgetHistory освобождает историю закрываемой базы
+func forgetHistory(db *sql.DB) {
+	historiesMu.Lock()
+	delete(histories, db)
+	historiesMu.Unlock()
+}
+
+// runCommand выполняет действие и запоминает его для отмены; новое действие
+// обнуляет стек повтора
+func runCommand(db *sql.DB, c command) error {
+	if err := c.Do(db); err != nil {
+		return err
+	}
+	h := historyFor(db)
+	h.mu.Lock()
+	h.undo = append(h.undo, c)
+	if len(h.undo) > undoLimit {
+		h.undo = h.undo[len(h.undo)-undoLimit:]
+	}
+	h.redo = nil
+	h.mu.Unlock()
+	h.changed()
+	return nil
+}
+
+// undoCommand отменяет последнее действие. Если отменить не удалось (например,
+// транзакцию с тех пор сверили), действие остается в стеке.
+func undoCommand(db *sql.DB) error {
+	h := historyFor(db)
+	h.mu.Lock()
+	if len(h.undo) == 0 {
+		h.mu.Unlock()
+		return errors.New(T("нечего отменять"))
+	}
+	c := h.undo[len(h.undo)-1]
+	h.mu.Unlock()
+
+	if err := c.Undo(db); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, c)
+	h.mu.Unlock()
+	h.changed()
+	return nil
+}
+
+// redoCommand повторяет последнее отмененное действие
+func redoCommand(db *sql.DB) error {
+	h := historyFor(db)
+	h.mu.Lock()
+	if len(h.redo) == 0 {
+		h.mu.Unlock()
+		return errors.New(T("нечего повторять"))
+	}
+	c := h.redo[len(h.redo)-1]
+	h.mu.Unlock()
+
+	if err := c.Do(db); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, c)
+	h.mu.Unlock()
+	h.changed()
+	return nil
+}
+
+// labels возвращает подписи действий, которые отменятся и повторятся следующими
+func (h *undoHistory) labels() (undo, redo string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.undo) > 0 {
+		undo = h.undo[len(h.undo)-1].Label()
+	}
+	if len(h.redo) > 0 {
+		redo = h.redo[len(h.redo)-1].Label()
+	}
+	return undo, redo
+}
+
+func (h *undoHistory) changed() {
+	h.mu.Lock()
+	onChange := h.onChange
+	h.mu.Unlock()
+	if onChange != nil {
+		onChange()
+	}
+}
+
+// addTransactionCommand добавляет транзакцию; повтор возвращает её под тем же номером
+type addTransactionCommand struct {
+	Transaction Transaction
+}
+
+func (c *addTransactionCommand) Do(db *sql.DB) error {
+	if c.Transaction.ID != 0 {
+		return restoreTransaction(db, c.Transaction)
+	}
+	id, err := insertTransaction(db, c.Transaction)
+	if err != nil {
+		return err
+	}
+	c.Transaction.ID = int(id)
+	return nil
+}
+
+func (c *addTransactionCommand) Undo(db *sql.DB) error {
+	return deleteTransaction(db, c.Transaction.ID)
+}
+
+func (c *addTransactionCommand) Label() string {
+	return Tf("добавление транзакции %s", money(c.Transaction.Amount))
+}
+
+// editTransactionCommand сохраняет изменения транзакции, запомнив её прежний вид
+type editTransactionCommand struct {
+	Before, After Transaction
+	loaded        bool
+}
+
+func (c *editTransactionCommand) Do(db *sql.DB) error {
+	if !c.loaded {
+		before, err := loadTransaction(db, c.After.ID)
+		if err != nil {
+			return err
+		}
+		c.Before, c.loaded = before, true
+		return updateTransaction(db, c.After)
+	}
+	return updateKeepingStatus(db, c.After)
+}
+
+func (c *editTransactionCommand) Undo(db *sql.DB) error {
+	return updateKeepingStatus(db, c.Before)
+}
+
+// updateKeepingStatus сохраняет поля транзакции, но не отметку сверки: её ставят
+// и снимают отдельно, и отмена или повтор правки не должны менять её задним числом
+func updateKeepingStatus(db *sql.DB, t Transaction) error {
+	current, err := loadTransaction(db, t.ID)
+	if err != nil {
+		return err
+	}
+	t.Status = current.Status
+	return updateTransaction(db, t)
+}
+
+func (c *editTransactionCommand) Label() string {
+	return Tf("изменение транзакции %s", money(c.After.Amount))
+}
+
+// deleteTransactionCommand удаляет транзакцию; отмена возвращает её под тем же номером
+type deleteTransactionCommand struct {
+	ID          int
+	Transaction Transaction
+}
+
+func (c *deleteTransactionCommand) Do(db *sql.DB) error {
+	t, err := loadTransaction(db, c.ID)
+	if err != nil {
+		return err
+	}
+	if err := deleteTransaction(db, c.ID); err != nil {
+		return err
+	}
+	c.Transaction = t
+	return nil
+}
+
+func (c *deleteTransactionCommand) Undo(db *sql.DB) error {
+	return restoreTransaction(db, c.Transaction)
+}
+
+func (c *deleteTransactionCommand) Label() string {
+	return Tf("удаление транзакции %s", money(c.Transaction.Amount))
+}
+
+// budgetCommand задает лимит категории; отмена возвращает прежний лимит или убирает новый
+type budgetCommand struct {
+	Budget  Budget
+	Before  Budget
+	Existed bool
+	loaded  bool
+}
+
+func (c *budgetCommand) Do(db *sql.DB) error {
+	if !c.loaded {
+		before, existed, err := findBudget(db, c.Budget.Category)
+		if err != nil {
+			return err
+		}
+		c.Before, c.Existed, c.loaded = before, existed, true
+	}
+	return saveBudget(db, c.Budget)
+}
+
+func (c *budgetCommand) Undo(db *sql.DB) error {
+	if c.Existed {
+		return saveBudget(db, c.Before)
+	}
+	return deleteBudget(db, c.Budget.Category)
+}
+
+func (c *budgetCommand) Label() string {
+	return Tf("изменение бюджета «%s»", c.Budget.Category)
+}
+
+// mergeCategoriesCommand вливает одну категорию в другую
+type mergeCategoriesCommand struct {
+	From, To string
+	merge    CategoryMerge
+}
+
+func (c *mergeCategoriesCommand) Do(db *sql.DB) error {
+	merge, err := mergeCategories(db, c.From, c.To)
+	if err != nil {
+		return err
+	}
+	c.merge = merge
+	return nil
+}
+
+func (c *mergeCategoriesCommand) Undo(db *sql.DB) error {
+	return unmergeCategories(db, c.merge)
+}
+
+func (c *mergeCategoriesCommand) Label() string {
+	return Tf("объединение категорий «%s» и «%s»", c.From, c.To)
+}
+
+// importCommand сохраняет транзакции из файла; отмена удаляет весь импорт разом
+type importCommand struct {
+	Transactions []Transaction
+	saved        []Transaction
+}
+
+func (c *importCommand) Do(db *sql.DB) error {
+	if c.saved != nil {
+		return restoreTransactions(db, c.saved)
+	}
+	saved, err := importTransactions(db, c.Transactions)
+	if err != nil {
+		return err
+	}
+	c.saved = saved
+	return nil
+}
+
+func (c *importCommand) Undo(db *sql.DB) error {
+	// Если хоть одну транзакцию уже сверили или к ней привязали взнос, импорт не трогаем вовсе
+	ids := make([]int, 0, len(c.saved))
+	for _, t := range c.saved {
+		ids = append(ids, t.ID)
+	}
+	return deleteTransactions(db, ids)
+}
+
+func (c *importCommand) Label() string {
+	return Tf("импорт транзакций: %d", len(c.Transactions))
+}
+
+// undoControls — кнопки отмены и повтора на главном окне с подписью действия.
+// Те же действия вызываются сочетаниями Ctrl+Z и Ctrl+Shift+Z.
+func undoControls(s *session) fyne.CanvasObject {
+	undoButton := widget.NewButtonWithIcon(T("Отменить"), theme.ContentUndoIcon(), nil)
+	redoButton := widget.NewButtonWithIcon(T("Повторить"), theme.ContentRedoIcon(), nil)
+
+	update := func() {
+		undo, redo := historyFor(s.db).labels()
+		undoButton.SetText(T("Отменить"))
+		undoButton.Disable()
+		if undo != "" {
+			undoButton.SetText(T("Отменить") + ": " + undo)
+			undoButton.Enable()
+		}
+		redoButton.SetText(T("Повторить"))
+		redoButton.Disable()
+		if redo != "" {
+			redoButton.SetText(T("Повторить") + ": " + redo)
+			redoButton.Enable()
+		}
+	}
+	undoButton.OnTapped = func() { s.undo() }
+	redoButton.OnTapped = func() { s.redo() }
+
+	h := historyFor(s.db)
+	h.mu.Lock()
+	h.onChange = update
+	h.mu.Unlock()
+	update()
+	return container.NewHBox(undoButton, redoButton)
+}
+
+func (s *session) undo() {
+	if err := undoCommand(s.db); err != nil {
+		dialog.ShowError(err, s.window)
+	}
+}
+
+func (s *session) redo() {
+	if err := redoCommand(s.db); err != nil {
+		dialog.ShowError(err, s.window)
+	}
+}
+
+// addUndoShortcuts вешает отмену и повтор на главное окно. Обработчики берут
+// базу из сеанса, поэтому после смены профиля их не нужно перевешивать.
+func (s *session) addUndoShortcuts() {
+	canvas := s.window.Canvas()
+	canvas.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault},
+		func(fyne.Shortcut) { s.undo() })
+	canvas.AddShortcut(&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: fyne.KeyModifierShortcutDefault | fyne.KeyModifierShift},
+		func(fyne.Shortcut) { s.redo() })
+}
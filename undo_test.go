@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func countTransactions(t *testing.T, db dbExecutor) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM transactions").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// Отмена импорта удаляет все его транзакции или, если хоть одну удалить нельзя, ни одной
+func TestUndoImport(t *testing.T) {
+	imported := []Transaction{
+		{Date: "2026-09-01", Type: typeExpense, Category: "Еда", Amount: 100},
+		{Date: "2026-09-02", Type: typeExpense, Amount: 300, Splits: []SplitLine{
+			{Category: "Еда", Amount: 200}, {Category: "Дом", Amount: 100}}},
+		{Date: "2026-09-03", Type: typeIncome, Category: "Зарплата", Amount: 1000},
+	}
+	// Запрет ставится на последнюю транзакцию, чтобы первые успели бы удалиться
+	tests := []struct {
+		name         string
+		lock, unlock string
+	}{
+		{
+			name:   "сверенная транзакция",
+			lock:   "UPDATE transactions SET status = '" + statusReconciled + "' WHERE id = 3",
+			unlock: "UPDATE transactions SET status = '' WHERE id = 3",
+		},
+		{
+			name:   "взнос в цель",
+			lock:   "INSERT INTO goal_contributions (transaction_id) VALUES (3)",
+			unlock: "DELETE FROM goal_contributions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			if err := runCommand(db, &importCommand{Transactions: imported}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.Exec(tt.lock); err != nil {
+				t.Fatal(err)
+			}
+			if err := undoCommand(db); err == nil {
+				t.Fatal("импорт отменен, хотя одну из транзакций удалять нельзя")
+			}
+			if n := countTransactions(t, db); n != len(imported) {
+				t.Errorf("после неудачной отмены осталось %d транзакций из %d", n, len(imported))
+			}
+
+			if _, err := db.Exec(tt.unlock); err != nil {
+				t.Fatal(err)
+			}
+			if err := undoCommand(db); err != nil {
+				t.Fatal(err)
+			}
+			var splits int
+			if err := db.QueryRow("SELECT COUNT(*) FROM transaction_splits").Scan(&splits); err != nil {
+				t.Fatal(err)
+			}
+			if n := countTransactions(t, db); n != 0 || splits != 0 {
+				t.Errorf("после отмены осталось транзакций %d, частей %d", n, splits)
+			}
+			if err := redoCommand(db); err != nil {
+				t.Fatal(err)
+			}
+			if n := countTransactions(t, db); n != len(imported) {
+				t.Errorf("после повтора %d транзакций, ожидалось %d", n, len(imported))
+			}
+		})
+	}
+}
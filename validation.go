@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// formValidator проверяет поля формы при каждом изменении: ошибка показывается
+// под полем, а кнопка сохранения доступна, только когда все поля заполнены верно
+type formValidator struct {
+	fields []*validatedField
+	submit *widget.Button
+}
+
+type validatedField struct {
+	check   func() error
+	message *canvas.Text
+	touched bool // ошибку показываем только после того, как поле изменили
+}
+
+func newFormValidator(submit *widget.Button) *formValidator {
+	submit.Disable()
+	return &formValidator{submit: submit}
+}
+
+// add регистрирует проверку поля и возвращает поле вместе со строкой для ошибки
+func (v *formValidator) add(object fyne.CanvasObject, check func() error) (*validatedField, fyne.CanvasObject) {
+	message := canvas.NewText("", themeColor(theme.ColorNameError))
+	message.TextSize = theme.TextSize() * 0.85
+	message.Hide()
+	field := &validatedField{check: check, message: message}
+	v.fields = append(v.fields, field)
+	return field, container.NewVBox(object, message)
+}
+
+// changed отмечает поле измененным и заново проверяет форму
+func (v *formValidator) changed(field *validatedField) {
+	field.touched = true
+	v.validate()
+}
+
+// validate проверяет все поля и возвращает true, если форму можно сохранить
+func (v *formValidator) validate() bool {
+	valid := true
+	for _, field := range v.fields {
+		err := field.check()
+		if err != nil {
+			valid = false
+		}
+		if err != nil && field.touched {
+			field.message.Text = err.Error()
+			field.message.Show()
+		} else {
+			field.message.Hide()
+		}
+		field.message.Refresh()
+	}
+	if valid {
+		v.submit.Enable()
+	} else {
+		v.submit.Disable()
+	}
+	return valid
+}
+
+// requiredText — проверка обязательного текстового поля
+func requiredText(entry *widget.Entry, message string) func() error {
+	return func() error {
+		if entry.Text == "" {
+			return errors.New(T(message))
+		}
+		return nil
+	}
+}
+
+// positiveAmount — проверка поля суммы: число больше нуля
+func positiveAmount(entry *widget.Entry) func() error {
+	return func() error {
+		amount, err := parseAmount(entry.Text)
+		if err != nil || amount <= 0 {
+			return errors.New(T("введите сумму больше нуля"))
+		}
+		return nil
+	}
+}